@@ -2,8 +2,15 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"expvar"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"time"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -12,9 +19,23 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/shayja/orders-service/config"
 	"github.com/shayja/orders-service/docs"
+	"github.com/shayja/orders-service/internal/adapters/catalog"
 	"github.com/shayja/orders-service/internal/adapters/controllers"
+	"github.com/shayja/orders-service/internal/adapters/inventory"
 	"github.com/shayja/orders-service/internal/adapters/middleware"
+	"github.com/shayja/orders-service/internal/adapters/payments"
+	"github.com/shayja/orders-service/internal/adapters/repositories/cache"
 	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	paymentrepo "github.com/shayja/orders-service/internal/adapters/repositories/payments"
+	privacyrepo "github.com/shayja/orders-service/internal/adapters/repositories/privacy"
+	promotionrepo "github.com/shayja/orders-service/internal/adapters/repositories/promotions"
+	"github.com/shayja/orders-service/internal/adapters/repositories/replicas"
+	reportrepo "github.com/shayja/orders-service/internal/adapters/repositories/reports"
+	returnrepo "github.com/shayja/orders-service/internal/adapters/repositories/returns"
+	sagarepo "github.com/shayja/orders-service/internal/adapters/repositories/sagas"
+	"github.com/shayja/orders-service/internal/adapters/scheduler"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
 	"github.com/shayja/orders-service/internal/usecases"
 )
 
@@ -50,22 +71,79 @@ func main() {
 	// Load environment variables and Format the connection string to the database
 	// Connect to database
 	db := RegisterDb(cfg)
+	router := RegisterReplicas(cfg, db)
 
 	// Initialize repository, usecase, and controller
-	repo := &repositories.OrderRepository{Db: db}
-	usecase := &usecases.OrderUsecase{OrderRepo: repo}
+	repo := RegisterOrderCache(cfg, &repositories.OrderRepository{Db: db, Router: router})
+	promotionRepo := &promotionrepo.PromotionRepository{Db: db}
+	paymentRepo := &paymentrepo.PaymentRepository{Db: db}
+	returnRepo := &returnrepo.ReturnRepository{Db: db}
+	reportRepo := &reportrepo.ReportRepository{Db: db, Router: router}
+	// Only the in-process payment provider is available for now
+	gateway := &payments.FakeGateway{Secret: cfg.PaymentWebhookSecret}
+	usecase := &usecases.OrderUsecase{
+		OrderRepo:         repo,
+		BulkLimit:         cfg.BulkMaxOrders,
+		AllowedCurrencies: cfg.AllowedCurrencies,
+		Pricing:           usecases.FlatTaxCalculator{TaxRate: cfg.TaxRate},
+		PromotionRepo:     promotionRepo,
+		Catalog:           RegisterCatalog(cfg),
+		SagaRepo:          &sagarepo.SagaRepository{Db: db},
+		ReturnRepo:        returnRepo,
+		FulfilmentRepo:    &repositories.FulfilmentRepository{Db: db},
+		PendingTTL:        time.Duration(cfg.PendingOrderTTLMinutes) * time.Minute,
+		ArchiveAfter:      time.Duration(cfg.OrderRetentionDays) * 24 * time.Hour,
+		Tenants:           RegisterTenants(cfg),
+	}
+	if cfg.InventoryFile != "" {
+		stock, err := inventory.LoadFileInventory(cfg.InventoryFile)
+		if err != nil {
+			panic(err)
+		}
+		usecase.Inventory = stock
+	}
+	// The background jobs run over the orders of every tenant
+	RegisterJobs(usecase.ForTenant(entities.AllTenants)).Start(context.Background())
 	controller := &controllers.OrderController{OrderUsecase: usecase}
+	promotionController := &controllers.PromotionController{PromotionUsecase: &usecases.PromotionUsecase{PromotionRepo: promotionRepo}}
+	paymentController := &controllers.PaymentController{PaymentUsecase: &usecases.PaymentUsecase{PaymentRepo: paymentRepo, Gateway: gateway, OrderRepo: repo}}
+	returnController := &controllers.ReturnController{ReturnUsecase: &usecases.ReturnUsecase{
+		ReturnRepo:  returnRepo,
+		OrderRepo:   repo,
+		PaymentRepo: paymentRepo,
+		Gateway:     gateway,
+	}}
+
+	reportController := &controllers.ReportController{ReportUsecase: &usecases.ReportUsecase{ReportRepo: reportRepo}}
+	privacyController := &controllers.PrivacyController{PrivacyUsecase: &usecases.PrivacyUsecase{PrivacyRepo: &privacyrepo.PrivacyRepository{Db: db}, OrderRepo: repo}}
 
 	// Initialize Gin
 	r := gin.Default()
+	r.Use(middleware.BodyLimitMiddleware(cfg.MaxBodyBytes))
 
 	// Define your secret key for token validation
 	secretKey := cfg.AccessTokenSecret
+	// Tokens without a tenant_id claim belong to the default tenant
+	auth := middleware.AuthMiddleware(secretKey, cfg.DefaultTenantID)
 
 	//GenerateToken(secretKey)
 
+	// Requests are limited per user, or per client IP on the routes without a token
+	rateLimits := &middleware.MemoryRateLimitStore{}
+	rateLimit := func(group string, limit string) gin.HandlerFunc {
+		rate, err := middleware.ParseRateLimit(limit)
+		if err != nil {
+			panic(err)
+		}
+		return middleware.RateLimitMiddleware(group, rate, rateLimits)
+	}
+
 	// Register routes
-	RegisterRoutes(r, controller, secretKey)
+	RegisterRoutes(r, controller, auth, rateLimit("orders", cfg.RateLimitOrders))
+	RegisterPromotionRoutes(r, promotionController, auth, rateLimit("promotions", cfg.RateLimitPromotions))
+	RegisterPaymentRoutes(r, paymentController, auth, rateLimit("payments", cfg.RateLimitPayments), rateLimit("webhook", cfg.RateLimitWebhook))
+	RegisterReturnRoutes(r, returnController, auth, rateLimit("returns", cfg.RateLimitReturns))
+	RegisterAdminRoutes(r, controller, reportController, privacyController, auth, rateLimit("admin", cfg.RateLimitAdmin))
 
 	RegisterSwagger(r)
 
@@ -86,6 +164,25 @@ func RegisterDb(cfg *config.Config) *sql.DB {
 	return db
 }
 
+// RegisterReplicas connects to the read replicas of READ_REPLICA_DSNS and returns the router sending
+// the listings, exports and reports to them, or nil when there is no replica.
+func RegisterReplicas(cfg *config.Config, primary *sql.DB) *replicas.Router {
+	if len(cfg.ReadReplicaDSNs) == 0 {
+		return nil
+	}
+	var dbs []*sql.DB
+	for _, dsn := range cfg.ReadReplicaDSNs {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			panic(err)
+		}
+		dbs = append(dbs, db)
+	}
+	router := replicas.NewRouter(primary, dbs, time.Duration(cfg.ReplicaStickinessSeconds)*time.Second)
+	router.Start(context.Background(), time.Duration(cfg.ReplicaHealthCheckSeconds)*time.Second)
+	return router
+}
+
 
 /*
 // No user auth in this microservice so we call this func to generate a JWT token using the provided secret key, the secret stored in .env file.
@@ -103,18 +200,195 @@ func GenerateToken(secretKey string) {
 	fmt.Println("Generated Token:", token)
 }
 */
-func RegisterRoutes(r *gin.Engine, controller *controllers.OrderController, secretKey string) {
+// RegisterOrderCache returns repo with a cache of the orders looked up by ID, or repo itself when
+// ORDER_CACHE_TTL_SECONDS is 0. The hits and misses are published as the order_cache expvar.
+func RegisterOrderCache(cfg *config.Config, repo usecases.OrderRepository) usecases.OrderRepository {
+	if cfg.OrderCacheTTLSeconds == 0 {
+		return repo
+	}
+	cached := cache.NewOrderRepository(repo, &cache.MemoryBackend{Capacity: cfg.OrderCacheSize}, time.Duration(cfg.OrderCacheTTLSeconds)*time.Second)
+	expvar.Publish("order_cache", cached.Metrics)
+	return cached
+}
+
+// RegisterCatalog returns the product catalog used to validate line items: the product service when
+// PRODUCT_SERVICE_URL is set, else the products of PRODUCT_CATALOG_FILE, else none.
+func RegisterCatalog(cfg *config.Config) usecases.ProductCatalog {
+	switch {
+	case cfg.ProductServiceURL != "":
+		return catalog.NewHTTPCatalog(cfg.ProductServiceURL, time.Duration(cfg.ProductServiceTimeoutMs)*time.Millisecond)
+	case cfg.ProductCatalogFile != "":
+		fileCatalog, err := catalog.LoadFileCatalog(cfg.ProductCatalogFile)
+		if err != nil {
+			panic(err)
+		}
+		return fileCatalog
+	default:
+		log.Println("No product catalog configured, line item prices are not validated")
+		return nil
+	}
+}
+
+// RegisterTenants returns the settings of the tenants read from TENANTS_FILE, a JSON object keyed by tenant ID.
+// Tenants not in the file use the settings of the service.
+func RegisterTenants(cfg *config.Config) map[string]entities.TenantConfig {
+	if cfg.TenantsFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(cfg.TenantsFile)
+	if err != nil {
+		panic(err)
+	}
+	var tenants map[string]entities.TenantConfig
+	if err := json.Unmarshal(data, &tenants); err != nil {
+		panic(fmt.Errorf("parse %s: %v", cfg.TenantsFile, err))
+	}
+	if err := usecases.ValidateTenants(tenants); err != nil {
+		panic(err)
+	}
+	return tenants
+}
+
+// RegisterJobs returns the scheduler of the background jobs: the recovery of the order sagas left unfinished
+// when an inventory is configured, the auto-cancellation of stale pending orders when PENDING_ORDER_TTL_MINUTES is set,
+// and the archival of old orders when ORDER_RETENTION_DAYS is set.
+func RegisterJobs(usecase *usecases.OrderUsecase) *scheduler.Scheduler {
+	jobs := &scheduler.Scheduler{}
+	if usecase.Inventory != nil {
+		jobs.Add(scheduler.Job{Name: "saga-recovery", Interval: time.Minute, Run: func(ctx context.Context) error {
+			n, err := usecase.ResumeSagas(time.Minute)
+			if n > 0 {
+				log.Printf("Resumed %d order sagas", n)
+			}
+			return err
+		}})
+	}
+	if usecase.PendingTTL > 0 {
+		jobs.Add(scheduler.Job{Name: "auto-cancel", Interval: time.Minute, Run: func(ctx context.Context) error {
+			n, err := usecase.CancelStaleOrders()
+			if n > 0 {
+				log.Printf("Cancelled %d stale pending orders", n)
+			}
+			if errors.Is(err, apperrors.ErrJobRunning) {
+				// Another replica is sweeping
+				return nil
+			}
+			return err
+		}})
+	}
+	if usecase.ArchiveAfter > 0 {
+		jobs.Add(scheduler.Job{Name: "archive", Interval: time.Hour, Run: func(ctx context.Context) error {
+			n, err := usecase.ArchiveOrders()
+			if n > 0 {
+				log.Printf("Archived %d orders", n)
+			}
+			if errors.Is(err, apperrors.ErrJobRunning) {
+				// Another replica is archiving
+				return nil
+			}
+			return err
+		}})
+	}
+	return jobs
+}
+
+func RegisterRoutes(r *gin.Engine, controller *controllers.OrderController, auth gin.HandlerFunc, rateLimit gin.HandlerFunc) {
 	// Version 1 routes
 	routes := r.Group("/api/v1/order")
 	{
 		// Apply AuthMiddleware globally or for specific routes
-		routes.Use(middleware.AuthMiddleware(secretKey))
+		routes.Use(auth, rateLimit)
 
 		// Register version 1 order routes
 		routes.GET("", controller.GetOrders)
+		routes.GET("export", controller.Export)
+		routes.GET("summary", controller.GetSummary)
 		routes.POST("", controller.Create)
+		routes.POST("bulk", controller.CreateBulk)
+		routes.PUT("status/bulk", middleware.AdminMiddleware(), controller.UpdateStatusBulk)
+		routes.POST("stale/cancel", middleware.AdminMiddleware(), controller.CancelStaleOrders)
 		routes.GET(":id", controller.GetByID)
 		routes.PUT(":id/status", controller.UpdateStatus)
+		routes.PUT(":id/fulfilment", middleware.AdminMiddleware(), controller.SetFulfilment)
+		routes.POST(":id/items", controller.AddItem)
+		routes.PATCH(":id/items/:itemId", controller.UpdateItem)
+		routes.DELETE(":id/items/:itemId", controller.RemoveItem)
+	}
+}
+
+func RegisterPromotionRoutes(r *gin.Engine, controller *controllers.PromotionController, auth gin.HandlerFunc, rateLimit gin.HandlerFunc) {
+	// Promotions are managed by admins only
+	routes := r.Group("/api/v1/promotions")
+	{
+		routes.Use(auth, rateLimit, middleware.AdminMiddleware())
+
+		routes.GET("", controller.GetPromotions)
+		routes.POST("", controller.Create)
+		routes.GET(":id", controller.GetByID)
+		routes.PUT(":id", controller.Update)
+		routes.DELETE(":id", controller.Delete)
+	}
+}
+
+func RegisterPaymentRoutes(r *gin.Engine, controller *controllers.PaymentController, auth gin.HandlerFunc, rateLimit gin.HandlerFunc, webhookRateLimit gin.HandlerFunc) {
+	routes := r.Group("/api/v1/order/:id/payments")
+	{
+		routes.Use(auth, rateLimit)
+
+		routes.GET("", controller.GetPayments)
+		routes.POST("", controller.StartPayment)
+		routes.POST(":paymentId/capture", middleware.AdminMiddleware(), controller.Capture)
+	}
+
+	// Provider callbacks are authenticated by their signature
+	r.POST("/api/v1/payments/webhook", webhookRateLimit, controller.Webhook)
+}
+
+func RegisterReturnRoutes(r *gin.Engine, controller *controllers.ReturnController, auth gin.HandlerFunc, rateLimit gin.HandlerFunc) {
+	routes := r.Group("/api/v1/order/:id/returns")
+	{
+		routes.Use(auth, rateLimit)
+
+		routes.GET("", controller.GetReturns)
+		routes.POST("", controller.RequestReturn)
+		routes.PATCH(":returnId", middleware.AdminMiddleware(), controller.UpdateReturnStatus)
+	}
+}
+
+func RegisterAdminRoutes(r *gin.Engine, controller *controllers.OrderController, reportController *controllers.ReportController, privacyController *controllers.PrivacyController, auth gin.HandlerFunc, rateLimit gin.HandlerFunc) {
+	// Support staff look up and fix the orders of any user
+	routes := r.Group("/api/v1/admin/orders")
+	{
+		routes.Use(auth, rateLimit, middleware.AdminMiddleware())
+
+		routes.GET("", controller.SearchOrders)
+		routes.POST("archive", controller.ArchiveOrders)
+		routes.GET(":id", controller.GetByID)
+		routes.DELETE(":id", controller.DeleteOrder)
+		routes.PUT(":id/status", controller.ForceStatus)
+		routes.GET(":id/history", controller.GetHistory)
+	}
+
+	reports := r.Group("/api/v1/admin/reports")
+	{
+		reports.Use(auth, rateLimit, middleware.AdminMiddleware())
+
+		reports.GET("orders", reportController.GetOrdersReport)
+		reports.GET("statuses", reportController.GetStatusReport)
+		reports.GET("products", reportController.GetProductReport)
+	}
+
+	// Runtime metrics, such as the hits and misses of the order cache
+	r.GET("/api/v1/admin/metrics", auth, rateLimit, middleware.AdminMiddleware(), gin.WrapH(expvar.Handler()))
+
+	// Privacy requests: the export and the erasure of the data of a user
+	users := r.Group("/api/v1/admin/users")
+	{
+		users.Use(auth, rateLimit, middleware.AdminMiddleware())
+
+		users.GET(":id/export", privacyController.ExportUserData)
+		users.GET(":id/erasures", privacyController.GetErasures)
+		users.POST(":id/erasures", privacyController.EraseUser)
 	}
 }
 
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
@@ -16,11 +19,55 @@ type Config struct {
 	DBPassword string `validate:"required"`
 	DBName string `validate:"required"`
 	SSLMode string `validate:"required"`
+	ReadReplicaDSNs []string
+	ReplicaStickinessSeconds int `validate:"min=0"`
+	ReplicaHealthCheckSeconds int `validate:"min=1"`
 	ServerPort string `validate:"required"`
 	TokenTTL string `validate:"required"`
 	AccessTokenSecret string `validate:"required"`
+	BulkMaxOrders int `validate:"min=1"`
+	AllowedCurrencies []string `validate:"min=1,dive,len=3"`
+	TaxRate int64 `validate:"min=0,max=10000"` // basis points
+	ProductServiceURL string `validate:"omitempty,url"`
+	ProductServiceTimeoutMs int `validate:"min=1"`
+	ProductCatalogFile string
+	InventoryFile string
+	PaymentWebhookSecret string
+	PendingOrderTTLMinutes int `validate:"min=0"`
+	OrderRetentionDays int `validate:"min=0"`
+	OrderCacheTTLSeconds int `validate:"min=0"`
+	OrderCacheSize int `validate:"min=1"`
+	DefaultTenantID string
+	TenantsFile string
+	RateLimitOrders string
+	RateLimitPromotions string
+	RateLimitPayments string
+	RateLimitReturns string
+	RateLimitWebhook string
+	RateLimitAdmin string
+	MaxBodyBytes int64 `validate:"min=1"`
 }
 
+// Default values for optional settings.
+const (
+	DefaultBulkMaxOrders = 1000
+	DefaultAllowedCurrencies = "USD,EUR,ILS"
+	DefaultProductServiceTimeoutMs = 2000
+	DefaultTenantID = "default"
+	DefaultReplicaStickinessSeconds = 5
+	DefaultReplicaHealthCheckSeconds = 10
+	DefaultOrderCacheTTLSeconds = 10
+	DefaultOrderCacheSize = 10000
+	// Rate limits, written as <requests>/<period>
+	DefaultRateLimitOrders = "120/1m"
+	DefaultRateLimitPromotions = "60/1m"
+	DefaultRateLimitPayments = "30/1m"
+	DefaultRateLimitReturns = "30/1m"
+	DefaultRateLimitWebhook = "600/1m"
+	DefaultRateLimitAdmin = "60/1m"
+	DefaultMaxBodyBytes = 1 << 20
+)
+
 // LoadENV loads configuration from .env file and environment variables.
 func LoadENV() (*Config, error) {
 	
@@ -49,10 +96,33 @@ func LoadENV() (*Config, error) {
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		DBName: 	os.Getenv("DB_NAME"),
 		SSLMode:	os.Getenv("SSL_MODE"),
+		ReadReplicaDSNs: getEnvStrings("READ_REPLICA_DSNS"),
+		ReplicaStickinessSeconds: getEnvInt("REPLICA_STICKINESS_SECONDS", DefaultReplicaStickinessSeconds),
+		ReplicaHealthCheckSeconds: getEnvInt("REPLICA_HEALTH_CHECK_SECONDS", DefaultReplicaHealthCheckSeconds),
 		ServerPort: os.Getenv("SERVER_PORT"),
 		TokenTTL:	os.Getenv("TOKEN_TTL"),
 		AccessTokenSecret: os.Getenv("ACCESS_TOKEN_SECRET"),
-
+		BulkMaxOrders: getEnvInt("BULK_MAX_ORDERS", DefaultBulkMaxOrders),
+		AllowedCurrencies: getEnvList("ALLOWED_CURRENCIES", DefaultAllowedCurrencies),
+		TaxRate: getEnvBasisPoints("TAX_RATE", 0),
+		ProductServiceURL: os.Getenv("PRODUCT_SERVICE_URL"),
+		ProductServiceTimeoutMs: getEnvInt("PRODUCT_SERVICE_TIMEOUT_MS", DefaultProductServiceTimeoutMs),
+		ProductCatalogFile: os.Getenv("PRODUCT_CATALOG_FILE"),
+		InventoryFile: os.Getenv("INVENTORY_FILE"),
+		PaymentWebhookSecret: os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+		PendingOrderTTLMinutes: getEnvInt("PENDING_ORDER_TTL_MINUTES", 0),
+		OrderRetentionDays: getEnvInt("ORDER_RETENTION_DAYS", 0),
+		OrderCacheTTLSeconds: getEnvInt("ORDER_CACHE_TTL_SECONDS", DefaultOrderCacheTTLSeconds),
+		OrderCacheSize: getEnvInt("ORDER_CACHE_SIZE", DefaultOrderCacheSize),
+		DefaultTenantID: getEnvString("DEFAULT_TENANT_ID", DefaultTenantID),
+		TenantsFile: os.Getenv("TENANTS_FILE"),
+		RateLimitOrders: getEnvString("RATE_LIMIT_ORDERS", DefaultRateLimitOrders),
+		RateLimitPromotions: getEnvString("RATE_LIMIT_PROMOTIONS", DefaultRateLimitPromotions),
+		RateLimitPayments: getEnvString("RATE_LIMIT_PAYMENTS", DefaultRateLimitPayments),
+		RateLimitReturns: getEnvString("RATE_LIMIT_RETURNS", DefaultRateLimitReturns),
+		RateLimitWebhook: getEnvString("RATE_LIMIT_WEBHOOK", DefaultRateLimitWebhook),
+		RateLimitAdmin: getEnvString("RATE_LIMIT_ADMIN", DefaultRateLimitAdmin),
+		MaxBodyBytes: int64(getEnvInt("MAX_BODY_BYTES", DefaultMaxBodyBytes)),
 	}
 
 	// Validate configuration
@@ -63,4 +133,59 @@ func LoadENV() (*Config, error) {
 	}
 
 	return config, nil
-}
\ No newline at end of file
+}
+
+// getEnvInt reads an integer environment variable, falling back to def when it is unset or malformed.
+func getEnvInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvString reads a string environment variable, falling back to def when it is unset.
+// Unlike an unset variable, an empty one is kept.
+func getEnvString(key string, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return def
+}
+
+// getEnvList reads a comma separated, upper-cased list, falling back to def when it is unset.
+// The first entry is used as the default value.
+func getEnvList(key string, def string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		value = def
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvStrings reads a semicolon separated list, for values such as connection strings that may contain commas.
+func getEnvStrings(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvBasisPoints reads a percentage such as "17" or "7.25" and returns it in basis points (1700, 725),
+// falling back to def when it is unset or malformed.
+func getEnvBasisPoints(key string, def int64) int64 {
+	percent, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return int64(math.Round(percent * 100))
+}
@@ -0,0 +1,164 @@
+// adapters/catalog/http_catalog.go
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// Default settings of the HTTP catalog.
+const (
+	DEFAULT_TIMEOUT           = 2 * time.Second
+	DEFAULT_CACHE_TTL         = time.Minute
+	DEFAULT_FAILURE_THRESHOLD = 5
+	DEFAULT_COOLDOWN          = 30 * time.Second
+)
+
+// HTTPCatalog reads products from the product service, GET {BaseURL}/products/{id}.
+//
+// Products (and unknown products) are cached for CacheTTL. After FailureThreshold consecutive
+// failures the circuit opens and requests fail fast with apperrors.ErrCatalogUnavailable for
+// Cooldown, after which a single request is let through to probe the service.
+type HTTPCatalog struct {
+	BaseURL          string
+	Client           *http.Client
+	CacheTTL         time.Duration
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu       sync.Mutex
+	cache    map[string]cacheEntry
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+type cacheEntry struct {
+	product   *entities.Product // nil for an unknown product
+	expiresAt time.Time
+}
+
+// NewHTTPCatalog returns a catalog for the product service at baseURL with the default settings.
+func NewHTTPCatalog(baseURL string, timeout time.Duration) *HTTPCatalog {
+	if timeout <= 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	return &HTTPCatalog{
+		BaseURL:          strings.TrimRight(baseURL, "/"),
+		Client:           &http.Client{Timeout: timeout},
+		CacheTTL:         DEFAULT_CACHE_TTL,
+		FailureThreshold: DEFAULT_FAILURE_THRESHOLD,
+		Cooldown:         DEFAULT_COOLDOWN,
+	}
+}
+
+func (c *HTTPCatalog) GetProducts(ids []string) (map[string]*entities.Product, error) {
+	products := make(map[string]*entities.Product, len(ids))
+	for _, id := range ids {
+		product, ok := c.cached(id)
+		if !ok {
+			var err error
+			if product, err = c.fetch(id); err != nil {
+				return nil, err
+			}
+			c.store(id, product)
+		}
+		if product != nil {
+			products[id] = product
+		}
+	}
+	return products, nil
+}
+
+// fetch reads a product from the product service, it returns nil when the product does not exist.
+func (c *HTTPCatalog) fetch(id string) (*entities.Product, error) {
+	if !c.allow() {
+		return nil, fmt.Errorf("%w: circuit open", apperrors.ErrCatalogUnavailable)
+	}
+
+	resp, err := c.Client.Get(c.BaseURL + "/products/" + url.PathEscape(id))
+	if err != nil {
+		c.failure()
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrCatalogUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		c.success()
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		c.failure()
+		return nil, fmt.Errorf("%w: product service responded with %s", apperrors.ErrCatalogUnavailable, resp.Status)
+	}
+
+	var product entities.Product
+	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
+		c.failure()
+		return nil, fmt.Errorf("%w: invalid product %s: %v", apperrors.ErrCatalogUnavailable, id, err)
+	}
+	c.success()
+	product.Currency = strings.ToUpper(product.Currency)
+	return &product, nil
+}
+
+func (c *HTTPCatalog) cached(id string) (*entities.Product, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[id]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.product, true
+}
+
+func (c *HTTPCatalog) store(id string, product *entities.Product) {
+	if c.CacheTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cache == nil {
+		c.cache = make(map[string]cacheEntry)
+	}
+	c.cache[id] = cacheEntry{product: product, expiresAt: time.Now().Add(c.CacheTTL)}
+}
+
+// allow reports whether a request may be sent: the circuit is closed, or it is open for longer than
+// the cooldown and no other probe is in flight.
+func (c *HTTPCatalog) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.FailureThreshold <= 0 || c.failures < c.FailureThreshold {
+		return true
+	}
+	if c.probing || time.Since(c.openedAt) < c.Cooldown {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+func (c *HTTPCatalog) success() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures, c.probing = 0, false
+}
+
+func (c *HTTPCatalog) failure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures++
+	c.probing = false
+	if c.FailureThreshold > 0 && c.failures >= c.FailureThreshold {
+		c.openedAt = time.Now()
+	}
+}
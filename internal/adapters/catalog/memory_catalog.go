@@ -0,0 +1,62 @@
+// adapters/catalog/memory_catalog.go
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/shayja/orders-service/internal/entities"
+)
+
+// MemoryCatalog is a product catalog held in memory, for tests and offline development.
+type MemoryCatalog struct {
+	mu       sync.RWMutex
+	products map[string]*entities.Product
+}
+
+// NewMemoryCatalog returns a catalog with the given products.
+func NewMemoryCatalog(products ...*entities.Product) *MemoryCatalog {
+	c := &MemoryCatalog{products: make(map[string]*entities.Product, len(products))}
+	for _, product := range products {
+		c.Put(product)
+	}
+	return c
+}
+
+// LoadFileCatalog returns a catalog with the products of a JSON file holding an array of products.
+func LoadFileCatalog(path string) (*MemoryCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var products []*entities.Product
+	if err := json.Unmarshal(data, &products); err != nil {
+		return nil, fmt.Errorf("invalid product catalog %s: %v", path, err)
+	}
+	return NewMemoryCatalog(products...), nil
+}
+
+// Put adds or replaces a product.
+func (c *MemoryCatalog) Put(product *entities.Product) {
+	p := *product
+	p.Currency = strings.ToUpper(p.Currency)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.products[p.ID] = &p
+}
+
+func (c *MemoryCatalog) GetProducts(ids []string) (map[string]*entities.Product, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	products := make(map[string]*entities.Product, len(ids))
+	for _, id := range ids {
+		if product, ok := c.products[id]; ok {
+			p := *product
+			products[id] = &p
+		}
+	}
+	return products, nil
+}
@@ -0,0 +1,51 @@
+// internal/adapters/controllers/errors.go
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// errorStatus maps an error returned by a usecase to an HTTP status code.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidRequest),
+		errors.Is(err, apperrors.ErrBulkLimitExceeded),
+		errors.Is(err, apperrors.ErrEmptyBulkRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, apperrors.ErrOrderNotFound),
+		errors.Is(err, apperrors.ErrItemNotFound),
+		errors.Is(err, apperrors.ErrPromotionNotFound),
+		errors.Is(err, apperrors.ErrPaymentNotFound),
+		errors.Is(err, apperrors.ErrReturnNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperrors.ErrOrderNotPending),
+		errors.Is(err, apperrors.ErrItemsLocked),
+		errors.Is(err, apperrors.ErrLastItem),
+		errors.Is(err, apperrors.ErrPromotionCodeTaken),
+		errors.Is(err, apperrors.ErrPromotionInUse),
+		errors.Is(err, apperrors.ErrPromotionLimitReached),
+		errors.Is(err, apperrors.ErrOutOfStock),
+		errors.Is(err, apperrors.ErrPaymentExists),
+		errors.Is(err, apperrors.ErrPaymentState),
+		errors.Is(err, apperrors.ErrOrderNotCompleted),
+		errors.Is(err, apperrors.ErrOrderNotFulfillable),
+		errors.Is(err, apperrors.ErrOrderOpen),
+		errors.Is(err, apperrors.ErrJobRunning),
+		errors.Is(err, apperrors.ErrStatusTransition),
+		errors.Is(err, apperrors.ErrReturnState):
+		return http.StatusConflict
+	case errors.Is(err, apperrors.ErrPaymentDeclined):
+		return http.StatusPaymentRequired
+	case errors.Is(err, apperrors.ErrInvalidSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, apperrors.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, apperrors.ErrCatalogUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,49 @@
+// internal/adapters/controllers/etag.go
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag exposes the order version as a strong entity tag.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// parseIfMatch returns the order version the client expects from the If-Match header.
+// Zero means the header is absent (or "*") and no version check is requested.
+func parseIfMatch(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid If-Match header %q", value)
+	}
+	return version, nil
+}
+
+// matchesIfNoneMatch reports whether the If-None-Match header lists the current order version.
+func matchesIfNoneMatch(c *gin.Context, version int) bool {
+	value := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if value == "" {
+		return false
+	}
+	if value == "*" {
+		return true
+	}
+	current := strconv.Itoa(version)
+	for _, tag := range strings.Split(value, ",") {
+		// Weak comparison, as required for If-None-Match
+		tag = strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "W/"), `"`)
+		if tag == current {
+			return true
+		}
+	}
+	return false
+}
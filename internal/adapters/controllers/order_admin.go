@@ -0,0 +1,230 @@
+// internal/adapters/controllers/order_admin.go
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+// An order ID prefix is made of the leading hex digits and dashes of a UUID.
+var orderIDPrefixPattern = regexp.MustCompile(`^[0-9a-fA-F-]{1,36}$`)
+
+// SearchOrders godoc
+// @Summary	Search the orders of all users
+// @Description	Responds with the orders of any user matching the filter, newest first. Admin only.
+// @Tags	Admin
+// @Produce	json
+// @Param	page	query	int	true	"Page number"
+// @Param	user_id	query	string	false	"User ID"
+// @Param	product_id	query	string	false	"Only orders with a line item of this product"
+// @Param	id_prefix	query	string	false	"Order ID prefix"
+// @Param	status	query	int	false	"Order status"
+// @Param	currency	query	string	false	"ISO 4217 currency code"
+// @Param	from	query	string	false	"Created at or after (YYYY-MM-DD or RFC3339)"
+// @Param	to	query	string	false	"Created before (RFC3339), or on or before (YYYY-MM-DD)"
+// @Success	200	{array}	entities.Order
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Router	/admin/orders [get]
+// @Security apiKey
+func (uc *OrderController) SearchOrders(c *gin.Context) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid page number"})
+		return
+	}
+
+	filter, err := parseSearchFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	res, err := uc.usecase(c).SearchOrders(page, filter)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	if res == nil {
+		res = []*entities.Order{}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// ForceStatus godoc
+// @Summary	Force the status of an order
+// @Description	Sets the status of an order regardless of the status rules. The reason is recorded in the order history. Admin only.
+// @Tags	Admin
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	If-Match	header	string	false	"Expected order version (ETag)"
+// @Param	status	body	entities.ForceStatusRequest	true	"New status and reason"
+// @Success	200	{object}	entities.Order
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	412	{object}	map[string]interface{}
+// @Router	/admin/orders/{id}/status [put]
+// @Security apiKey
+func (uc *OrderController) ForceStatus(c *gin.Context) {
+
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err})
+		return
+	}
+
+	var req entities.ForceStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	res, err := uc.usecase(c).ForceStatus(uri.ID, req.Status, req.Reason, expectedVersion, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	setETag(c, res.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// GetHistory godoc
+// @Summary	Get the audit trail of an order
+// @Description	Responds with the recorded changes of an order, oldest first. Admin only.
+// @Tags	Admin
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Success	200	{array}	entities.OrderHistory
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Router	/admin/orders/{id}/history [get]
+// @Security apiKey
+func (uc *OrderController) GetHistory(c *gin.Context) {
+
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err})
+		return
+	}
+
+	res, err := uc.usecase(c).GetOrderHistory(uri.ID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// DeleteOrder godoc
+// @Summary	Delete an order
+// @Description	Soft deletes a completed or cancelled order: it is kept with its history until archived, but no longer listed or found. Admin only.
+// @Tags	Admin
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	If-Match	header	string	false	"Expected order version (ETag)"
+// @Success	200	{object}	map[string]interface{}
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Failure	412	{object}	map[string]interface{}
+// @Router	/admin/orders/{id} [delete]
+// @Security apiKey
+func (uc *OrderController) DeleteOrder(c *gin.Context) {
+
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err})
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	if err := uc.usecase(c).DeleteOrder(uri.ID, expectedVersion, c.GetString("userID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "msg": nil})
+}
+
+// ArchiveOrders godoc
+// @Summary	Archive old orders
+// @Description	Runs the archival now: moves the completed, cancelled and deleted orders created more than ORDER_RETENTION_DAYS ago to the archive. Admin only.
+// @Tags	Admin
+// @Produce	json
+// @Success	200	{object}	map[string]interface{}
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/admin/orders/archive [post]
+// @Security apiKey
+func (uc *OrderController) ArchiveOrders(c *gin.Context) {
+	archived, err := uc.usecase(c).ArchiveOrders()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"archived": archived}, "msg": nil})
+}
+
+// parseIncludeArchived reads the include_archived flag of the query string, false when it is not set.
+func parseIncludeArchived(c *gin.Context) (bool, error) {
+	value := c.Query("include_archived")
+	if value == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid include_archived %q", value)
+	}
+	return include, nil
+}
+
+// parseSearchFilter reads the order filter of the query string, plus the user, product and order ID prefix.
+func parseSearchFilter(c *gin.Context) (entities.OrderFilter, error) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		return filter, err
+	}
+
+	if value := c.Query("user_id"); value != "" {
+		if !utils.IsValidUUID(value) {
+			return filter, fmt.Errorf("invalid user_id %q", value)
+		}
+		filter.UserID = value
+	}
+
+	if value := c.Query("product_id"); value != "" {
+		if !utils.IsValidUUID(value) {
+			return filter, fmt.Errorf("invalid product_id %q", value)
+		}
+		filter.ProductID = value
+	}
+
+	if value := c.Query("id_prefix"); value != "" {
+		if !orderIDPrefixPattern.MatchString(value) {
+			return filter, fmt.Errorf("invalid id_prefix %q", value)
+		}
+		filter.IDPrefix = value
+	}
+
+	return filter, nil
+}
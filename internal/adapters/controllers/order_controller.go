@@ -22,6 +22,11 @@ type OrderController struct {
 // @Tags	Orders
 // @Produce	json
 // @Param	page	query	int	true	"Page number"
+// @Param	status	query	int	false	"Order status"
+// @Param	currency	query	string	false	"ISO 4217 currency code"
+// @Param	from	query	string	false	"Created at or after (YYYY-MM-DD or RFC3339)"
+// @Param	to	query	string	false	"Created before (RFC3339), or on or before (YYYY-MM-DD)"
+// @Param	include_archived	query	bool	false	"Also list the archived orders"
 // @Success	200	{array}	entities.Order
 // @Failure	400	{object}	map[string]interface{}
 // @Failure	404	{object}	map[string]interface{}
@@ -49,10 +54,21 @@ func (uc *OrderController) GetOrders(c *gin.Context) {
 		return
 	}
 
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	filter.UserID = userID.(string)
+	if filter.IncludeArchived, err = parseIncludeArchived(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
 	// Fetch the orders using the userID from the token
-	res, err := uc.OrderUsecase.GetOrders(page, userID.(string))
+	res, err := uc.usecase(c).GetOrders(page, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -63,13 +79,55 @@ func (uc *OrderController) GetOrders(c *gin.Context) {
 	}
 }
 
+// GetSummary godoc
+// @Summary	Get the order summary of the user
+// @Description	Responds with the number of orders, spend per currency, last order date and counts by status of the user's orders.
+// @Tags	Orders
+// @Produce	json
+// @Param	from	query	string	false	"Created at or after (YYYY-MM-DD or RFC3339)"
+// @Param	to	query	string	false	"Created before (RFC3339), or on or before (YYYY-MM-DD)"
+// @Success	200	{object}	entities.OrderSummary
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	401	{object}	map[string]interface{}
+// @Router	/order/summary [get]
+// @Security apiKey
+func (uc *OrderController) GetSummary(c *gin.Context) {
+	// The summary is always the one of the user of the token
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "msg": "User ID not found in token"})
+		return
+	}
+	if !utils.IsValidUUID(userID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid user id"})
+		return
+	}
+
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	filter.UserID = userID
+
+	res, err := uc.usecase(c).GetSummary(filter)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
 // GetByID godoc
 // @Summary	Get an order by order ID
 // @Description	Responds with an entity of order as JSON.
 // @Tags	Orders
 // @Param	id	path	string	true	"Order ID"
 // @Produce	json
+// @Param	If-None-Match	header	string	false	"Known order version (ETag)"
+// @Param	include_archived	query	bool	false	"Look the order up in the archive when it is not found"
 // @Success	200	{object}	entities.Order
+// @Success	304
 // @Failure	400	{object}	map[string]interface{}
 // @Failure	404	{object}	map[string]interface{}
 // @Router	/order/{id} [get]
@@ -82,12 +140,27 @@ func (uc *OrderController) GetByID(c *gin.Context) {
 		return
 	}
 
-	res, err := uc.OrderUsecase.GetByID(uri.ID)
+	includeArchived, err := parseIncludeArchived(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	res, err := uc.usecase(c).GetByID(uri.ID)
+	if err == nil && includeArchived && (res == nil || !utils.IsValidUUID(res.ID)) {
+		res, err = uc.usecase(c).GetArchivedByID(uri.ID)
+	}
 	if err != nil || !utils.IsValidUUID(res.ID) {
 		c.JSON(http.StatusNotFound, gin.H{"status": "failed", "msg": "Order not found"})
 		return
 	}
 
+	setETag(c, res.Version)
+	if matchesIfNoneMatch(c, res.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
 }
 
@@ -103,15 +176,14 @@ func (uc *OrderController) GetByID(c *gin.Context) {
 // @Security apiKey
 func (uc *OrderController) Create(c *gin.Context) {
 
-	var post *entities.OrderRequest
-	if err := c.ShouldBind(&post); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err})
+	var post entities.OrderRequest
+	if !bindJSON(c, &post) {
 		return
 	}
 
-	insertedID, err := uc.OrderUsecase.Create(post)
+	insertedID, err := uc.usecase(c).Create(&post)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "msg": err})
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
 		return
 	}
 
@@ -123,9 +195,12 @@ func (uc *OrderController) Create(c *gin.Context) {
 // @Description	Update the status of an order
 // @Tags	Orders
 // @Param	id	path	string	true	"Order ID"
+// @Param	If-Match	header	string	false	"Expected order version (ETag)"
 // @Param	status	body	int	true	"New status"
 // @Success	200	{object}	map[string]interface{}
 // @Failure	400	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	412	{object}	map[string]interface{}
 // @Router	/order/{id}/status [put]
 // @Security apiKey
 func (uc *OrderController) UpdateStatus(c *gin.Context) {
@@ -139,16 +214,153 @@ func (uc *OrderController) UpdateStatus(c *gin.Context) {
 	var status struct {
 		Status int `json:"status" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&status); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err})
+	if !bindJSON(c, &status) {
 		return
 	}
 
-	res, err := uc.OrderUsecase.UpdateStatus(uri.ID, status.Status)
+	expectedVersion, err := parseIfMatch(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "msg": err})
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
 		return
 	}
 
+	res, err := uc.usecase(c).UpdateStatus(uri.ID, status.Status, expectedVersion, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	setETag(c, res.Version)
 	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
 }
+
+// SetFulfilment godoc
+// @Summary	Set the shipment of an order
+// @Description	Records the tracking number and carrier of an order that is processing or completed, and bumps the order version (admin only).
+// @Tags	Orders
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	If-Match	header	string	false	"Expected order version (ETag)"
+// @Param	shipment	body	entities.FulfilmentRequest	true	"Tracking number and carrier"
+// @Success	200	{object}	entities.Order
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Failure	412	{object}	map[string]interface{}
+// @Router	/order/{id}/fulfilment [put]
+// @Security apiKey
+func (uc *OrderController) SetFulfilment(c *gin.Context) {
+
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	var put entities.FulfilmentRequest
+	if !bindJSON(c, &put) {
+		return
+	}
+
+	res, err := uc.usecase(c).SetFulfilment(uri.ID, &put, expectedVersion, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	setETag(c, res.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// CancelStaleOrders godoc
+// @Summary	Cancel stale pending orders
+// @Description	Runs the auto-cancellation sweep now: cancels the orders left pending for longer than PENDING_ORDER_TTL_MINUTES (admin only).
+// @Tags	Orders
+// @Produce	json
+// @Success	200	{object}	map[string]interface{}
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/order/stale/cancel [post]
+// @Security apiKey
+func (uc *OrderController) CancelStaleOrders(c *gin.Context) {
+	cancelled, err := uc.usecase(c).CancelStaleOrders()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"cancelled": cancelled}, "msg": nil})
+}
+
+// CreateBulk godoc
+// @Summary	Create many orders at once.
+// @Description	Add a batch of orders, either all-or-nothing (atomic) or best-effort with a result per item.
+// @Tags	Orders
+// @Produce	json
+// @Param	orders	body	entities.BulkOrderRequest	true	"Orders data"
+// @Success	201	{object}	map[string]interface{}
+// @Success	207	{object}	map[string]interface{}
+// @Failure	400	{object}	map[string]interface{}
+// @Router	/order/bulk [post]
+// @Security apiKey
+func (uc *OrderController) CreateBulk(c *gin.Context) {
+
+	var post entities.BulkOrderRequest
+	if !bindJSON(c, &post) {
+		return
+	}
+
+	res, err := uc.usecase(c).CreateBulk(&post)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	c.JSON(bulkResultStatus(res, http.StatusCreated), gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// UpdateStatusBulk godoc
+// @Summary	Update the status of many orders at once.
+// @Description	Change the status of a batch of orders, either all-or-nothing (atomic) or best-effort with a result per item (admin only).
+// @Tags	Orders
+// @Produce	json
+// @Param	updates	body	entities.BulkStatusRequest	true	"Status updates"
+// @Success	200	{object}	map[string]interface{}
+// @Success	207	{object}	map[string]interface{}
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Router	/order/status/bulk [put]
+// @Security apiKey
+func (uc *OrderController) UpdateStatusBulk(c *gin.Context) {
+
+	var put entities.BulkStatusRequest
+	if !bindJSON(c, &put) {
+		return
+	}
+
+	res, err := uc.usecase(c).UpdateStatusBulk(&put, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	c.JSON(bulkResultStatus(res, http.StatusOK), gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// bulkResultStatus returns 207 Multi-Status when at least one item failed, otherwise the given status.
+func bulkResultStatus(results []entities.BulkItemResult, ok int) int {
+	for _, res := range results {
+		if res.Status != entities.BulkItemSuccess {
+			return http.StatusMultiStatus
+		}
+	}
+	return ok
+}
@@ -0,0 +1,371 @@
+// internal/adapters/controllers/order_export.go
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+// EXPORT_FLUSH_EVERY is the number of orders written between two flushes of the response.
+const EXPORT_FLUSH_EVERY = 100
+
+// Export godoc
+// @Summary	Export orders as CSV or NDJSON
+// @Description	Streams the user's orders (or all orders, for admins) created in a date range, optionally with their line items.
+// @Tags	Orders
+// @Produce	text/csv
+// @Produce	application/x-ndjson
+// @Param	format	query	string	false	"Output format"	Enums(csv, ndjson)	default(csv)
+// @Param	from	query	string	false	"Created at or after (YYYY-MM-DD or RFC3339)"
+// @Param	to	query	string	false	"Created before (RFC3339), or on or before (YYYY-MM-DD)"
+// @Param	status	query	int	false	"Order status"
+// @Param	currency	query	string	false	"ISO 4217 currency code"
+// @Param	include_items	query	bool	false	"Include the order line items"
+// @Param	columns	query	string	false	"Comma separated list of columns to export"
+// @Param	all_users	query	bool	false	"Export the orders of all users (admin only)"
+// @Success	200	{string}	string
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Router	/order/export [get]
+// @Security apiKey
+func (uc *OrderController) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid format, expected csv or ndjson"})
+		return
+	}
+
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	// Get the userID from the token, only admins may export the orders of all users
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "msg": "User ID not found in token"})
+		return
+	}
+	if c.Query("all_users") == "true" {
+		if c.GetString("role") != entities.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"status": "failed", "msg": "Only admins can export the orders of all users"})
+			return
+		}
+	} else {
+		if !utils.IsValidUUID(userID.(string)) {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid user id"})
+			return
+		}
+		filter.UserID = userID.(string)
+	}
+
+	includeItems := c.Query("include_items") == "true"
+	columns, err := parseExportColumns(c.Query("columns"), includeItems)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	var writer exportWriter
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv")
+		writer = &csvExportWriter{w: csv.NewWriter(c.Writer), columns: columns}
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+		writer = &ndjsonExportWriter{w: c.Writer, columns: columns}
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="orders.%s"`, format))
+
+	exported := 0
+	err = uc.usecase(c).ExportOrders(filter, includeItems, func(order *entities.Order) error {
+		if err := writer.Write(order); err != nil {
+			return err
+		}
+		// Push the data to the client regularly instead of buffering the whole export
+		if exported++; exported%EXPORT_FLUSH_EVERY == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if c.Writer.Written() {
+			// The response is already on its way, all we can do is stop and log.
+			log.Println("Export aborted:", err)
+			return
+		}
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+	if err := writer.Flush(); err != nil {
+		log.Println("Export aborted:", err)
+	}
+}
+
+// parseOrderFilter reads the status, currency and date range filter from the query string.
+// A date-only "to" value includes the whole day.
+func parseOrderFilter(c *gin.Context) (entities.OrderFilter, error) {
+	var filter entities.OrderFilter
+
+	if value := c.Query("status"); value != "" {
+		status, err := strconv.Atoi(value)
+		if err != nil || !entities.IsValidOrderStatus(status) {
+			return filter, fmt.Errorf("invalid status %q", value)
+		}
+		filter.Status = status
+	}
+
+	if value := c.Query("currency"); value != "" {
+		filter.Currency = strings.ToUpper(value)
+		if len(filter.Currency) != 3 {
+			return filter, fmt.Errorf("invalid currency %q", value)
+		}
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, _, err := parseDateParam(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date %q", value)
+		}
+		filter.From = &from
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, dateOnly, err := parseDateParam(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date %q", value)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// parseDateParam accepts either a date (YYYY-MM-DD) or an RFC3339 timestamp.
+func parseDateParam(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// exportColumn is a column that can be selected in an export.
+type exportColumn struct {
+	name  string
+	item  bool
+	value func(order *entities.Order, detail *entities.OrderDetail) interface{}
+}
+
+var exportColumns = []exportColumn{
+	{name: "id", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} { return o.ID }},
+	{name: "user_id", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} { return o.UserID }},
+	{name: "total_price", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} {
+		return formatAmount(o.TotalPrice, o.Currency)
+	}},
+	{name: "subtotal", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} {
+		return formatAmount(o.Subtotal, o.Currency)
+	}},
+	{name: "discount_total", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} {
+		return formatAmount(o.DiscountTotal, o.Currency)
+	}},
+	{name: "tax_total", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} {
+		return formatAmount(o.TaxTotal, o.Currency)
+	}},
+	{name: "shipping_total", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} {
+		return formatAmount(o.ShippingTotal, o.Currency)
+	}},
+	{name: "status", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} { return o.Status }},
+	{name: "currency", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} { return o.Currency }},
+	{name: "created_at", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} { return o.CreatedAt }},
+	{name: "updated_at", value: func(o *entities.Order, _ *entities.OrderDetail) interface{} { return o.UpdatedAt }},
+	{name: "item_id", item: true, value: func(_ *entities.Order, d *entities.OrderDetail) interface{} { return d.ID }},
+	{name: "product_id", item: true, value: func(_ *entities.Order, d *entities.OrderDetail) interface{} { return d.ProductID }},
+	{name: "quantity", item: true, value: func(_ *entities.Order, d *entities.OrderDetail) interface{} { return d.Quantity }},
+	{name: "unit_price", item: true, value: func(o *entities.Order, d *entities.OrderDetail) interface{} {
+		return formatAmount(d.UnitPrice, o.Currency)
+	}},
+	{name: "item_total_price", item: true, value: func(o *entities.Order, d *entities.OrderDetail) interface{} {
+		return formatAmount(d.TotalPrice, o.Currency)
+	}},
+	{name: "discount_amount", item: true, value: func(o *entities.Order, d *entities.OrderDetail) interface{} {
+		return formatAmount(d.DiscountAmount, o.Currency)
+	}},
+	{name: "tax_amount", item: true, value: func(o *entities.Order, d *entities.OrderDetail) interface{} {
+		return formatAmount(d.TaxAmount, o.Currency)
+	}},
+}
+
+// parseExportColumns resolves a comma separated list of column names.
+// An empty list selects every order column, plus every line item column when items are included.
+func parseExportColumns(value string, includeItems bool) ([]exportColumn, error) {
+	if value == "" {
+		var columns []exportColumn
+		for _, column := range exportColumns {
+			if !column.item || includeItems {
+				columns = append(columns, column)
+			}
+		}
+		return columns, nil
+	}
+
+	var columns []exportColumn
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, column := range exportColumns {
+			if column.name == name {
+				if column.item && !includeItems {
+					return nil, fmt.Errorf("column %q requires include_items=true", name)
+				}
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return columns, nil
+}
+
+// exportWriter encodes exported orders to the response.
+type exportWriter interface {
+	Write(order *entities.Order) error
+	Flush() error
+}
+
+// csvExportWriter writes one row per order, or one row per line item when item columns are selected.
+type csvExportWriter struct {
+	w             *csv.Writer
+	columns       []exportColumn
+	headerWritten bool
+}
+
+func (e *csvExportWriter) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	header := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		header[i] = column.name
+	}
+	return e.w.Write(header)
+}
+
+func (e *csvExportWriter) Write(order *entities.Order) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	hasItemColumns := false
+	for _, column := range e.columns {
+		hasItemColumns = hasItemColumns || column.item
+	}
+
+	details := []*entities.OrderDetail{nil}
+	if hasItemColumns && len(order.OrderDetails) > 0 {
+		details = details[:0]
+		for i := range order.OrderDetails {
+			details = append(details, &order.OrderDetails[i])
+		}
+	}
+
+	for _, detail := range details {
+		record := make([]string, len(e.columns))
+		for i, column := range e.columns {
+			if column.item && detail == nil {
+				continue
+			}
+			record[i] = formatCSVValue(column.value(order, detail))
+		}
+		if err := e.w.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *csvExportWriter) Flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// formatAmount writes an amount with the minor units of the order currency, as a JSON number.
+func formatAmount(amount entities.Money, currency string) json.Number {
+	return json.Number(amount.FormatCurrency(currency))
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ndjsonExportWriter writes one JSON object per order and line, with the line items nested.
+type ndjsonExportWriter struct {
+	w       io.Writer
+	columns []exportColumn
+}
+
+func (e *ndjsonExportWriter) Write(order *entities.Order) error {
+	record := make(map[string]interface{}, len(e.columns)+1)
+	var itemColumns []exportColumn
+	for _, column := range e.columns {
+		if column.item {
+			itemColumns = append(itemColumns, column)
+			continue
+		}
+		record[column.name] = column.value(order, nil)
+	}
+
+	if len(itemColumns) > 0 {
+		items := make([]map[string]interface{}, 0, len(order.OrderDetails))
+		for i := range order.OrderDetails {
+			item := make(map[string]interface{}, len(itemColumns))
+			for _, column := range itemColumns {
+				item[column.name] = column.value(order, &order.OrderDetails[i])
+			}
+			items = append(items, item)
+		}
+		record["order_details"] = items
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(line, '\n'))
+	return err
+}
+
+func (e *ndjsonExportWriter) Flush() error {
+	return nil
+}
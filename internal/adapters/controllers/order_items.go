@@ -0,0 +1,137 @@
+// internal/adapters/controllers/order_items.go
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+)
+
+// AddItem godoc
+// @Summary	Add a line item to a pending order
+// @Description	Adds a line item, recomputes the order total and bumps the order version. Send the current ETag in If-Match to avoid overwriting concurrent changes.
+// @Tags	Orders
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	If-Match	header	string	false	"Expected order version (ETag)"
+// @Param	item	body	entities.OrderItemRequest	true	"Line item data"
+// @Success	201	{object}	entities.Order
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Failure	412	{object}	map[string]interface{}
+// @Router	/order/{id}/items [post]
+// @Security apiKey
+func (uc *OrderController) AddItem(c *gin.Context) {
+
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	var post entities.OrderItemRequest
+	if !bindJSON(c, &post) {
+		return
+	}
+
+	res, err := uc.usecase(c).AddItem(uri.ID, &post, expectedVersion, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	setETag(c, res.Version)
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// UpdateItem godoc
+// @Summary	Update a line item of a pending order
+// @Description	Changes the quantity and/or unit price of a line item, recomputes the order total and bumps the order version.
+// @Tags	Orders
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	itemId	path	string	true	"Line item ID"
+// @Param	If-Match	header	string	false	"Expected order version (ETag)"
+// @Param	item	body	entities.OrderItemUpdate	true	"Fields to change"
+// @Success	200	{object}	entities.Order
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Failure	412	{object}	map[string]interface{}
+// @Router	/order/{id}/items/{itemId} [patch]
+// @Security apiKey
+func (uc *OrderController) UpdateItem(c *gin.Context) {
+
+	var uri entities.OrderItemURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	var patch entities.OrderItemUpdate
+	if !bindJSON(c, &patch) {
+		return
+	}
+
+	res, err := uc.usecase(c).UpdateItem(uri.ID, uri.ItemID, &patch, expectedVersion, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	setETag(c, res.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// RemoveItem godoc
+// @Summary	Remove a line item from a pending order
+// @Description	Removes a line item, recomputes the order total and bumps the order version. The last line item cannot be removed.
+// @Tags	Orders
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	itemId	path	string	true	"Line item ID"
+// @Param	If-Match	header	string	false	"Expected order version (ETag)"
+// @Success	200	{object}	entities.Order
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Failure	412	{object}	map[string]interface{}
+// @Router	/order/{id}/items/{itemId} [delete]
+// @Security apiKey
+func (uc *OrderController) RemoveItem(c *gin.Context) {
+
+	var uri entities.OrderItemURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	res, err := uc.usecase(c).RemoveItem(uri.ID, uri.ItemID, expectedVersion, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	setETag(c, res.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
@@ -0,0 +1,128 @@
+// internal/adapters/controllers/payment_controller.go
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+// Header carrying the signature of payment provider callbacks.
+const SignatureHeader = "X-Signature"
+
+type PaymentController struct {
+	PaymentUsecase *usecases.PaymentUsecase
+}
+
+// GetPayments godoc
+// @Summary	List the payments of an order
+// @Description	Responds with the payments of an order, oldest first.
+// @Tags	Payments
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Success	200	{array}	entities.Payment
+// @Failure	400	{object}	map[string]interface{}
+// @Router	/order/{id}/payments [get]
+// @Security apiKey
+func (pc *PaymentController) GetPayments(c *gin.Context) {
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil || !utils.IsValidUUID(uri.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid order id"})
+		return
+	}
+
+	res, err := pc.usecase(c).GetPayments(uri.ID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// StartPayment godoc
+// @Summary	Start the payment of an order
+// @Description	Authorizes the total of a pending order with the payment provider. A declined payment is recorded as failed.
+// @Tags	Payments
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Success	201	{object}	entities.Payment
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	402	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/order/{id}/payments [post]
+// @Security apiKey
+func (pc *PaymentController) StartPayment(c *gin.Context) {
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil || !utils.IsValidUUID(uri.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid order id"})
+		return
+	}
+
+	res, err := pc.usecase(c).StartPayment(uri.ID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "data": res, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// Capture godoc
+// @Summary	Capture a payment
+// @Description	Collects the authorized amount of a payment and moves its pending order to processing (admin only).
+// @Tags	Payments
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	paymentId	path	string	true	"Payment ID"
+// @Success	200	{object}	entities.Payment
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	402	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/order/{id}/payments/{paymentId}/capture [post]
+// @Security apiKey
+func (pc *PaymentController) Capture(c *gin.Context) {
+	var uri entities.PaymentURI
+	if err := c.ShouldBindUri(&uri); err != nil || !utils.IsValidUUID(uri.ID) || !utils.IsValidUUID(uri.PaymentID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid order or payment id"})
+		return
+	}
+
+	res, err := pc.usecase(c).Capture(uri.ID, uri.PaymentID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// Webhook godoc
+// @Summary	Payment provider callback
+// @Description	Applies a payment event sent by the payment provider, signed in the X-Signature header. Retried events are applied once.
+// @Tags	Payments
+// @Accept	json
+// @Produce	json
+// @Param	X-Signature	header	string	true	"Payload signature"
+// @Param	event	body	entities.PaymentEvent	true	"Payment event"
+// @Success	200	{object}	map[string]interface{}
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	401	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Router	/payments/webhook [post]
+func (pc *PaymentController) Webhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	// The callbacks carry no tenant, the payment is looked up among the payments of every tenant
+	if err := pc.PaymentUsecase.ForTenant(entities.AllTenants).HandleWebhook(payload, c.GetHeader(SignatureHeader)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "msg": nil})
+}
@@ -0,0 +1,105 @@
+// internal/adapters/controllers/privacy_controller.go
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+type PrivacyController struct {
+	PrivacyUsecase *usecases.PrivacyUsecase
+}
+
+// ExportUserData godoc
+// @Summary	Export the data of a user
+// @Description	Responds with a JSON bundle of the orders of a user, deleted and archived ones included, with their line items, addresses and history (admin only).
+// @Tags	Privacy
+// @Produce	json
+// @Param	id	path	string	true	"User ID"
+// @Success	200	{object}	entities.UserDataExport
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Router	/admin/users/{id}/export [get]
+// @Security apiKey
+func (pc *PrivacyController) ExportUserData(c *gin.Context) {
+
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err})
+		return
+	}
+
+	bundle, err := pc.usecase(c).ExportUserData(uri.ID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s.json"`, uri.ID))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// EraseUser godoc
+// @Summary	Erase the data of a user
+// @Description	Gives the orders of a user a pseudonymous user ID and wipes their addresses and return reasons, keeping the amounts for accounting. The erasure is recorded in the audit log. Users with a pending or processing order answer 409 (admin only).
+// @Tags	Privacy
+// @Produce	json
+// @Param	id	path	string	true	"User ID"
+// @Param	erasure	body	entities.ErasureRequest	true	"Reason of the erasure"
+// @Success	200	{object}	entities.ErasureRecord
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/admin/users/{id}/erasures [post]
+// @Security apiKey
+func (pc *PrivacyController) EraseUser(c *gin.Context) {
+
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err})
+		return
+	}
+
+	var req entities.ErasureRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	res, err := pc.usecase(c).EraseUser(uri.ID, req.Reason, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// GetErasures godoc
+// @Summary	Get the erasures of a user
+// @Description	Responds with the audit log entries of the erasures of the data of a user, oldest first (admin only).
+// @Tags	Privacy
+// @Produce	json
+// @Param	id	path	string	true	"User ID"
+// @Success	200	{array}	entities.ErasureRecord
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Router	/admin/users/{id}/erasures [get]
+// @Security apiKey
+func (pc *PrivacyController) GetErasures(c *gin.Context) {
+
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": err})
+		return
+	}
+
+	res, err := pc.usecase(c).GetErasures(uri.ID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
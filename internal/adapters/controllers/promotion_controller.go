@@ -0,0 +1,158 @@
+// internal/adapters/controllers/promotion_controller.go
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+type PromotionController struct {
+	PromotionUsecase *usecases.PromotionUsecase
+}
+
+// GetPromotions godoc
+// @Summary	List promotions
+// @Description	Responds with a page of promotions, newest first (admin only).
+// @Tags	Promotions
+// @Produce	json
+// @Param	page	query	int	true	"Page number"
+// @Success	200	{array}	entities.Promotion
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Router	/promotions [get]
+// @Security apiKey
+func (pc *PromotionController) GetPromotions(c *gin.Context) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid page number"})
+		return
+	}
+
+	res, err := pc.usecase(c).GetPromotions(page)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// GetByID godoc
+// @Summary	Get a promotion by ID
+// @Description	Responds with a promotion as JSON (admin only).
+// @Tags	Promotions
+// @Produce	json
+// @Param	id	path	string	true	"Promotion ID"
+// @Success	200	{object}	entities.Promotion
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Router	/promotions/{id} [get]
+// @Security apiKey
+func (pc *PromotionController) GetByID(c *gin.Context) {
+	id, ok := bindPromotionID(c)
+	if !ok {
+		return
+	}
+
+	res, err := pc.usecase(c).GetByID(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// Create godoc
+// @Summary	Create a promotion
+// @Description	Adds a promotion redeemable with its coupon code (admin only).
+// @Tags	Promotions
+// @Produce	json
+// @Param	promotion	body	entities.Promotion	true	"Promotion data"
+// @Success	201	{object}	map[string]interface{}
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/promotions [post]
+// @Security apiKey
+func (pc *PromotionController) Create(c *gin.Context) {
+	var post entities.Promotion
+	if !bindJSON(c, &post) {
+		return
+	}
+
+	insertedID, err := pc.usecase(c).Create(&post)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "id": insertedID, "msg": nil})
+}
+
+// Update godoc
+// @Summary	Update a promotion
+// @Description	Replaces the settings of a promotion, its redemption count is kept (admin only).
+// @Tags	Promotions
+// @Produce	json
+// @Param	id	path	string	true	"Promotion ID"
+// @Param	promotion	body	entities.Promotion	true	"Promotion data"
+// @Success	200	{object}	entities.Promotion
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/promotions/{id} [put]
+// @Security apiKey
+func (pc *PromotionController) Update(c *gin.Context) {
+	id, ok := bindPromotionID(c)
+	if !ok {
+		return
+	}
+
+	var post entities.Promotion
+	if !bindJSON(c, &post) {
+		return
+	}
+
+	res, err := pc.usecase(c).Update(id, &post)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// Delete godoc
+// @Summary	Delete a promotion
+// @Description	Deletes a promotion that was never redeemed, redeemed promotions can only be deactivated (admin only).
+// @Tags	Promotions
+// @Produce	json
+// @Param	id	path	string	true	"Promotion ID"
+// @Success	200	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/promotions/{id} [delete]
+// @Security apiKey
+func (pc *PromotionController) Delete(c *gin.Context) {
+	id, ok := bindPromotionID(c)
+	if !ok {
+		return
+	}
+
+	if err := pc.usecase(c).Delete(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "msg": nil})
+}
+
+// bindPromotionID reads the promotion ID from the path, responding with 400 when it is not a UUID.
+func bindPromotionID(c *gin.Context) (string, bool) {
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil || !utils.IsValidUUID(uri.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid promotion id"})
+		return "", false
+	}
+	return uri.ID, true
+}
@@ -0,0 +1,191 @@
+// internal/adapters/controllers/report_controller.go
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+type ReportController struct {
+	ReportUsecase *usecases.ReportUsecase
+}
+
+// GetOrdersReport godoc
+// @Summary	Orders and revenue per period
+// @Description	Responds with the number of orders, revenue and average order value per day, week or month and currency, cancelled orders left out (admin only).
+// @Tags	Reports
+// @Produce	json
+// @Produce	text/csv
+// @Param	interval	query	string	false	"Period length"	Enums(day, week, month)	default(day)
+// @Param	from	query	string	false	"Created at or after (YYYY-MM-DD or RFC3339), defaults to 30 days before to"
+// @Param	to	query	string	false	"Created before (RFC3339), or on or before (YYYY-MM-DD), defaults to now"
+// @Param	format	query	string	false	"Output format"	Enums(json, csv)	default(json)
+// @Success	200	{array}	entities.OrdersReportRow
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Router	/admin/reports/orders [get]
+// @Security apiKey
+func (rc *ReportController) GetOrdersReport(c *gin.Context) {
+	filter, format, ok := parseReportQuery(c)
+	if !ok {
+		return
+	}
+	filter.Interval = c.Query("interval")
+
+	report, err := rc.usecase(c).OrdersByPeriod(filter)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		records := [][]string{{"period", "currency", "orders", "revenue", "average_order_value"}}
+		for _, row := range report {
+			records = append(records, []string{formatCSVValue(row.Period), row.Currency, strconv.Itoa(row.Orders),
+				row.Revenue.FormatCurrency(row.Currency), row.AverageOrderValue.FormatCurrency(row.Currency)})
+		}
+		writeReportCSV(c, "orders", records)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": report, "msg": nil})
+}
+
+// GetStatusReport godoc
+// @Summary	Orders per status
+// @Description	Responds with the number of orders in each status (admin only).
+// @Tags	Reports
+// @Produce	json
+// @Produce	text/csv
+// @Param	from	query	string	false	"Created at or after (YYYY-MM-DD or RFC3339), defaults to 30 days before to"
+// @Param	to	query	string	false	"Created before (RFC3339), or on or before (YYYY-MM-DD), defaults to now"
+// @Param	format	query	string	false	"Output format"	Enums(json, csv)	default(json)
+// @Success	200	{array}	entities.StatusReportRow
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Router	/admin/reports/statuses [get]
+// @Security apiKey
+func (rc *ReportController) GetStatusReport(c *gin.Context) {
+	filter, format, ok := parseReportQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := rc.usecase(c).StatusCounts(filter)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		records := [][]string{{"status", "orders"}}
+		for _, row := range report {
+			records = append(records, []string{strconv.Itoa(row.Status), strconv.Itoa(row.Orders)})
+		}
+		writeReportCSV(c, "statuses", records)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": report, "msg": nil})
+}
+
+// GetProductReport godoc
+// @Summary	Top products
+// @Description	Responds with the best selling products per currency, by quantity or revenue, cancelled orders left out (admin only).
+// @Tags	Reports
+// @Produce	json
+// @Produce	text/csv
+// @Param	sort	query	string	false	"Rank the products by"	Enums(quantity, revenue)	default(quantity)
+// @Param	limit	query	int	false	"Number of products (1 to 100)"	default(10)
+// @Param	from	query	string	false	"Created at or after (YYYY-MM-DD or RFC3339), defaults to 30 days before to"
+// @Param	to	query	string	false	"Created before (RFC3339), or on or before (YYYY-MM-DD), defaults to now"
+// @Param	format	query	string	false	"Output format"	Enums(json, csv)	default(json)
+// @Success	200	{array}	entities.ProductReportRow
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	403	{object}	map[string]interface{}
+// @Router	/admin/reports/products [get]
+// @Security apiKey
+func (rc *ReportController) GetProductReport(c *gin.Context) {
+	filter, format, ok := parseReportQuery(c)
+	if !ok {
+		return
+	}
+	filter.SortBy = c.Query("sort")
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": fmt.Sprintf("invalid limit %q", value)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	report, err := rc.usecase(c).TopProducts(filter)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		records := [][]string{{"product_id", "currency", "quantity", "revenue", "orders"}}
+		for _, row := range report {
+			records = append(records, []string{row.ProductID, row.Currency, strconv.Itoa(row.Quantity),
+				row.Revenue.FormatCurrency(row.Currency), strconv.Itoa(row.Orders)})
+		}
+		writeReportCSV(c, "products", records)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": report, "msg": nil})
+}
+
+// parseReportQuery reads the date range and output format of a report from the query string.
+// A date-only "to" value includes the whole day. It responds 400 Bad Request and returns false when they are invalid.
+func parseReportQuery(c *gin.Context) (entities.ReportFilter, string, bool) {
+	var filter entities.ReportFilter
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid format, expected json or csv"})
+		return filter, "", false
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, _, err := parseDateParam(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": fmt.Sprintf("invalid from date %q", value)})
+			return filter, "", false
+		}
+		filter.From = from
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, dateOnly, err := parseDateParam(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": fmt.Sprintf("invalid to date %q", value)})
+			return filter, "", false
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = to
+	}
+
+	return filter, format, true
+}
+
+// writeReportCSV responds with the records of a report as a CSV attachment, the first record being the header.
+func writeReportCSV(c *gin.Context, name string, records [][]string) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-report.csv"`, name))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.WriteAll(records); err != nil {
+		log.Println("Report aborted:", err)
+	}
+}
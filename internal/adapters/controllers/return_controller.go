@@ -0,0 +1,115 @@
+// internal/adapters/controllers/return_controller.go
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+type ReturnController struct {
+	ReturnUsecase *usecases.ReturnUsecase
+}
+
+// GetReturns godoc
+// @Summary	List the returns of an order
+// @Description	Responds with the returns of an order and their items, oldest first.
+// @Tags	Returns
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Success	200	{array}	entities.Return
+// @Failure	400	{object}	map[string]interface{}
+// @Router	/order/{id}/returns [get]
+// @Security apiKey
+func (rc *ReturnController) GetReturns(c *gin.Context) {
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil || !utils.IsValidUUID(uri.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid order id"})
+		return
+	}
+
+	res, err := rc.usecase(c).GetReturns(uri.ID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// RequestReturn godoc
+// @Summary	Request a return
+// @Description	Creates a return authorization for some line items and quantities of a completed order. The refund amount is computed from the stored line prices, discounts and taxes.
+// @Tags	Returns
+// @Accept	json
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	return	body	entities.ReturnRequest	true	"Items to return"
+// @Success	201	{object}	entities.Return
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/order/{id}/returns [post]
+// @Security apiKey
+func (rc *ReturnController) RequestReturn(c *gin.Context) {
+	var uri entities.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil || !utils.IsValidUUID(uri.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid order id"})
+		return
+	}
+
+	var post entities.ReturnRequest
+	if !bindJSON(c, &post) {
+		return
+	}
+	for _, item := range post.Items {
+		if !utils.IsValidUUID(item.OrderDetailID) {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid line item id"})
+			return
+		}
+	}
+
+	res, err := rc.usecase(c).RequestReturn(uri.ID, &post, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": res, "msg": nil})
+}
+
+// UpdateReturnStatus godoc
+// @Summary	Advance a return
+// @Description	Moves a return to its next status: requested, received, refunded. Refunding pays the refund amount back to the captured payment of the order (admin only).
+// @Tags	Returns
+// @Accept	json
+// @Produce	json
+// @Param	id	path	string	true	"Order ID"
+// @Param	returnId	path	string	true	"Return ID"
+// @Param	status	body	entities.ReturnStatusRequest	true	"New status"
+// @Success	200	{object}	entities.Return
+// @Failure	400	{object}	map[string]interface{}
+// @Failure	404	{object}	map[string]interface{}
+// @Failure	409	{object}	map[string]interface{}
+// @Router	/order/{id}/returns/{returnId} [patch]
+// @Security apiKey
+func (rc *ReturnController) UpdateReturnStatus(c *gin.Context) {
+	var uri entities.ReturnURI
+	if err := c.ShouldBindUri(&uri); err != nil || !utils.IsValidUUID(uri.ID) || !utils.IsValidUUID(uri.ReturnID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "msg": "Invalid order or return id"})
+		return
+	}
+
+	var post entities.ReturnStatusRequest
+	if !bindJSON(c, &post) {
+		return
+	}
+
+	res, err := rc.usecase(c).AdvanceReturn(uri.ID, uri.ReturnID, post.Status, c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"status": "failed", "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": res, "msg": nil})
+}
@@ -0,0 +1,33 @@
+// internal/adapters/controllers/tenancy.go
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+// The handlers run the usecases scoped to the tenant set on the request by the auth middleware.
+
+func (uc *OrderController) usecase(c *gin.Context) *usecases.OrderUsecase {
+	return uc.OrderUsecase.ForTenant(c.GetString("tenantID"))
+}
+
+func (pc *PromotionController) usecase(c *gin.Context) *usecases.PromotionUsecase {
+	return pc.PromotionUsecase.ForTenant(c.GetString("tenantID"))
+}
+
+func (pc *PaymentController) usecase(c *gin.Context) *usecases.PaymentUsecase {
+	return pc.PaymentUsecase.ForTenant(c.GetString("tenantID"))
+}
+
+func (rc *ReturnController) usecase(c *gin.Context) *usecases.ReturnUsecase {
+	return rc.ReturnUsecase.ForTenant(c.GetString("tenantID"))
+}
+
+func (rc *ReportController) usecase(c *gin.Context) *usecases.ReportUsecase {
+	return rc.ReportUsecase.ForTenant(c.GetString("tenantID"))
+}
+
+func (pc *PrivacyController) usecase(c *gin.Context) *usecases.PrivacyUsecase {
+	return pc.PrivacyUsecase.ForTenant(c.GetString("tenantID"))
+}
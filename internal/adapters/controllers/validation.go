@@ -0,0 +1,218 @@
+// internal/adapters/controllers/validation.go
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shayja/orders-service/internal/entities"
+)
+
+// Error codes of the fields of an invalid request body.
+const (
+	CodeInvalidJSON  = "invalid_json"
+	CodeUnknownField = "unknown_field"
+	CodeInvalidType  = "invalid_type"
+	CodeRequired     = "required"
+	CodeInvalidUUID  = "invalid_uuid"
+	CodeTooSmall     = "too_small"
+	CodeTooLarge     = "too_large"
+	CodeInvalidValue = "invalid_value"
+	CodeDuplicate    = "duplicate"
+	CodeBodyTooLarge = "body_too_large"
+)
+
+// FieldError describes why a field of a request body was rejected.
+type FieldError struct {
+	// The JSON path of the field
+	// example: order_details[0].quantity
+	Field string `json:"field" example:"order_details[0].quantity"`
+	// A stable code of the rule the field breaks
+	// example: too_small
+	Code string `json:"code" example:"too_small"`
+	// A description of the rule
+	// example: must be at least 1
+	Message string `json:"message" example:"must be at least 1"`
+}
+
+func init() {
+	registerEntityValidations()
+}
+
+// bindJSON decodes the JSON body of the request into obj and validates it with the binding rules of obj.
+// Unknown fields are rejected. When the body is invalid, it responds 400 Bad Request with the list of
+// the invalid fields (413 when the body is too large) and returns false.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	fieldErrors, status := decodeJSON(c, obj)
+	if fieldErrors == nil {
+		if err := binding.Validator.ValidateStruct(obj); err != nil {
+			fieldErrors, status = validationErrors(err), http.StatusBadRequest
+		}
+	}
+	if fieldErrors != nil {
+		c.JSON(status, gin.H{"status": "failed", "msg": "Invalid request body", "errors": fieldErrors})
+		return false
+	}
+	return true
+}
+
+// decodeJSON decodes the body of the request, it returns the error of a body that cannot be decoded into obj.
+func decodeJSON(c *gin.Context, obj interface{}) ([]FieldError, int) {
+	body, err := io.ReadAll(c.Request.Body)
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		message := fmt.Sprintf("must not exceed %d bytes", maxBytesError.Limit)
+		return []FieldError{{Code: CodeBodyTooLarge, Message: message}}, http.StatusRequestEntityTooLarge
+	}
+	if err != nil {
+		return []FieldError{{Code: CodeInvalidJSON, Message: err.Error()}}, http.StatusBadRequest
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(obj)
+	if err == nil && decoder.More() {
+		err = errors.New("unexpected data after the JSON value")
+	}
+
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+	switch {
+	case err == nil:
+		return nil, http.StatusOK
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+		return []FieldError{{Code: CodeInvalidJSON, Message: err.Error()}}, http.StatusBadRequest
+	case errors.As(err, &typeError):
+		message := fmt.Sprintf("must be a %s", jsonType(typeError.Type))
+		return []FieldError{{Field: typeError.Field, Code: CodeInvalidType, Message: message}}, http.StatusBadRequest
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return []FieldError{{Field: field, Code: CodeUnknownField, Message: "is not a known field"}}, http.StatusBadRequest
+	case err == io.EOF:
+		return []FieldError{{Code: CodeInvalidJSON, Message: "request body is empty"}}, http.StatusBadRequest
+	default:
+		// Values rejected by their own decoder, e.g. amounts with too many decimal places
+		return []FieldError{{Code: CodeInvalidValue, Message: err.Error()}}, http.StatusBadRequest
+	}
+}
+
+// validationErrors lists the fields that break their binding rules.
+func validationErrors(err error) []FieldError {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return []FieldError{{Code: CodeInvalidValue, Message: err.Error()}}
+	}
+	fieldErrors := make([]FieldError, 0, len(errs))
+	for _, e := range errs {
+		// The namespace starts with the name of the request type
+		_, field, _ := strings.Cut(e.Namespace(), ".")
+		code, message := ruleError(e)
+		fieldErrors = append(fieldErrors, FieldError{Field: field, Code: code, Message: message})
+	}
+	return fieldErrors
+}
+
+// ruleError returns the code and description of a broken binding rule.
+func ruleError(e validator.FieldError) (string, string) {
+	countable := e.Kind() == reflect.Slice || e.Kind() == reflect.Map || e.Kind() == reflect.String
+	switch e.Tag() {
+	case "required":
+		return CodeRequired, "is required"
+	case "uuid":
+		return CodeInvalidUUID, "must be a UUID"
+	case "min", "money_min":
+		if countable {
+			return CodeTooSmall, fmt.Sprintf("must have a length of at least %s", e.Param())
+		}
+		return CodeTooSmall, fmt.Sprintf("must be at least %s", e.Param())
+	case "max", "money_max":
+		if countable {
+			return CodeTooLarge, fmt.Sprintf("must have a length of at most %s", e.Param())
+		}
+		return CodeTooLarge, fmt.Sprintf("must be at most %s", e.Param())
+	case "len":
+		return CodeInvalidValue, fmt.Sprintf("must be %s characters long", e.Param())
+	case "unique":
+		return CodeDuplicate, fmt.Sprintf("must not repeat the same %s", jsonName(e, e.Param()))
+	default:
+		return CodeInvalidValue, fmt.Sprintf("breaks the %s rule", e.Tag())
+	}
+}
+
+// registerEntityValidations teaches the validator of gin the rules of the request entities:
+// fields are named after their JSON names, and Money amounts are bounded with money_min and money_max.
+func registerEntityValidations() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	// Amounts are validated as numbers of cents
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(entities.Money).Cents()
+	}, entities.Money{})
+	v.RegisterValidation("money_min", moneyBound(func(cents, bound int64) bool { return cents >= bound }))
+	v.RegisterValidation("money_max", moneyBound(func(cents, bound int64) bool { return cents <= bound }))
+}
+
+// moneyBound returns a validation comparing an amount with the amount given as the rule parameter.
+func moneyBound(ok func(cents int64, bound int64) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		bound, err := entities.ParseMoney(fl.Param())
+		if err != nil {
+			panic(fmt.Sprintf("invalid amount %q in %s rule", fl.Param(), fl.GetTag()))
+		}
+		return fl.Field().Kind() == reflect.Int64 && ok(fl.Field().Int(), bound.Cents())
+	}
+}
+
+// jsonName returns the JSON name of a field of the struct type validated by e.
+func jsonName(e validator.FieldError, name string) string {
+	typ := e.Type()
+	for typ.Kind() == reflect.Slice || typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Struct {
+		if field, ok := typ.FieldByName(name); ok {
+			if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" {
+				return tag
+			}
+		}
+	}
+	return name
+}
+
+// jsonType names the JSON type a Go type is decoded from.
+func jsonType(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
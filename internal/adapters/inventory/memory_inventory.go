@@ -0,0 +1,99 @@
+// adapters/inventory/memory_inventory.go
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// MemoryInventory keeps stock levels in memory, for tests and offline development.
+type MemoryInventory struct {
+	mu           sync.Mutex
+	stock        map[string]int
+	reservations map[string]*reservation
+}
+
+type reservation struct {
+	items     []entities.StockItem
+	confirmed bool
+}
+
+// NewMemoryInventory returns an inventory with the given quantity on hand per product ID.
+func NewMemoryInventory(stock map[string]int) *MemoryInventory {
+	inv := &MemoryInventory{stock: make(map[string]int, len(stock)), reservations: make(map[string]*reservation)}
+	for productID, quantity := range stock {
+		inv.stock[productID] = quantity
+	}
+	return inv
+}
+
+// LoadFileInventory returns an inventory with the stock of a JSON file mapping product IDs to quantities.
+func LoadFileInventory(path string) (*MemoryInventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stock map[string]int
+	if err := json.Unmarshal(data, &stock); err != nil {
+		return nil, fmt.Errorf("invalid inventory %s: %v", path, err)
+	}
+	return NewMemoryInventory(stock), nil
+}
+
+// Available returns the quantity of a product that can still be reserved.
+func (inv *MemoryInventory) Available(productID string) int {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.stock[productID]
+}
+
+func (inv *MemoryInventory) Reserve(reservationID string, items []entities.StockItem) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if _, ok := inv.reservations[reservationID]; ok {
+		return nil
+	}
+	for _, item := range items {
+		if inv.stock[item.ProductID] < item.Quantity {
+			return fmt.Errorf("%w: product %s", apperrors.ErrOutOfStock, item.ProductID)
+		}
+	}
+	for _, item := range items {
+		inv.stock[item.ProductID] -= item.Quantity
+	}
+	inv.reservations[reservationID] = &reservation{items: items}
+	return nil
+}
+
+func (inv *MemoryInventory) Release(reservationID string) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	r, ok := inv.reservations[reservationID]
+	if !ok {
+		return nil
+	}
+	if r.confirmed {
+		return fmt.Errorf("reservation %s is already confirmed", reservationID)
+	}
+	for _, item := range r.items {
+		inv.stock[item.ProductID] += item.Quantity
+	}
+	delete(inv.reservations, reservationID)
+	return nil
+}
+
+func (inv *MemoryInventory) Confirm(reservationID string) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	r, ok := inv.reservations[reservationID]
+	if !ok {
+		return fmt.Errorf("unknown reservation %s", reservationID)
+	}
+	r.confirmed = true
+	return nil
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/entities"
+)
+
+// AdminMiddleware only lets through requests whose token carries the admin role.
+// It must run after AuthMiddleware, which sets the role.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != entities.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"status": "failed", "msg": "Admin role required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -7,9 +7,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/shayja/orders-service/internal/entities"
 )
 
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// AuthMiddleware validates the JWT of the request and sets the user ID, role and tenant ID it carries.
+// Tokens without a tenant_id claim belong to defaultTenant, they are rejected when it is empty.
+func AuthMiddleware(secretKey string, defaultTenant string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the token from the Authorization header
 		tokenString := c.GetHeader("Authorization")
@@ -49,6 +52,23 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 		userID := claims["sub"].(string)
 		c.Set("userID", userID)
 
+		// Set the tenant, every repository call is scoped to it
+		tenantID := defaultTenant
+		if claim, ok := claims["tenant_id"]; ok {
+			tenantID, _ = claim.(string)
+		}
+		if !entities.IsValidTenantID(tenantID) {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "msg": "Invalid or missing tenant"})
+			c.Abort()
+			return
+		}
+		c.Set("tenantID", tenantID)
+
+		// Set the role (if any), used to grant admin access to other users' data
+		if role, ok := claims["role"].(string); ok {
+			c.Set("role", role)
+		}
+
 		// Continue with the next middleware/handler
 		c.Next()
 	}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit is a token bucket: it holds up to Requests tokens and is refilled with Requests tokens every Period.
+// Every request takes a token. The zero RateLimit does not limit requests.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseRateLimit parses a rate limit written as <requests>/<period>, e.g. "60/1m" or "5/10s".
+// An empty value or "0" disables the limit.
+func ParseRateLimit(value string) (RateLimit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return RateLimit{}, nil
+	}
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, expected <requests>/<period>", value)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", value)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", value)
+	}
+	return RateLimit{Requests: n, Period: d}, nil
+}
+
+// RateLimitResult is the state of a bucket after a request took, or failed to take, a token.
+type RateLimitResult struct {
+	Allowed bool
+	// The tokens left in the bucket
+	Remaining int
+	// The time until a token is available, zero while the bucket holds some
+	RetryAfter time.Duration
+	// The time until the bucket is full again
+	Reset time.Duration
+}
+
+// RateLimitStore keeps the token buckets of the rate limits, by key.
+// A store shared by the replicas of the service makes the limits apply to the service as a whole.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key, created full when it does not exist.
+	Take(key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// MemoryRateLimitStore keeps the token buckets in memory, the limits apply to each replica on its own.
+// Buckets that are full again are dropped.
+type MemoryRateLimitStore struct {
+	// Returns the current time, time.Now when nil
+	Now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     RateLimit
+}
+
+// How often the full buckets are dropped from a MemoryRateLimitStore.
+const RATE_LIMIT_SWEEP_INTERVAL = time.Minute
+
+// Take implements RateLimitStore.
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit) (RateLimitResult, error) {
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets == nil {
+		s.buckets = make(map[string]*tokenBucket)
+		s.lastSweep = now
+	}
+	if now.Sub(s.lastSweep) >= RATE_LIMIT_SWEEP_INTERVAL {
+		s.sweep(now)
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Requests), updatedAt: now, limit: limit}
+		s.buckets[key] = bucket
+	}
+	bucket.refill(now)
+
+	result := RateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = bucket.timeFor(1 - bucket.tokens)
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = bucket.timeFor(float64(limit.Requests) - bucket.tokens)
+	return result, nil
+}
+
+// sweep drops the buckets that are full again, a new bucket would be created in the same state.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, bucket := range s.buckets {
+		bucket.refill(now)
+		if bucket.tokens >= float64(bucket.limit.Requests) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// refill adds the tokens earned since the last update, up to the size of the bucket.
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updatedAt)
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(float64(b.limit.Requests), b.tokens+elapsed.Seconds()*b.rate())
+	b.updatedAt = now
+}
+
+// timeFor returns the time the bucket takes to earn tokens.
+func (b *tokenBucket) timeFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / b.rate() * float64(time.Second))
+}
+
+// rate returns the tokens earned per second.
+func (b *tokenBucket) rate() float64 {
+	return float64(b.limit.Requests) / b.limit.Period.Seconds()
+}
+
+// RateLimitMiddleware limits the requests to each route of a route group, per user when the request
+// carries a token and per client IP otherwise. It must run after AuthMiddleware on authenticated routes.
+// The responses carry the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and requests
+// over the limit are rejected with 429 Too Many Requests and a Retry-After header.
+// When the store fails, requests are let through.
+func RateLimitMiddleware(group string, limit RateLimit, store RateLimitStore) gin.HandlerFunc {
+	if limit.Requests <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		client := "ip:" + c.ClientIP()
+		if userID := c.GetString("userID"); userID != "" {
+			client = "user:" + c.GetString("tenantID") + ":" + userID
+		}
+		key := strings.Join([]string{group, c.Request.Method, c.FullPath(), client}, " ")
+
+		result, err := store.Take(key, limit)
+		if err != nil {
+			log.Printf("Rate limit store failed: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"status": "failed", "msg": "Too many requests"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as the rate limit headers carry.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,104 @@
+// adapters/payments/fake_gateway.go
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+// FakeGateway is an in-process payment provider, for tests and development.
+// Its webhook payloads are signed with the hex HMAC-SHA256 of the payload under Secret.
+type FakeGateway struct {
+	// Signs the webhook payloads, webhooks are rejected when empty.
+	Secret string
+	// Amounts above DeclineAbove are declined, zero declines none.
+	DeclineAbove entities.Money
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+type fakePayment struct {
+	authorized, captured, refunded entities.Money
+	refunds                        map[string]bool // idempotency keys
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) Authorize(orderID string, amount entities.Money, currency string) (string, error) {
+	ref := "fake_" + utils.CreateNewUUID().String()
+	if !g.DeclineAbove.IsZero() && g.DeclineAbove.Sub(amount).IsNegative() {
+		return ref, fmt.Errorf("%w: amount above %s %s", apperrors.ErrPaymentDeclined, g.DeclineAbove, currency)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.payments == nil {
+		g.payments = make(map[string]*fakePayment)
+	}
+	g.payments[ref] = &fakePayment{authorized: amount}
+	return ref, nil
+}
+
+func (g *FakeGateway) Capture(providerRef string, amount entities.Money) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.payments[providerRef]
+	if !ok {
+		return fmt.Errorf("unknown payment %s", providerRef)
+	}
+	if p.authorized.Sub(amount).IsNegative() {
+		return fmt.Errorf("%w: capture exceeds the authorized amount", apperrors.ErrPaymentDeclined)
+	}
+	p.captured = amount
+	return nil
+}
+
+func (g *FakeGateway) Refund(providerRef string, amount entities.Money, idempotencyKey string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.payments[providerRef]
+	if !ok {
+		return fmt.Errorf("unknown payment %s", providerRef)
+	}
+	if p.refunds[idempotencyKey] {
+		return nil
+	}
+	if p.captured.Sub(p.refunded).Sub(amount).IsNegative() {
+		return fmt.Errorf("%w: refund exceeds the captured amount", apperrors.ErrPaymentDeclined)
+	}
+	p.refunded = p.refunded.Add(amount)
+	if p.refunds == nil {
+		p.refunds = make(map[string]bool)
+	}
+	p.refunds[idempotencyKey] = true
+	return nil
+}
+
+func (g *FakeGateway) ParseWebhook(payload []byte, signature string) (*entities.PaymentEvent, error) {
+	if g.Secret == "" || !hmac.Equal([]byte(g.Sign(payload)), []byte(signature)) {
+		return nil, apperrors.ErrInvalidSignature
+	}
+	var event entities.PaymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" || event.ProviderRef == "" {
+		return nil, fmt.Errorf("%w: malformed payment event", apperrors.ErrInvalidRequest)
+	}
+	return &event, nil
+}
+
+// Sign returns the signature of a webhook payload.
+func (g *FakeGateway) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(g.Secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,104 @@
+// adapters/repositories/cache/memory_backend.go
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity is the number of orders a MemoryBackend keeps when its Capacity is not set.
+const DefaultCapacity = 10000
+
+// MemoryBackend keeps the orders in memory, each replica of the service has its own cache.
+// When it is full, the least recently used order is evicted.
+type MemoryBackend struct {
+	// The maximum number of orders kept, DefaultCapacity when not set
+	Capacity int
+	// Returns the current time, time.Now when nil
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// Most recently used first
+	lru *list.List
+}
+
+type memoryEntry struct {
+	id        string
+	entry     Entry
+	expiresAt time.Time
+}
+
+// Get returns the entry of an order, expired entries are dropped.
+func (b *MemoryBackend) Get(id string) (Entry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	element, ok := b.entries[id]
+	if !ok {
+		return Entry{}, false
+	}
+	item := element.Value.(*memoryEntry)
+	if !b.now().Before(item.expiresAt) {
+		b.remove(element)
+		return Entry{}, false
+	}
+	b.lru.MoveToFront(element)
+	return item.entry, true
+}
+
+// Set stores the entry of an order for ttl.
+func (b *MemoryBackend) Set(id string, entry Entry, ttl time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.entries == nil {
+		b.entries = map[string]*list.Element{}
+		b.lru = list.New()
+	}
+	expiresAt := b.now().Add(ttl)
+	if element, ok := b.entries[id]; ok {
+		element.Value = &memoryEntry{id: id, entry: entry, expiresAt: expiresAt}
+		b.lru.MoveToFront(element)
+		return
+	}
+	b.entries[id] = b.lru.PushFront(&memoryEntry{id: id, entry: entry, expiresAt: expiresAt})
+
+	capacity := b.Capacity
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	for b.lru.Len() > capacity {
+		b.remove(b.lru.Back())
+	}
+}
+
+// Delete drops the entry of an order.
+func (b *MemoryBackend) Delete(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if element, ok := b.entries[id]; ok {
+		b.remove(element)
+	}
+}
+
+// Len returns the number of orders kept, expired ones included until they are looked up or evicted.
+func (b *MemoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.lru == nil {
+		return 0
+	}
+	return b.lru.Len()
+}
+
+func (b *MemoryBackend) remove(element *list.Element) {
+	b.lru.Remove(element)
+	delete(b.entries, element.Value.(*memoryEntry).id)
+}
+
+func (b *MemoryBackend) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,186 @@
+// adapters/repositories/cache/order_cache.go
+package cache
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+	"golang.org/x/sync/singleflight"
+)
+
+// Entry is a cached order with the tenant scope it was read in.
+type Entry struct {
+	TenantID string
+	Order    *entities.Order
+}
+
+// Backend stores the cached orders by order ID.
+// A backend shared by the replicas of the service, such as Redis, also shares the invalidations.
+type Backend interface {
+	// Get returns the entry of an order, false when it is not cached or expired.
+	Get(id string) (Entry, bool)
+	// Set stores the entry of an order for ttl.
+	Set(id string, entry Entry, ttl time.Duration)
+	// Delete drops the entry of an order.
+	Delete(id string)
+}
+
+// Metrics counts the lookups of orders served from the cache and from the repository.
+type Metrics struct {
+	Hits   expvar.Int
+	Misses expvar.Int
+}
+
+// String formats the metrics as a JSON object, so that they can be published with expvar.
+func (m *Metrics) String() string {
+	return `{"hits": ` + m.Hits.String() + `, "misses": ` + m.Misses.String() + `}`
+}
+
+// OrderRepository is an OrderRepository keeping the orders read by GetByID in a cache for TTL.
+// The writes made through it drop the cached copies of the orders they change, and concurrent
+// lookups of an order that is not cached are served by a single query.
+// Orders that are not found are not cached.
+type OrderRepository struct {
+	usecases.OrderRepository
+	Backend Backend
+	TTL     time.Duration
+	Metrics *Metrics
+
+	tenantID string
+	loads    *singleflight.Group
+	// Bumped by every invalidation, so that a lookup running across a write does not cache what it read before it
+	generation *atomic.Uint64
+}
+
+// NewOrderRepository returns repo with a cache of the orders in backend.
+func NewOrderRepository(repo usecases.OrderRepository, backend Backend, ttl time.Duration) *OrderRepository {
+	return &OrderRepository{
+		OrderRepository: repo,
+		Backend:         backend,
+		TTL:             ttl,
+		Metrics:         &Metrics{},
+		loads:           &singleflight.Group{},
+		generation:      &atomic.Uint64{},
+	}
+}
+
+// ForTenant returns the repository scoped to the orders of a tenant, sharing the cache.
+// A cached order is only served in the tenant scope it was read in.
+func (r *OrderRepository) ForTenant(tenantID string) usecases.OrderRepository {
+	scoped := *r
+	scoped.OrderRepository = r.OrderRepository.ForTenant(tenantID)
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+// GetByID returns a copy of the cached order, or reads it from the repository and caches it.
+func (r *OrderRepository) GetByID(id string) (*entities.Order, error) {
+	if entry, ok := r.Backend.Get(id); ok && entry.TenantID == r.tenantID {
+		r.Metrics.Hits.Add(1)
+		return cloneOrder(entry.Order), nil
+	}
+	r.Metrics.Misses.Add(1)
+
+	order, err, _ := r.loads.Do(r.tenantID+"/"+id, func() (interface{}, error) {
+		generation := r.generation.Load()
+		order, err := r.OrderRepository.GetByID(id)
+		if err != nil || order == nil || order.ID == "" {
+			return order, err
+		}
+		if r.generation.Load() == generation {
+			r.Backend.Set(id, Entry{TenantID: r.tenantID, Order: order}, r.TTL)
+		}
+		return order, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneOrder(order.(*entities.Order)), nil
+}
+
+// Invalidate drops the cached copies of orders, for the changes made to them outside of the repository.
+func (r *OrderRepository) Invalidate(ids ...string) {
+	r.generation.Add(1)
+	for _, id := range ids {
+		r.Backend.Delete(id)
+	}
+}
+
+func (r *OrderRepository) UpdateStatus(id string, status int, expectedVersion int, actorID string) (*entities.Order, error) {
+	defer r.Invalidate(id)
+	return r.OrderRepository.UpdateStatus(id, status, expectedVersion, actorID)
+}
+
+func (r *OrderRepository) UpdateStatusBulk(updates []entities.StatusUpdate, atomic bool, actorID string) ([]entities.BulkItemResult, error) {
+	ids := make([]string, len(updates))
+	for i, u := range updates {
+		ids[i] = u.ID
+	}
+	defer r.Invalidate(ids...)
+	return r.OrderRepository.UpdateStatusBulk(updates, atomic, actorID)
+}
+
+func (r *OrderRepository) AddOrderItem(orderID string, item *entities.OrderItemRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	defer r.Invalidate(orderID)
+	return r.OrderRepository.AddOrderItem(orderID, item, expectedVersion, actorID)
+}
+
+func (r *OrderRepository) UpdateOrderItem(orderID string, itemID string, update *entities.OrderItemUpdate, expectedVersion int, actorID string) (*entities.Order, error) {
+	defer r.Invalidate(orderID)
+	return r.OrderRepository.UpdateOrderItem(orderID, itemID, update, expectedVersion, actorID)
+}
+
+func (r *OrderRepository) RemoveOrderItem(orderID string, itemID string, expectedVersion int, actorID string) (*entities.Order, error) {
+	defer r.Invalidate(orderID)
+	return r.OrderRepository.RemoveOrderItem(orderID, itemID, expectedVersion, actorID)
+}
+
+func (r *OrderRepository) ForceStatus(id string, status int, reason string, expectedVersion int, actorID string) (*entities.Order, error) {
+	defer r.Invalidate(id)
+	return r.OrderRepository.ForceStatus(id, status, reason, expectedVersion, actorID)
+}
+
+func (r *OrderRepository) DeleteOrder(id string, expectedVersion int, actorID string) error {
+	defer r.Invalidate(id)
+	return r.OrderRepository.DeleteOrder(id, expectedVersion, actorID)
+}
+
+// CancelStalePending drops the cancelled orders, including those of the batches committed before a failure.
+func (r *OrderRepository) CancelStalePending(before time.Time, batchSize int, reason string) ([]string, error) {
+	ids, err := r.OrderRepository.CancelStalePending(before, batchSize, reason)
+	r.Invalidate(ids...)
+	return ids, err
+}
+
+// ArchiveOrders drops the archived orders, which are no longer found by GetByID.
+func (r *OrderRepository) ArchiveOrders(before time.Time, batchSize int) ([]string, error) {
+	ids, err := r.OrderRepository.ArchiveOrders(before, batchSize)
+	r.Invalidate(ids...)
+	return ids, err
+}
+
+// cloneOrder copies an order, so that the callers can change the order they get without changing the cached one.
+func cloneOrder(order *entities.Order) *entities.Order {
+	if order == nil {
+		return nil
+	}
+	clone := *order
+	if order.OrderDetails != nil {
+		clone.OrderDetails = append([]entities.OrderDetail(nil), order.OrderDetails...)
+	}
+	if order.Returns != nil {
+		clone.Returns = append([]entities.Return(nil), order.Returns...)
+	}
+	if order.ShippingAddress != nil {
+		address := *order.ShippingAddress
+		clone.ShippingAddress = &address
+	}
+	if order.BillingAddress != nil {
+		address := *order.BillingAddress
+		clone.BillingAddress = &address
+	}
+	return &clone
+}
@@ -0,0 +1,24 @@
+// adapters/repositories/history/history.go
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Insert records a change of an order in its history, in the transaction of the change.
+// details is stored as JSON. An empty actorID marks a change made by the system.
+func Insert(tx *sql.Tx, orderID string, event string, details interface{}, actorID string) error {
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	var actor sql.NullString
+	if actorID != "" {
+		actor = sql.NullString{String: actorID, Valid: true}
+	}
+	_, err = tx.Exec(
+		`INSERT INTO order_history (order_id, event, details, actor_id) VALUES ($1, $2, $3, $4)`,
+		orderID, event, payload, actor)
+	return err
+}
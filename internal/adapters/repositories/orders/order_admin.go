@@ -0,0 +1,127 @@
+// adapters/repositories/orders/order_admin.go
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/history"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// Search the orders of all users matching the filter, newest first.
+// Pages hold filter.PageSize orders, PAGE_SIZE when it is not set.
+func (r *OrderRepository) SearchOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	pageSize := PAGE_SIZE
+	if filter.PageSize > 0 {
+		pageSize = filter.PageSize
+	}
+	where, args := orderFilterClause(filter, "o", nil)
+	args = append(args, pageSize*(page-1), pageSize)
+	query := fmt.Sprintf(`SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency,
+		o.subtotal, o.discount_total, o.tax_total, o.shipping_total
+		FROM orders o%s ORDER BY o.created_at DESC, o.id OFFSET $%d LIMIT $%d`, where, len(args)-1, len(args))
+
+	rows, err := r.db().Query(query, args...)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*entities.Order
+	for rows.Next() {
+		order := &entities.Order{}
+		if err := rows.Scan(orderDest(order)...); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// Set the status of an order regardless of the status rules, and record the previous status
+// and the reason in the order history.
+func (r *OrderRepository) ForceStatus(id string, status int, reason string, expectedVersion int, actorID string) (*entities.Order, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	previous, err := lockOrder(tx, id, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if previous == status {
+		return nil, fmt.Errorf("%w: order is already in status %d", apperrors.ErrInvalidRequest, status)
+	}
+
+	// The version and updated_at columns are maintained by the orders_bump_version trigger
+	if _, err := tx.Exec(`UPDATE orders SET status = $2 WHERE id = $1`, id, status); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	details := map[string]interface{}{"from": previous, "to": status, "reason": reason}
+	if err := history.Insert(tx, id, entities.HistoryStatusForced, details, actorID); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	return r.reload(id)
+}
+
+// Get the recorded changes of an order, oldest first.
+func (r *OrderRepository) GetOrderHistory(orderID string) ([]entities.OrderHistory, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Soft deleted orders keep their history until they are archived
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1)`, orderID).Scan(&exists); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if !exists {
+		return nil, apperrors.ErrOrderNotFound
+	}
+
+	rows, err := tx.Query(
+		`SELECT id, order_id, event, details, actor_id, created_at FROM order_history
+		WHERE order_id = $1 ORDER BY created_at, id`,
+		orderID)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []entities.OrderHistory{}
+	for rows.Next() {
+		var entry entities.OrderHistory
+		var details []byte
+		var actorID sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.OrderID, &entry.Event, &details, &actorID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.Details = details
+		entry.ActorID = actorID.String
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return history, tx.Commit()
+}
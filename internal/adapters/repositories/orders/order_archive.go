@@ -0,0 +1,271 @@
+// adapters/repositories/orders/order_archive.go
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shayja/orders-service/internal/adapters/repositories/history"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// ARCHIVE_LOCK_KEY is the Postgres advisory lock held by the replica moving old orders to the archive.
+const ARCHIVE_LOCK_KEY = 4_276_002
+
+// The tables holding the rows of an order, copied to their archive table in this order.
+// $1 is the array of the IDs of the archived orders.
+var archiveTables = []struct {
+	table  string
+	filter string
+}{
+	{"order_details", "order_id = ANY($1)"},
+	{"order_history", "order_id = ANY($1)"},
+	{"order_fulfilment", "order_id = ANY($1)"},
+	{"order_addresses", "order_id = ANY($1)"},
+	{"payments", "order_id = ANY($1)"},
+	{"payment_events", "payment_id IN (SELECT id FROM payments WHERE order_id = ANY($1))"},
+	{"returns", "order_id = ANY($1)"},
+	{"return_items", "return_id IN (SELECT id FROM returns WHERE order_id = ANY($1))"},
+	{"promotion_redemptions", "order_id = ANY($1)"},
+}
+
+// Soft delete a completed or cancelled order, when expectedVersion is set the order must still be at that version.
+// The order and its history are kept until it is archived, but no query finds it anymore.
+// Orders whose inventory saga is not finished yet are rejected, like open orders.
+func (r *OrderRepository) DeleteOrder(id string, expectedVersion int, actorID string) error {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockOrder(tx, id, expectedVersion)
+	if err != nil {
+		return err
+	}
+	if status != entities.OrderStatusCompleted && status != entities.OrderStatusCancelled {
+		return apperrors.ErrOrderOpen
+	}
+
+	var sagaOpen bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM order_sagas WHERE order_id = $1 AND state = 'order_created')`, id).Scan(&sagaOpen)
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	if sagaOpen {
+		return apperrors.ErrOrderOpen
+	}
+
+	// The version and updated_at columns are maintained by the orders_bump_version trigger
+	if _, err := tx.Exec(`UPDATE orders SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1`, id); err != nil {
+		fmt.Print(err)
+		return err
+	}
+
+	if err := history.Insert(tx, id, entities.HistoryDeleted, map[string]interface{}{"status": status}, actorID); err != nil {
+		fmt.Print(err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return err
+	}
+	return nil
+}
+
+// Move the orders created before before, batchSize orders per transaction, to the archive tables with all their rows.
+// Only completed, cancelled and deleted orders are archived, and only once their inventory saga is finished.
+// Only the replica holding the advisory lock archives, the others get ErrJobRunning. Orders locked by a
+// concurrent request are skipped and picked up by the next run.
+// It returns the IDs of the archived orders.
+func (r *OrderRepository) ArchiveOrders(before time.Time, batchSize int) ([]string, error) {
+	if r.TenantID == "" {
+		return nil, apperrors.ErrTenantRequired
+	}
+	ctx := context.Background()
+	// The advisory lock belongs to the session, so the whole run uses one connection
+	conn, err := r.Db.Conn(ctx)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, ARCHIVE_LOCK_KEY).Scan(&locked); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if !locked {
+		return nil, apperrors.ErrJobRunning
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, ARCHIVE_LOCK_KEY)
+
+	var archived []string
+	for {
+		ids, err := archiveBatch(ctx, conn, r.TenantID, before, batchSize)
+		if err != nil {
+			fmt.Print(err)
+			return archived, err
+		}
+		archived = append(archived, ids...)
+		if len(ids) < batchSize {
+			return archived, nil
+		}
+	}
+}
+
+// archiveBatch moves up to batchSize orders of a tenant to the archive tables in one transaction.
+func archiveBatch(ctx context.Context, conn *sql.Conn, tenantID string, before time.Time, batchSize int) ([]string, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := tenancy.SetTenant(ctx, tx, tenantID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT o.id FROM orders o
+		WHERE o.created_at < $1 AND (o.status IN ($2, $3) OR o.deleted_at IS NOT NULL)
+			AND NOT EXISTS (SELECT 1 FROM order_sagas s WHERE s.order_id = o.id AND s.state = 'order_created')
+		ORDER BY o.created_at
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED`,
+		before, entities.OrderStatusCompleted, entities.OrderStatusCancelled, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// orders_archive has the columns of orders followed by archived_at
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO orders_archive SELECT o.*, CURRENT_TIMESTAMP FROM orders o WHERE o.id = ANY($1)`,
+		pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	for _, t := range archiveTables {
+		query := `INSERT INTO ` + t.table + `_archive SELECT * FROM ` + t.table + ` WHERE ` + t.filter
+		if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The history is the only table not removed with its order
+	if _, err := tx.ExecContext(ctx, `DELETE FROM order_history WHERE order_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM orders WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	return ids, tx.Commit()
+}
+
+// Get the user orders including the archived ones, narrowed down by the optional status, currency and date range
+// of the filter, newest first. Archived orders have ArchivedAt set.
+func (r *OrderRepository) getAllWithArchived(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	pageSize := PAGE_SIZE
+	if filter.PageSize > 0 {
+		pageSize = filter.PageSize
+	}
+	live, args := orderFilterClause(filter, "o", nil)
+	archive, args := orderFilterClause(filter, "a", args)
+	args = append(args, pageSize*(page-1), pageSize)
+	query := fmt.Sprintf(`SELECT id, user_id, total_price, status, created_at, updated_at, version, currency,
+		subtotal, discount_total, tax_total, shipping_total, archived_at
+		FROM (
+			SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency,
+				o.subtotal, o.discount_total, o.tax_total, o.shipping_total, NULL::timestamp AS archived_at
+			FROM orders o%s
+			UNION ALL
+			SELECT a.id, a.user_id, a.total_price, a.status, a.created_at, a.updated_at, a.version, a.currency,
+				a.subtotal, a.discount_total, a.tax_total, a.shipping_total, a.archived_at
+			FROM orders_archive a%s
+		) AS all_orders
+		ORDER BY created_at DESC, id OFFSET $%d LIMIT $%d`, live, archive, len(args)-1, len(args))
+
+	rows, err := r.replica(filter.UserID).Query(query, args...)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*entities.Order
+	for rows.Next() {
+		order := &entities.Order{}
+		if err := rows.Scan(append(orderDest(order), &order.ArchivedAt)...); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// Get an archived order by ID with its line items.
+// Like GetByID, it returns an order without ID when there is no such order in the archive.
+func (r *OrderRepository) GetArchivedByID(id string) (*entities.Order, error) {
+	rows, err := r.db().Query(
+		`SELECT id, user_id, total_price, status, created_at, updated_at, version, currency,
+			subtotal, discount_total, tax_total, shipping_total, archived_at
+		FROM orders_archive WHERE id = $1 AND deleted_at IS NULL`,
+		id)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	order := &entities.Order{}
+	if !rows.Next() {
+		return order, rows.Err()
+	}
+	if err := rows.Scan(append(orderDest(order), &order.ArchivedAt)...); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	rows.Close()
+
+	details, err := r.db().Query(
+		`SELECT id, order_id, product_id, quantity, unit_price, total_price, discount_amount, tax_amount, created_at, updated_at
+		FROM order_details_archive WHERE order_id = $1 ORDER BY created_at, id`,
+		id)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer details.Close()
+
+	for details.Next() {
+		var detail entities.OrderDetail
+		if err := details.Scan(&detail.ID, &detail.OrderID, &detail.ProductID, &detail.Quantity, &detail.UnitPrice, &detail.TotalPrice, &detail.DiscountAmount, &detail.TaxAmount, &detail.CreatedAt, &detail.UpdatedAt); err != nil {
+			return nil, err
+		}
+		order.OrderDetails = append(order.OrderDetails, detail)
+	}
+	return order, details.Err()
+}
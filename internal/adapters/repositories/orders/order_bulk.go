@@ -0,0 +1,231 @@
+// adapters/repositories/orders/order_bulk.go
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/history"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+// BULK_CHUNK_SIZE is the number of rows sent in one multi-row statement.
+// Keeps the number of bind parameters well below the Postgres limit of 65535.
+const BULK_CHUNK_SIZE = 1000
+
+// Create many orders using multi-row inserts.
+// In atomic mode the whole batch is committed or rolled back together. Otherwise a failing
+// batch is retried order by order inside savepoints, so every item gets its own result.
+func (r *OrderRepository) CreateBulk(orders []*entities.OrderRequest, atomic bool) ([]entities.BulkItemResult, error) {
+	ids := make([]string, len(orders))
+	for i := range orders {
+		ids[i] = utils.CreateNewUUID().String()
+	}
+
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]entities.BulkItemResult, len(orders))
+
+	if atomic {
+		if err := insertOrders(tx, orders, ids); err != nil {
+			fmt.Print(err)
+			return nil, err
+		}
+		for i := range orders {
+			results[i] = entities.BulkItemResult{Index: i, ID: ids[i], Status: entities.BulkItemSuccess}
+		}
+	} else {
+		if _, err := tx.Exec("SAVEPOINT bulk_batch"); err != nil {
+			return nil, err
+		}
+		if err := insertOrders(tx, orders, ids); err == nil {
+			for i := range orders {
+				results[i] = entities.BulkItemResult{Index: i, ID: ids[i], Status: entities.BulkItemSuccess}
+			}
+		} else {
+			// The batch failed, find the offending orders one by one.
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT bulk_batch"); err != nil {
+				return nil, err
+			}
+			for i := range orders {
+				if _, err := tx.Exec("SAVEPOINT bulk_item"); err != nil {
+					return nil, err
+				}
+				if err := insertOrders(tx, orders[i:i+1], ids[i:i+1]); err != nil {
+					if _, err := tx.Exec("ROLLBACK TO SAVEPOINT bulk_item"); err != nil {
+						return nil, err
+					}
+					results[i] = entities.BulkItemResult{Index: i, Status: entities.BulkItemFailed, Error: err.Error()}
+					continue
+				}
+				if _, err := tx.Exec("RELEASE SAVEPOINT bulk_item"); err != nil {
+					return nil, err
+				}
+				results[i] = entities.BulkItemResult{Index: i, ID: ids[i], Status: entities.BulkItemSuccess}
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	for i, result := range results {
+		if result.Status == entities.BulkItemSuccess {
+			r.wrote(orders[i].UserID)
+		}
+	}
+	return results, nil
+}
+
+// Update the status of many orders with a single statement per chunk.
+// In atomic mode a missing order rolls back the whole batch. Otherwise missing orders are
+// reported as failed items and the other updates are kept. Every change is recorded in the order history.
+func (r *OrderRepository) UpdateStatusBulk(updates []entities.StatusUpdate, atomic bool, actorID string) ([]entities.BulkItemResult, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The previous status of every updated order, for its history
+	updated := make(map[string]int, len(updates))
+	for start := 0; start < len(updates); start += BULK_CHUNK_SIZE {
+		end := min(start+BULK_CHUNK_SIZE, len(updates))
+		chunk := updates[start:end]
+
+		values := make([]string, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*2)
+		for i, u := range chunk {
+			values[i] = fmt.Sprintf("($%d::uuid, $%d::integer)", i*2+1, i*2+2)
+			args = append(args, u.ID, u.Status)
+		}
+
+		// The orders are locked in ID order first, to read their status before the update
+		query := `WITH v(id, status) AS (VALUES ` + strings.Join(values, ", ") + `),
+			prev AS (SELECT o.id, o.status FROM orders o JOIN v ON v.id = o.id
+				WHERE o.deleted_at IS NULL ORDER BY o.id FOR UPDATE OF o)
+			UPDATE orders AS o SET status = v.status, updated_at = CURRENT_TIMESTAMP
+			FROM v JOIN prev ON prev.id = v.id
+			WHERE o.id = v.id AND o.deleted_at IS NULL RETURNING o.id, prev.status`
+		rows, err := tx.Query(query, args...)
+		if err != nil {
+			fmt.Print(err)
+			return nil, err
+		}
+		for rows.Next() {
+			var id string
+			var previous int
+			if err := rows.Scan(&id, &previous); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			updated[id] = previous
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]entities.BulkItemResult, len(updates))
+	for i, u := range updates {
+		if _, ok := updated[u.ID]; !ok {
+			if atomic {
+				return nil, fmt.Errorf("%w: %s", apperrors.ErrOrderNotFound, u.ID)
+			}
+			results[i] = entities.BulkItemResult{Index: i, ID: u.ID, Status: entities.BulkItemFailed, Error: apperrors.ErrOrderNotFound.Error()}
+			continue
+		}
+		results[i] = entities.BulkItemResult{Index: i, ID: u.ID, Status: entities.BulkItemSuccess}
+	}
+
+	for _, u := range updates {
+		previous, ok := updated[u.ID]
+		if !ok {
+			continue
+		}
+		details := map[string]interface{}{"from": previous, "to": u.Status}
+		if err := history.Insert(tx, u.ID, entities.HistoryStatusChanged, details, actorID); err != nil {
+			fmt.Print(err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// insertOrders writes the orders and their line items using chunked multi-row inserts.
+// Delivery details are rare in imports and are written order by order.
+func insertOrders(tx *sql.Tx, orders []*entities.OrderRequest, ids []string) error {
+	for start := 0; start < len(orders); start += BULK_CHUNK_SIZE {
+		end := min(start+BULK_CHUNK_SIZE, len(orders))
+
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*9)
+		for i := start; i < end; i++ {
+			values = append(values, placeholders(len(args), 9))
+			o := orders[i]
+			args = append(args, ids[i], o.UserID, o.TotalPrice, o.Status, o.Currency, o.Subtotal, o.DiscountTotal, o.TaxTotal, o.ShippingTotal)
+		}
+		query := `INSERT INTO orders (id, user_id, total_price, status, currency, subtotal, discount_total, tax_total, shipping_total) VALUES ` + strings.Join(values, ", ")
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+
+	values := make([]string, 0, BULK_CHUNK_SIZE)
+	args := make([]interface{}, 0, BULK_CHUNK_SIZE*6)
+	flush := func() error {
+		if len(values) == 0 {
+			return nil
+		}
+		query := `INSERT INTO order_details (order_id, product_id, quantity, unit_price, discount_amount, tax_amount) VALUES ` + strings.Join(values, ", ")
+		_, err := tx.Exec(query, args...)
+		values, args = values[:0], args[:0]
+		return err
+	}
+	for i, order := range orders {
+		for _, detail := range order.OrderDetails {
+			values = append(values, placeholders(len(args), 6))
+			args = append(args, ids[i], detail.ProductID, detail.Quantity, detail.UnitPrice, detail.DiscountAmount, detail.TaxAmount)
+			if len(values) == BULK_CHUNK_SIZE {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	for i, order := range orders {
+		if err := insertDelivery(tx, ids[i], order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// placeholders returns a row of count placeholders numbered after the first offset arguments, e.g. "($3, $4)".
+func placeholders(offset int, count int) string {
+	params := make([]string, count)
+	for i := range params {
+		params[i] = fmt.Sprintf("$%d", offset+i+1)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
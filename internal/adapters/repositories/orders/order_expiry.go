@@ -0,0 +1,116 @@
+// adapters/repositories/orders/order_expiry.go
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// AUTO_CANCEL_LOCK_KEY is the Postgres advisory lock held by the replica sweeping stale pending orders.
+const AUTO_CANCEL_LOCK_KEY = 4_276_001
+
+// Cancel the orders pending since before, batchSize orders per transaction, and record reason in their history.
+// Only the replica holding the advisory lock sweeps, the others get ErrJobRunning. Orders locked by a
+// concurrent request are skipped and picked up by the next sweep, and so are orders with an open payment.
+// It returns the IDs of the cancelled orders.
+func (r *OrderRepository) CancelStalePending(before time.Time, batchSize int, reason string) ([]string, error) {
+	if r.TenantID == "" {
+		return nil, apperrors.ErrTenantRequired
+	}
+	ctx := context.Background()
+	// The advisory lock belongs to the session, so the whole sweep runs on one connection
+	conn, err := r.Db.Conn(ctx)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, AUTO_CANCEL_LOCK_KEY).Scan(&locked); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if !locked {
+		return nil, apperrors.ErrJobRunning
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, AUTO_CANCEL_LOCK_KEY)
+
+	details, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return nil, err
+	}
+
+	var cancelled []string
+	for {
+		ids, err := cancelPendingBatch(ctx, conn, r.TenantID, before, batchSize, details)
+		if err != nil {
+			fmt.Print(err)
+			return cancelled, err
+		}
+		cancelled = append(cancelled, ids...)
+		if len(ids) < batchSize {
+			return cancelled, nil
+		}
+	}
+}
+
+// cancelPendingBatch cancels up to batchSize stale pending orders of a tenant in one transaction.
+func cancelPendingBatch(ctx context.Context, conn *sql.Conn, tenantID string, before time.Time, batchSize int, details []byte) ([]string, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := tenancy.SetTenant(ctx, tx, tenantID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`WITH stale AS (
+			SELECT o.id FROM orders o
+			WHERE o.status = $1 AND o.created_at < $2 AND o.deleted_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM payments p WHERE p.order_id = o.id AND p.status IN ('authorized', 'captured'))
+			ORDER BY o.created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE orders SET status = $4 FROM stale WHERE orders.id = stale.id RETURNING orders.id`,
+		entities.OrderStatusPending, before, batchSize, entities.OrderStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// A NULL actor marks a change made by the system
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO order_history (order_id, event, details) SELECT id, $2, $3 FROM unnest($1::uuid[]) AS id`,
+		pq.Array(ids), entities.HistoryAutoCancelled, details)
+	if err != nil {
+		return nil, err
+	}
+	return ids, tx.Commit()
+}
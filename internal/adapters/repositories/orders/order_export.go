@@ -0,0 +1,167 @@
+// adapters/repositories/orders/order_export.go
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/shayja/orders-service/internal/entities"
+)
+
+// EXPORT_FETCH_SIZE is the number of rows fetched from the export cursor at a time.
+const EXPORT_FETCH_SIZE = 500
+
+// Stream the orders matching the filter to fn, oldest first.
+// Rows are read through a server-side cursor in a read-only transaction, so only
+// EXPORT_FETCH_SIZE rows are held in memory at any time. When includeItems is set,
+// every order is passed to fn together with its line items.
+func (r *OrderRepository) ExportOrders(filter entities.OrderFilter, includeItems bool, fn func(*entities.Order) error) error {
+	tx, err := r.replica(filter.UserID).BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	where, args := orderFilterClause(filter, "o", nil)
+	columns := `o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency,
+		o.subtotal, o.discount_total, o.tax_total, o.shipping_total`
+	query := `SELECT ` + columns + ` FROM orders o` + where + ` ORDER BY o.created_at, o.id`
+	if includeItems {
+		query = `SELECT ` + columns + `,
+			d.id, d.product_id, d.quantity, d.unit_price, d.total_price, d.discount_amount, d.tax_amount, d.created_at, d.updated_at
+			FROM orders o LEFT JOIN order_details d ON d.order_id = o.id` + where + ` ORDER BY o.created_at, o.id, d.created_at`
+	}
+
+	if _, err := tx.Exec(`DECLARE export_cursor NO SCROLL CURSOR FOR `+query, args...); err != nil {
+		fmt.Print(err)
+		return err
+	}
+
+	var current *entities.Order
+	for {
+		rows, err := tx.Query(fmt.Sprintf("FETCH %d FROM export_cursor", EXPORT_FETCH_SIZE))
+		if err != nil {
+			fmt.Print(err)
+			return err
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			order := &entities.Order{}
+			dest := orderDest(order)
+			var detail nullableOrderDetail
+			if includeItems {
+				dest = append(dest, detail.dest()...)
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return err
+			}
+
+			// Rows of the same order are consecutive, emit an order once all its items were read.
+			if current == nil || current.ID != order.ID {
+				if current != nil {
+					if err := fn(current); err != nil {
+						rows.Close()
+						return err
+					}
+				}
+				current = order
+			}
+			if includeItems && detail.ID.Valid {
+				current.OrderDetails = append(current.OrderDetails, detail.toOrderDetail(current.ID))
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if fetched < EXPORT_FETCH_SIZE {
+			break
+		}
+	}
+
+	if current != nil {
+		if err := fn(current); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("CLOSE export_cursor"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// orderFilterClause builds the WHERE clause for filter on the orders table aliased as alias.
+// The placeholders continue after the given args, which are returned extended with the filter values.
+func orderFilterClause(filter entities.OrderFilter, alias string, args []interface{}) (string, []interface{}) {
+	// Deleted orders are left out of every list
+	conditions := []string{alias + ".deleted_at IS NULL"}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, alias, len(args)))
+	}
+
+	if filter.UserID != "" {
+		add("%s.user_id = $%d", filter.UserID)
+	}
+	if filter.Status != 0 {
+		add("%s.status = $%d", filter.Status)
+	}
+	if filter.Currency != "" {
+		add("%s.currency = $%d", filter.Currency)
+	}
+	if filter.From != nil {
+		add("%s.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("%s.created_at < $%d", *filter.To)
+	}
+	if filter.ProductID != "" {
+		add("EXISTS (SELECT 1 FROM order_details od WHERE od.order_id = %s.id AND od.product_id = $%d)", filter.ProductID)
+	}
+	if filter.IDPrefix != "" {
+		// The prefix is made of hex digits and dashes, it carries no LIKE wildcards
+		add("%s.id::text LIKE $%d", strings.ToLower(filter.IDPrefix)+"%")
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// nullableOrderDetail scans a line item coming from a LEFT JOIN, where all columns may be NULL.
+// Money reads NULL as zero.
+type nullableOrderDetail struct {
+	ID             sql.NullString
+	ProductID      sql.NullString
+	Quantity       sql.NullInt64
+	UnitPrice      entities.Money
+	TotalPrice     entities.Money
+	DiscountAmount entities.Money
+	TaxAmount      entities.Money
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+}
+
+func (d *nullableOrderDetail) dest() []interface{} {
+	return []interface{}{&d.ID, &d.ProductID, &d.Quantity, &d.UnitPrice, &d.TotalPrice, &d.DiscountAmount, &d.TaxAmount, &d.CreatedAt, &d.UpdatedAt}
+}
+
+func (d *nullableOrderDetail) toOrderDetail(orderID string) entities.OrderDetail {
+	return entities.OrderDetail{
+		ID:             d.ID.String,
+		OrderID:        orderID,
+		ProductID:      d.ProductID.String,
+		Quantity:       int(d.Quantity.Int64),
+		UnitPrice:      d.UnitPrice,
+		TotalPrice:     d.TotalPrice,
+		DiscountAmount: d.DiscountAmount,
+		TaxAmount:      d.TaxAmount,
+		CreatedAt:      d.CreatedAt.Time,
+		UpdatedAt:      d.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,142 @@
+// adapters/repositories/orders/order_fulfilment.go
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/history"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+// FulfilmentRepository stores the delivery method, addresses and shipment of orders.
+// The delivery method and addresses are written by OrderRepository together with the order.
+type FulfilmentRepository struct {
+	Db *sql.DB
+	// The tenant the statements are scoped to, or entities.AllTenants
+	TenantID string
+}
+
+// ForTenant returns the repository scoped to the orders of a tenant.
+func (r *FulfilmentRepository) ForTenant(tenantID string) usecases.FulfilmentRepository {
+	return &FulfilmentRepository{Db: r.Db, TenantID: tenantID}
+}
+
+// db returns the database scoped to the tenant of the repository.
+func (r *FulfilmentRepository) db() *tenancy.DB {
+	return tenancy.Scope(r.Db, r.TenantID)
+}
+
+// Get the delivery method, addresses and shipment of an order, nil when none were recorded
+func (r *FulfilmentRepository) GetFulfilment(orderID string) (*entities.Fulfilment, error) {
+	rows, err := r.db().Query(
+		`SELECT f.delivery_method, f.tracking_number, f.carrier,
+			a.kind, a.name, a.line1, a.line2, a.city, a.region, a.postal_code, a.country, a.phone
+		FROM order_fulfilment f
+		LEFT JOIN order_addresses a ON a.order_id = f.order_id
+		WHERE f.order_id = $1`,
+		orderID)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fulfilment *entities.Fulfilment
+	for rows.Next() {
+		var method, trackingNumber, carrier, kind, name, line1, line2, city, region, postalCode, country, phone sql.NullString
+		if err := rows.Scan(&method, &trackingNumber, &carrier, &kind, &name, &line1, &line2, &city, &region, &postalCode, &country, &phone); err != nil {
+			fmt.Print(err)
+			return nil, err
+		}
+		if fulfilment == nil {
+			fulfilment = &entities.Fulfilment{DeliveryMethod: method.String, TrackingNumber: trackingNumber.String, Carrier: carrier.String}
+		}
+		address := &entities.Address{Name: name.String, Line1: line1.String, Line2: line2.String, City: city.String,
+			Region: region.String, PostalCode: postalCode.String, Country: country.String, Phone: phone.String}
+		switch kind.String {
+		case entities.AddressShipping:
+			fulfilment.ShippingAddress = address
+		case entities.AddressBilling:
+			fulfilment.BillingAddress = address
+		}
+	}
+	return fulfilment, rows.Err()
+}
+
+// Set the tracking number and carrier of an order that is processing or completed.
+// When expectedVersion is set the order must still be at that version.
+func (r *FulfilmentRepository) SetFulfilment(orderID string, req *entities.FulfilmentRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, err := lockOrder(tx, orderID, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if status != entities.OrderStatusProcessing && status != entities.OrderStatusCompleted {
+		return nil, apperrors.ErrOrderNotFulfillable
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO order_fulfilment (order_id, tracking_number, carrier) VALUES ($1, $2, $3)
+		ON CONFLICT (order_id) DO UPDATE SET tracking_number = EXCLUDED.tracking_number, carrier = EXCLUDED.carrier, updated_at = CURRENT_TIMESTAMP`,
+		orderID, req.TrackingNumber, req.Carrier)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	// Bumps the order version
+	if _, err := tx.Exec(`UPDATE orders SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, orderID); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if err := history.Insert(tx, orderID, entities.HistoryFulfilmentSet, req, actorID); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	orders := &OrderRepository{Db: r.Db, TenantID: r.TenantID}
+	return orders.GetByID(orderID)
+}
+
+// insertDelivery stores the delivery method and addresses of a new order, when it has any.
+func insertDelivery(tx *sql.Tx, orderID string, orderRequest *entities.OrderRequest) error {
+	if orderRequest.DeliveryMethod == "" && orderRequest.ShippingAddress == nil && orderRequest.BillingAddress == nil {
+		return nil
+	}
+	_, err := tx.Exec(`INSERT INTO order_fulfilment (order_id, delivery_method) VALUES ($1, $2)`, orderID, nullIfEmpty(orderRequest.DeliveryMethod))
+	if err != nil {
+		return err
+	}
+	addresses := []struct {
+		kind    string
+		address *entities.Address
+	}{{entities.AddressShipping, orderRequest.ShippingAddress}, {entities.AddressBilling, orderRequest.BillingAddress}}
+	for _, a := range addresses {
+		if a.address == nil {
+			continue
+		}
+		address := a.address
+		_, err := tx.Exec(
+			`INSERT INTO order_addresses (order_id, kind, name, line1, line2, city, region, postal_code, country, phone)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			orderID, a.kind, address.Name, address.Line1, nullIfEmpty(address.Line2), address.City,
+			nullIfEmpty(address.Region), nullIfEmpty(address.PostalCode), address.Country, nullIfEmpty(address.Phone))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
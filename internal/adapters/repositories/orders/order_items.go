@@ -0,0 +1,218 @@
+// adapters/repositories/orders/order_items.go
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/history"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// Get the line items of an order. Deleted orders are not found.
+func (r *OrderRepository) GetOrderDetails(orderID string) ([]entities.OrderDetail, error) {
+	var exists bool
+	err := r.db().QueryRow(`SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND deleted_at IS NULL)`, orderID).Scan(&exists)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if !exists {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	return r.orderDetails(orderID)
+}
+
+// orderDetails reads the line items of an order without checking the order itself.
+func (r *OrderRepository) orderDetails(orderID string) ([]entities.OrderDetail, error) {
+	query := `SELECT id, order_id, product_id, quantity, unit_price, total_price, discount_amount, tax_amount, created_at, updated_at
+		FROM order_details WHERE order_id = $1 ORDER BY created_at, id`
+	rows, err := r.db().Query(query, orderID)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var details []entities.OrderDetail
+	for rows.Next() {
+		var detail entities.OrderDetail
+		if err := rows.Scan(&detail.ID, &detail.OrderID, &detail.ProductID, &detail.Quantity, &detail.UnitPrice, &detail.TotalPrice, &detail.DiscountAmount, &detail.TaxAmount, &detail.CreatedAt, &detail.UpdatedAt); err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+	return details, rows.Err()
+}
+
+// Add a line item to a pending order
+func (r *OrderRepository) AddOrderItem(orderID string, item *entities.OrderItemRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	return r.modifyPendingOrder(orderID, expectedVersion, actorID, func(tx *sql.Tx) (string, interface{}, error) {
+		var itemID string
+		err := tx.QueryRow(
+			`INSERT INTO order_details (order_id, product_id, quantity, unit_price, discount_amount, tax_amount) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			orderID, item.ProductID, item.Quantity, item.UnitPrice, item.DiscountAmount, item.TaxAmount).Scan(&itemID)
+		if err != nil {
+			return "", nil, err
+		}
+		return entities.HistoryItemAdded, map[string]interface{}{
+			"item_id":    itemID,
+			"product_id": item.ProductID,
+			"quantity":   item.Quantity,
+			"unit_price": item.UnitPrice,
+		}, nil
+	})
+}
+
+// Change the quantity and/or unit price of a line item of a pending order
+func (r *OrderRepository) UpdateOrderItem(orderID string, itemID string, update *entities.OrderItemUpdate, expectedVersion int, actorID string) (*entities.Order, error) {
+	return r.modifyPendingOrder(orderID, expectedVersion, actorID, func(tx *sql.Tx) (string, interface{}, error) {
+		var quantity int
+		var unitPrice entities.Money
+		err := tx.QueryRow(
+			`UPDATE order_details
+			SET quantity = COALESCE($3, quantity), unit_price = COALESCE($4, unit_price),
+				discount_amount = COALESCE($5, discount_amount), tax_amount = COALESCE($6, tax_amount), updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND order_id = $2
+			RETURNING quantity, unit_price`,
+			itemID, orderID, update.Quantity, update.UnitPrice, update.DiscountAmount, update.TaxAmount).Scan(&quantity, &unitPrice)
+		if err == sql.ErrNoRows {
+			return "", nil, apperrors.ErrItemNotFound
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return entities.HistoryItemUpdated, map[string]interface{}{
+			"item_id":    itemID,
+			"quantity":   quantity,
+			"unit_price": unitPrice,
+		}, nil
+	})
+}
+
+// Remove a line item from a pending order, an order always keeps at least one item
+func (r *OrderRepository) RemoveOrderItem(orderID string, itemID string, expectedVersion int, actorID string) (*entities.Order, error) {
+	return r.modifyPendingOrder(orderID, expectedVersion, actorID, func(tx *sql.Tx) (string, interface{}, error) {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM order_details WHERE order_id = $1`, orderID).Scan(&count); err != nil {
+			return "", nil, err
+		}
+
+		var productID string
+		var quantity int
+		err := tx.QueryRow(
+			`DELETE FROM order_details WHERE id = $1 AND order_id = $2 RETURNING product_id, quantity`,
+			itemID, orderID).Scan(&productID, &quantity)
+		if err == sql.ErrNoRows {
+			return "", nil, apperrors.ErrItemNotFound
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if count <= 1 {
+			// The delete is rolled back together with the transaction
+			return "", nil, apperrors.ErrLastItem
+		}
+		return entities.HistoryItemRemoved, map[string]interface{}{
+			"item_id":    itemID,
+			"product_id": productID,
+			"quantity":   quantity,
+		}, nil
+	})
+}
+
+// modifyPendingOrder runs change in a transaction holding a lock on the order.
+// The order must be pending and, when expectedVersion is set, still at that version.
+// Orders holding a stock reservation or a redeemed promotion are rejected: the reservation covers
+// the line items the order was created with, and the promotion discount was computed from them.
+// Afterwards the order price breakdown is recomputed from its line items, the version is
+// bumped and the change is recorded in the order history.
+func (r *OrderRepository) modifyPendingOrder(orderID string, expectedVersion int, actorID string, change func(tx *sql.Tx) (string, interface{}, error)) (*entities.Order, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, err := lockOrder(tx, orderID, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if status != entities.OrderStatusPending {
+		return nil, apperrors.ErrOrderNotPending
+	}
+
+	var locked bool
+	err = tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM order_sagas WHERE order_id = $1 AND state = 'order_created')
+			OR EXISTS (SELECT 1 FROM promotion_redemptions WHERE order_id = $1)`,
+		orderID).Scan(&locked)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if locked {
+		return nil, apperrors.ErrItemsLocked
+	}
+
+	event, details, err := change(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The version and updated_at columns are maintained by the orders_bump_version trigger
+	_, err = tx.Exec(
+		`UPDATE orders AS o
+		SET subtotal = t.subtotal, discount_total = t.discount_total, tax_total = t.tax_total,
+			total_price = t.subtotal - t.discount_total + t.tax_total + o.shipping_total
+		FROM (SELECT COALESCE(SUM(total_price), 0) AS subtotal, COALESCE(SUM(discount_amount), 0) AS discount_total,
+				COALESCE(SUM(tax_amount), 0) AS tax_total
+			FROM order_details WHERE order_id = $1) AS t
+		WHERE o.id = $1`,
+		orderID)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	if err := history.Insert(tx, orderID, event, details, actorID); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	order, err := r.reload(orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.OrderDetails, err = r.orderDetails(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// lockOrder locks the order row for the rest of the transaction and returns its status.
+// Deleted orders are not found.
+// When expectedVersion is set, the order must still be at that version.
+func lockOrder(tx *sql.Tx, orderID string, expectedVersion int) (int, error) {
+	var status, version int
+	err := tx.QueryRow(`SELECT status, version FROM orders WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, orderID).Scan(&status, &version)
+	if err == sql.ErrNoRows {
+		return 0, apperrors.ErrOrderNotFound
+	}
+	if err != nil {
+		fmt.Print(err)
+		return 0, err
+	}
+	if expectedVersion != 0 && version != expectedVersion {
+		return 0, apperrors.ErrVersionMismatch
+	}
+	return status, nil
+}
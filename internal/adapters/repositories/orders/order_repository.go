@@ -6,20 +6,72 @@ import (
 	"fmt"
 
 	"github.com/lib/pq"
+	"github.com/shayja/orders-service/internal/adapters/repositories/history"
+	"github.com/shayja/orders-service/internal/adapters/repositories/replicas"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
 	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
 	"github.com/shayja/orders-service/pkg/utils"
 )
 
 type OrderRepository struct {
 	Db *sql.DB
+	// The tenant the statements are scoped to, or entities.AllTenants
+	TenantID string
+	// Sends the listings and exports to the read replicas when set, Db serves every statement otherwise
+	Router *replicas.Router
+}
+
+// ForTenant returns the repository scoped to the orders of a tenant.
+func (r *OrderRepository) ForTenant(tenantID string) usecases.OrderRepository {
+	return &OrderRepository{Db: r.Db, TenantID: tenantID, Router: r.Router}
+}
+
+// db returns the database scoped to the tenant of the repository.
+func (r *OrderRepository) db() *tenancy.DB {
+	return tenancy.Scope(r.Db, r.TenantID)
+}
+
+// replica returns the database the reads made on behalf of a user go to, scoped to the tenant of the repository.
+func (r *OrderRepository) replica(userID string) *tenancy.DB {
+	if r.Router == nil {
+		return r.db()
+	}
+	return tenancy.Scope(r.Router.Reader(userID), r.TenantID)
+}
+
+// wrote sends the reads of a user to the primary for a while, so the user sees their own write.
+func (r *OrderRepository) wrote(userID string) {
+	if r.Router != nil {
+		r.Router.Wrote(userID)
+	}
+}
+
+// reload returns an order after a write and sends the reads of its owner to the primary for a while.
+func (r *OrderRepository) reload(id string) (*entities.Order, error) {
+	order, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	r.wrote(order.UserID)
+	return order, nil
 }
 
 const PAGE_SIZE = 20
-// Get all user orders
-func (r *OrderRepository) GetAllOrders(page int, userID string) ([]*entities.Order, error) {
-	offset := PAGE_SIZE * (page - 1)
-	query := `SELECT * FROM get_user_orders($1, $2, $3)`
-	rows, err := r.Db.Query(query, userID, offset, PAGE_SIZE)
+// Get all user orders, narrowed down by the optional status, currency and date range of the filter.
+// Pages hold filter.PageSize orders, PAGE_SIZE when it is not set. Archived orders are included on request.
+func (r *OrderRepository) GetAllOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	if filter.IncludeArchived {
+		return r.getAllWithArchived(page, filter)
+	}
+	pageSize := PAGE_SIZE
+	if filter.PageSize > 0 {
+		pageSize = filter.PageSize
+	}
+	offset := pageSize * (page - 1)
+	query := `SELECT * FROM get_user_orders($1, $2, $3, $4, $5, $6, $7)`
+	rows, err := r.replica(filter.UserID).Query(query, filter.UserID, offset, pageSize, nullIfZero(filter.Status), nullIfEmpty(filter.Currency), filter.From, filter.To)
 	if err != nil {
 		fmt.Print(err)
 		return nil, err
@@ -29,7 +81,7 @@ func (r *OrderRepository) GetAllOrders(page int, userID string) ([]*entities.Ord
 	var orders []*entities.Order
 	for rows.Next() {
 		order := &entities.Order{}
-		if err := rows.Scan(&order.ID, &order.UserID, &order.TotalPrice, &order.Status, &order.CreatedAt, &order.UpdatedAt); err != nil {
+		if err := rows.Scan(orderDest(order)...); err != nil {
 			return nil, err
 		}
 		orders = append(orders, order)
@@ -40,7 +92,7 @@ func (r *OrderRepository) GetAllOrders(page int, userID string) ([]*entities.Ord
 // Get order by ID
 func (r *OrderRepository) GetByID(id string) (*entities.Order, error) {
 	query := `SELECT * FROM get_order($1)`
-	rows, err := r.Db.Query(query, id)
+	rows, err := r.db().Query(query, id)
 	if err != nil {
 		fmt.Print(err)
 		return nil, err
@@ -49,7 +101,7 @@ func (r *OrderRepository) GetByID(id string) (*entities.Order, error) {
 
 	order := &entities.Order{}
 	if rows.Next() {
-		err := rows.Scan(&order.ID, &order.UserID, &order.TotalPrice, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+		err := rows.Scan(orderDest(order)...)
 		if err != nil {
 			fmt.Print(err)
 			return nil, err
@@ -58,31 +110,183 @@ func (r *OrderRepository) GetByID(id string) (*entities.Order, error) {
 	return order, nil
 }
 
-// Create a new order
+// Create a new order with its delivery method and addresses.
+// When the order carries a promotion, the promotion is redeemed in the same transaction,
+// and so is its inventory saga moved to order_created.
 func (r *OrderRepository) Create(orderRequest *entities.OrderRequest) (string, error) {
 	newID := utils.CreateNewUUID().String()
-	_, err := r.Db.Exec(
-		`CALL orders_insert($1, $2, $3, $4::order_detail_type[], $5)`,
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`CALL orders_insert($1, $2, $3, $4::order_detail_type[], $5, $6, $7, $8, $9, $10)`,
 		orderRequest.UserID,
 		orderRequest.TotalPrice,
 		orderRequest.Status,
 		pq.Array(orderRequest.OrderDetails),
-		&newID)
+		&newID,
+		orderRequest.Currency,
+		orderRequest.Subtotal,
+		orderRequest.DiscountTotal,
+		orderRequest.TaxTotal,
+		orderRequest.ShippingTotal)
 	if err != nil {
 		fmt.Print(err)
 		return "", err
 	}
 
+	if err := insertDelivery(tx, newID, orderRequest); err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+
+	if orderRequest.PromotionID != "" {
+		if err := redeemPromotion(tx, orderRequest.PromotionID, orderRequest.UserID, newID); err != nil {
+			return "", err
+		}
+	}
+
+	if orderRequest.SagaID != "" {
+		if err := attachSaga(tx, orderRequest.SagaID, newID); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+
+	r.wrote(orderRequest.UserID)
 	fmt.Printf("Order %s created successfully\n", newID)
 	return newID, nil
 }
 
-// Update order status
-func (r *OrderRepository) UpdateStatus(id string, status int) (*entities.Order, error) {
-	_, err := r.Db.Exec("CALL orders_update_status($1, $2)", id, status)
+// Update order status, when expectedVersion is set the order must still be at that version.
+// The change is recorded in the order history.
+func (r *OrderRepository) UpdateStatus(id string, status int, expectedVersion int, actorID string) (*entities.Order, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	previous, err := lockOrder(tx, id, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("CALL orders_update_status($1, $2)", id, status)
 	if err != nil {
 		fmt.Print(err)
 		return nil, err
 	}
-	return r.GetByID(id)
-}
\ No newline at end of file
+
+	details := map[string]interface{}{"from": previous, "to": status}
+	if err := history.Insert(tx, id, entities.HistoryStatusChanged, details, actorID); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	return r.reload(id)
+}
+
+// redeemPromotion records the redemption of a promotion by an order.
+// The promotion row stays locked until the transaction ends, so concurrent checkouts
+// are counted one after the other and can never exceed the redemption limits.
+func redeemPromotion(tx *sql.Tx, promotionID string, userID string, orderID string) error {
+	var maxRedemptions, maxPerUser, redemptions int
+	err := tx.QueryRow(
+		`SELECT max_redemptions, max_redemptions_per_user, redemptions FROM promotions
+		WHERE id = $1 AND active
+			AND (starts_at IS NULL OR starts_at <= CURRENT_TIMESTAMP) AND (ends_at IS NULL OR ends_at > CURRENT_TIMESTAMP)
+		FOR UPDATE`,
+		promotionID).Scan(&maxRedemptions, &maxPerUser, &redemptions)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: promotion is no longer valid", apperrors.ErrInvalidRequest)
+	}
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	if maxRedemptions > 0 && redemptions >= maxRedemptions {
+		return apperrors.ErrPromotionLimitReached
+	}
+
+	if maxPerUser > 0 {
+		// Runs after the lock was granted, so it sees the redemptions of the checkouts that held it before.
+		// The redemptions of archived orders still count.
+		var used int
+		err := tx.QueryRow(
+			`SELECT (SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2)
+				+ (SELECT COUNT(*) FROM promotion_redemptions_archive WHERE promotion_id = $1 AND user_id = $2)`,
+			promotionID, userID).Scan(&used)
+		if err != nil {
+			fmt.Print(err)
+			return err
+		}
+		if used >= maxPerUser {
+			return apperrors.ErrPromotionLimitReached
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE promotions SET redemptions = redemptions + 1 WHERE id = $1`, promotionID); err != nil {
+		fmt.Print(err)
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO promotion_redemptions (promotion_id, user_id, order_id) VALUES ($1, $2, $3)`,
+		promotionID, userID, orderID)
+	if err != nil {
+		fmt.Print(err)
+	}
+	return err
+}
+
+// attachSaga links a saga to its order. The saga must still hold its reservation,
+// a saga that was compensated in the meantime makes the order fail.
+func attachSaga(tx *sql.Tx, sagaID string, orderID string) error {
+	res, err := tx.Exec(
+		`UPDATE order_sagas SET order_id = $1, state = 'order_created', error = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND state = 'reserved'`,
+		orderID, sagaID)
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("stock reservation %s was released", sagaID)
+	}
+	return nil
+}
+
+// orderDest returns the scan destinations of an order row, in the column order of get_order.
+func orderDest(order *entities.Order) []interface{} {
+	return []interface{}{&order.ID, &order.UserID, &order.TotalPrice, &order.Status, &order.CreatedAt, &order.UpdatedAt, &order.Version, &order.Currency,
+		&order.Subtotal, &order.DiscountTotal, &order.TaxTotal, &order.ShippingTotal}
+}
+
+// nullIfEmpty maps an empty string to NULL, for optional procedure arguments.
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// nullIfZero maps zero to NULL, for optional procedure arguments.
+func nullIfZero(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,53 @@
+// adapters/repositories/orders/order_summary.go
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+)
+
+// Get the summary of the orders matching the filter, usually the orders of a user in a date range.
+// The orders are aggregated per currency and status in a single query, then summed up.
+func (r *OrderRepository) GetOrderSummary(filter entities.OrderFilter) (*entities.OrderSummary, error) {
+	where, args := orderFilterClause(filter, "o", nil)
+	rows, err := r.db().Query(
+		`SELECT o.currency, o.status, COUNT(*), SUM(o.total_price), MAX(o.created_at)
+		FROM orders o`+where+`
+		GROUP BY o.currency, o.status
+		ORDER BY o.currency, o.status`,
+		args...)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &entities.OrderSummary{TotalSpent: []entities.CurrencyAmount{}}
+	for rows.Next() {
+		var currency string
+		var status, count int
+		var total entities.Money
+		var lastOrderAt time.Time
+		if err := rows.Scan(&currency, &status, &count, &total, &lastOrderAt); err != nil {
+			return nil, err
+		}
+
+		summary.Orders += count
+		summary.ByStatus.Add(status, count)
+		if summary.LastOrderAt == nil || lastOrderAt.After(*summary.LastOrderAt) {
+			summary.LastOrderAt = &lastOrderAt
+		}
+		if status == entities.OrderStatusCancelled {
+			continue
+		}
+		// Rows are sorted by currency, the amounts of a currency are consecutive
+		if n := len(summary.TotalSpent); n > 0 && summary.TotalSpent[n-1].Currency == currency {
+			summary.TotalSpent[n-1].Amount = summary.TotalSpent[n-1].Amount.Add(total)
+		} else {
+			summary.TotalSpent = append(summary.TotalSpent, entities.CurrencyAmount{Currency: currency, Amount: total})
+		}
+	}
+	return summary, rows.Err()
+}
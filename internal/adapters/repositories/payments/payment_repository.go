@@ -0,0 +1,209 @@
+// adapters/repositories/payments/payment_repository.go
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/shayja/orders-service/internal/adapters/repositories/history"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+type PaymentRepository struct {
+	Db *sql.DB
+	// The tenant the statements are scoped to, or entities.AllTenants
+	TenantID string
+}
+
+// ForTenant returns the repository scoped to the payments of a tenant.
+func (r *PaymentRepository) ForTenant(tenantID string) usecases.PaymentRepository {
+	return &PaymentRepository{Db: r.Db, TenantID: tenantID}
+}
+
+// db returns the database scoped to the tenant of the repository.
+func (r *PaymentRepository) db() *tenancy.DB {
+	return tenancy.Scope(r.Db, r.TenantID)
+}
+
+const paymentColumns = `id, order_id, provider, provider_ref, status, currency, authorized_amount, captured_amount, refunded_amount,
+	failed_amount, failure_reason, created_at, updated_at`
+
+// Create a new payment. An order has at most one authorized or captured payment,
+// another one fails with apperrors.ErrPaymentExists.
+func (r *PaymentRepository) Create(payment *entities.Payment) (string, error) {
+	var id string
+	err := r.db().QueryRow(
+		`INSERT INTO payments (order_id, provider, provider_ref, status, currency, authorized_amount, captured_amount, refunded_amount,
+			failed_amount, failure_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		payment.OrderID, payment.Provider, nullIfEmpty(payment.ProviderRef), payment.Status, payment.Currency, payment.AuthorizedAmount,
+		payment.CapturedAmount, payment.RefundedAmount, payment.FailedAmount, nullIfEmpty(payment.FailureReason)).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "payments_order_id_open_key" {
+		return "", apperrors.ErrPaymentExists
+	}
+	if err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+	return id, nil
+}
+
+// Get a payment by ID
+func (r *PaymentRepository) GetByID(id string) (*entities.Payment, error) {
+	return scanPayment(r.db().QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE id = $1`, id))
+}
+
+// Get the payments of an order, oldest first. Deleted orders are not found.
+func (r *PaymentRepository) GetByOrderID(orderID string) ([]*entities.Payment, error) {
+	var exists bool
+	err := r.db().QueryRow(`SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND deleted_at IS NULL)`, orderID).Scan(&exists)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if !exists {
+		return nil, apperrors.ErrOrderNotFound
+	}
+
+	rows, err := r.db().Query(`SELECT `+paymentColumns+` FROM payments WHERE order_id = $1 ORDER BY created_at, id`, orderID)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []*entities.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
+
+// Get a payment by its provider reference
+func (r *PaymentRepository) GetByProviderRef(provider string, providerRef string) (*entities.Payment, error) {
+	return scanPayment(r.db().QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE provider = $1 AND provider_ref = $2`, provider, providerRef))
+}
+
+// Update the status and amounts of a payment still in status from
+func (r *PaymentRepository) Update(payment *entities.Payment, from string) error {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updatePayment(tx, payment, from); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return err
+	}
+	return nil
+}
+
+// Apply a provider event to a payment, once
+func (r *PaymentRepository) ApplyEvent(payment *entities.Payment, from string, eventID string) (bool, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO payment_events (provider, event_id, payment_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+		payment.Provider, eventID, payment.ID)
+	if err != nil {
+		fmt.Print(err)
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		// Already applied
+		return false, nil
+	}
+
+	if err := updatePayment(tx, payment, from); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return false, err
+	}
+	return true, nil
+}
+
+// updatePayment stores the state of a payment, when it became captured its pending order moves to processing.
+func updatePayment(tx *sql.Tx, payment *entities.Payment, from string) error {
+	res, err := tx.Exec(
+		`UPDATE payments
+		SET status = $1, authorized_amount = $2, captured_amount = $3, refunded_amount = $4, failed_amount = $5,
+			failure_reason = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7 AND status = $8`,
+		payment.Status, payment.AuthorizedAmount, payment.CapturedAmount, payment.RefundedAmount, payment.FailedAmount,
+		nullIfEmpty(payment.FailureReason), payment.ID, from)
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return apperrors.ErrPaymentState
+	}
+
+	if payment.Status != entities.PaymentCaptured || from == entities.PaymentCaptured {
+		return nil
+	}
+	// The version and updated_at columns are maintained by the orders_bump_version trigger
+	res, err = tx.Exec(`UPDATE orders SET status = $1 WHERE id = $2 AND status = $3 AND deleted_at IS NULL`,
+		entities.OrderStatusProcessing, payment.OrderID, entities.OrderStatusPending)
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	details := map[string]interface{}{"payment_id": payment.ID, "amount": payment.CapturedAmount}
+	err = history.Insert(tx, payment.OrderID, entities.HistoryPaymentCaptured, details, "")
+	if err != nil {
+		fmt.Print(err)
+	}
+	return err
+}
+
+// scanPayment reads a payment row in the order of paymentColumns.
+func scanPayment(row interface{ Scan(...interface{}) error }) (*entities.Payment, error) {
+	p := &entities.Payment{}
+	var providerRef, failureReason sql.NullString
+	err := row.Scan(&p.ID, &p.OrderID, &p.Provider, &providerRef, &p.Status, &p.Currency, &p.AuthorizedAmount, &p.CapturedAmount,
+		&p.RefundedAmount, &p.FailedAmount, &failureReason, &p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.ErrPaymentNotFound
+	}
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	p.ProviderRef, p.FailureReason = providerRef.String, failureReason.String
+	return p, nil
+}
+
+// nullIfEmpty maps an empty string to NULL, for optional columns.
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
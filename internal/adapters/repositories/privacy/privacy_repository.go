@@ -0,0 +1,337 @@
+// adapters/repositories/privacy/privacy_repository.go
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+// PrivacyRepository exports and erases the data tied to a user, in the live and the archive tables.
+type PrivacyRepository struct {
+	Db *sql.DB
+	// The tenant the statements are scoped to, or entities.AllTenants
+	TenantID string
+}
+
+// ForTenant returns the repository scoped to the rows of a tenant.
+func (r *PrivacyRepository) ForTenant(tenantID string) usecases.PrivacyRepository {
+	return &PrivacyRepository{Db: r.Db, TenantID: tenantID}
+}
+
+// db returns the database scoped to the tenant of the repository.
+func (r *PrivacyRepository) db() *tenancy.DB {
+	return tenancy.Scope(r.Db, r.TenantID)
+}
+
+// ERASED replaces the required personal fields of the erased addresses.
+const ERASED = "erased"
+
+// The suffixes of the live and the archive tables
+var tableSuffixes = []string{"", "_archive"}
+
+// Get the orders of a user with their line items, addresses and history, deleted and archived orders included.
+// The queries run in one read only transaction, so they see the same snapshot.
+func (r *PrivacyRepository) ExportUserData(userID string) ([]entities.UserDataOrder, error) {
+	tx, err := r.db().BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	orders, err := userOrders(tx, userID)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	index := make(map[string]int, len(orders))
+	for i := range orders {
+		index[orders[i].ID] = i
+	}
+
+	if err := userOrderDetails(tx, userID, orders, index); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if err := userAddresses(tx, userID, orders, index); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if err := userHistory(tx, userID, orders, index); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+// userOrders reads the live and archived orders of a user, oldest first.
+func userOrders(tx *sql.Tx, userID string) ([]entities.UserDataOrder, error) {
+	rows, err := tx.Query(
+		`SELECT id, user_id, total_price, status, created_at, updated_at, version, currency,
+			subtotal, discount_total, tax_total, shipping_total, deleted_at, NULL::timestamp AS archived_at
+		FROM orders WHERE user_id = $1
+		UNION ALL
+		SELECT id, user_id, total_price, status, created_at, updated_at, version, currency,
+			subtotal, discount_total, tax_total, shipping_total, deleted_at, archived_at
+		FROM orders_archive WHERE user_id = $1
+		ORDER BY created_at, id`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []entities.UserDataOrder{}
+	for rows.Next() {
+		order := entities.UserDataOrder{History: []entities.OrderHistory{}}
+		if err := rows.Scan(&order.ID, &order.UserID, &order.TotalPrice, &order.Status, &order.CreatedAt, &order.UpdatedAt,
+			&order.Version, &order.Currency, &order.Subtotal, &order.DiscountTotal, &order.TaxTotal, &order.ShippingTotal,
+			&order.DeletedAt, &order.ArchivedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// userOrderDetails reads the line items of the orders of a user into orders.
+func userOrderDetails(tx *sql.Tx, userID string, orders []entities.UserDataOrder, index map[string]int) error {
+	rows, err := tx.Query(
+		`SELECT d.id, d.order_id, d.product_id, d.quantity, d.unit_price, d.total_price, d.discount_amount, d.tax_amount, d.created_at, d.updated_at
+		FROM order_details d JOIN orders o ON o.id = d.order_id WHERE o.user_id = $1
+		UNION ALL
+		SELECT d.id, d.order_id, d.product_id, d.quantity, d.unit_price, d.total_price, d.discount_amount, d.tax_amount, d.created_at, d.updated_at
+		FROM order_details_archive d JOIN orders_archive o ON o.id = d.order_id WHERE o.user_id = $1
+		ORDER BY created_at, id`,
+		userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail entities.OrderDetail
+		if err := rows.Scan(&detail.ID, &detail.OrderID, &detail.ProductID, &detail.Quantity, &detail.UnitPrice, &detail.TotalPrice, &detail.DiscountAmount, &detail.TaxAmount, &detail.CreatedAt, &detail.UpdatedAt); err != nil {
+			return err
+		}
+		if i, ok := index[detail.OrderID]; ok {
+			orders[i].OrderDetails = append(orders[i].OrderDetails, detail)
+		}
+	}
+	return rows.Err()
+}
+
+// userAddresses reads the delivery method and addresses of the orders of a user into orders.
+func userAddresses(tx *sql.Tx, userID string, orders []entities.UserDataOrder, index map[string]int) error {
+	rows, err := tx.Query(
+		`SELECT a.order_id, a.kind, a.name, a.line1, a.line2, a.city, a.region, a.postal_code, a.country, a.phone
+		FROM order_addresses a JOIN orders o ON o.id = a.order_id WHERE o.user_id = $1
+		UNION ALL
+		SELECT a.order_id, a.kind, a.name, a.line1, a.line2, a.city, a.region, a.postal_code, a.country, a.phone
+		FROM order_addresses_archive a JOIN orders_archive o ON o.id = a.order_id WHERE o.user_id = $1`,
+		userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID, kind string
+		var address entities.Address
+		var line2, region, postalCode, phone sql.NullString
+		if err := rows.Scan(&orderID, &kind, &address.Name, &address.Line1, &line2, &address.City, &region, &postalCode, &address.Country, &phone); err != nil {
+			return err
+		}
+		address.Line2, address.Region, address.PostalCode, address.Phone = line2.String, region.String, postalCode.String, phone.String
+		i, ok := index[orderID]
+		if !ok {
+			continue
+		}
+		if kind == entities.AddressBilling {
+			orders[i].BillingAddress = &address
+		} else {
+			orders[i].ShippingAddress = &address
+		}
+	}
+	return rows.Err()
+}
+
+// userHistory reads the recorded changes of the orders of a user into orders.
+func userHistory(tx *sql.Tx, userID string, orders []entities.UserDataOrder, index map[string]int) error {
+	rows, err := tx.Query(
+		`SELECT h.id, h.order_id, h.event, h.details, h.actor_id, h.created_at
+		FROM order_history h JOIN orders o ON o.id = h.order_id WHERE o.user_id = $1
+		UNION ALL
+		SELECT h.id, h.order_id, h.event, h.details, h.actor_id, h.created_at
+		FROM order_history_archive h JOIN orders_archive o ON o.id = h.order_id WHERE o.user_id = $1
+		ORDER BY created_at, id`,
+		userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry entities.OrderHistory
+		var details []byte
+		var actorID sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.OrderID, &entry.Event, &details, &actorID, &entry.CreatedAt); err != nil {
+			return err
+		}
+		entry.Details = details
+		entry.ActorID = actorID.String
+		if i, ok := index[entry.OrderID]; ok {
+			orders[i].History = append(orders[i].History, entry)
+		}
+	}
+	return rows.Err()
+}
+
+// Erase the personal data of a user in the live and the archive tables, and record the erasure in the audit log.
+// The orders, promotion redemptions and the changes made by the user are given pseudonym as user ID, the names,
+// streets, cities, postal codes and phone numbers of the addresses and the return reasons are wiped. The amounts,
+// line items and countries are kept for accounting. Users with a pending or processing order get ErrOrderOpen.
+// The counts, ID and date of the entry are set on entry. It returns the IDs of the erased orders.
+func (r *PrivacyRepository) EraseUser(userID string, pseudonym string, entry *entities.ErasureRecord) ([]string, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Open orders still need the addresses to be delivered
+	var open bool
+	err = tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM orders WHERE user_id = $1 AND status IN ($2, $3) AND deleted_at IS NULL)`,
+		userID, entities.OrderStatusPending, entities.OrderStatusProcessing).Scan(&open)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if open {
+		return nil, apperrors.ErrOrderOpen
+	}
+
+	entry.Orders, entry.Addresses = 0, 0
+	var orderIDs []string
+	for _, suffix := range tableSuffixes {
+		// The rows of the orders are erased before the orders get the pseudonym
+		addresses, err := execCount(tx,
+			`UPDATE order_addresses`+suffix+`
+			SET name = $2, line1 = $2, line2 = NULL, city = $2, region = NULL, postal_code = NULL, phone = NULL
+			WHERE order_id IN (SELECT id FROM orders`+suffix+` WHERE user_id = $1)`,
+			userID, ERASED)
+		if err != nil {
+			return nil, err
+		}
+		entry.Addresses += addresses
+
+		if _, err := execCount(tx,
+			`UPDATE returns`+suffix+` SET reason = NULL
+			WHERE reason IS NOT NULL AND order_id IN (SELECT id FROM orders`+suffix+` WHERE user_id = $1)`,
+			userID); err != nil {
+			return nil, err
+		}
+		if _, err := execCount(tx, `UPDATE order_history`+suffix+` SET actor_id = $2 WHERE actor_id = $1`, userID, pseudonym); err != nil {
+			return nil, err
+		}
+		if _, err := execCount(tx, `UPDATE promotion_redemptions`+suffix+` SET user_id = $2 WHERE user_id = $1`, userID, pseudonym); err != nil {
+			return nil, err
+		}
+
+		// The version and updated_at columns of the live orders are maintained by the orders_bump_version trigger
+		ids, err := queryIDs(tx, `UPDATE orders`+suffix+` SET user_id = $2 WHERE user_id = $1 RETURNING id`, userID, pseudonym)
+		if err != nil {
+			return nil, err
+		}
+		orderIDs = append(orderIDs, ids...)
+		entry.Orders += len(ids)
+	}
+
+	var actor sql.NullString
+	if entry.ActorID != "" {
+		actor = sql.NullString{String: entry.ActorID, Valid: true}
+	}
+	err = tx.QueryRow(
+		`INSERT INTO erasure_audit_log (subject_hash, orders, addresses, reason, actor_id) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		entry.SubjectHash, entry.Orders, entry.Addresses, entry.Reason, actor).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	return orderIDs, nil
+}
+
+// execCount runs a statement and returns the number of rows it changed.
+func execCount(tx *sql.Tx, query string, args ...interface{}) (int, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		fmt.Print(err)
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// queryIDs runs a statement returning the IDs of the rows it changed.
+func queryIDs(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Get the erasures recorded for a subject hash, oldest first.
+func (r *PrivacyRepository) GetErasures(subjectHash string) ([]entities.ErasureRecord, error) {
+	rows, err := r.db().Query(
+		`SELECT id, subject_hash, orders, addresses, reason, actor_id, created_at FROM erasure_audit_log
+		WHERE subject_hash = $1 ORDER BY created_at, id`,
+		subjectHash)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	erasures := []entities.ErasureRecord{}
+	for rows.Next() {
+		var entry entities.ErasureRecord
+		var actorID sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.SubjectHash, &entry.Orders, &entry.Addresses, &entry.Reason, &actorID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.ActorID = actorID.String
+		erasures = append(erasures, entry)
+	}
+	return erasures, rows.Err()
+}
@@ -0,0 +1,157 @@
+// adapters/repositories/promotions/promotion_repository.go
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+type PromotionRepository struct {
+	Db *sql.DB
+	// The tenant the statements are scoped to, or entities.AllTenants
+	TenantID string
+}
+
+// ForTenant returns the repository scoped to the promotions of a tenant.
+func (r *PromotionRepository) ForTenant(tenantID string) usecases.PromotionRepository {
+	return &PromotionRepository{Db: r.Db, TenantID: tenantID}
+}
+
+// db returns the database scoped to the tenant of the repository.
+func (r *PromotionRepository) db() *tenancy.DB {
+	return tenancy.Scope(r.Db, r.TenantID)
+}
+
+const PAGE_SIZE = 20
+
+const promotionColumns = `id, code, type, percent, amount_off, buy_quantity, get_quantity, product_id, currency, min_order_value,
+	starts_at, ends_at, max_redemptions, max_redemptions_per_user, redemptions, active, created_at, updated_at`
+
+// Get all promotions, newest first
+func (r *PromotionRepository) GetAll(page int) ([]*entities.Promotion, error) {
+	offset := PAGE_SIZE * (page - 1)
+	rows, err := r.db().Query(`SELECT `+promotionColumns+` FROM promotions ORDER BY created_at DESC, id OFFSET $1 LIMIT $2`, offset, PAGE_SIZE)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []*entities.Promotion
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+	return promotions, rows.Err()
+}
+
+// Get a promotion by ID
+func (r *PromotionRepository) GetByID(id string) (*entities.Promotion, error) {
+	return scanPromotion(r.db().QueryRow(`SELECT `+promotionColumns+` FROM promotions WHERE id = $1`, id))
+}
+
+// Get a promotion by its coupon code
+func (r *PromotionRepository) GetByCode(code string) (*entities.Promotion, error) {
+	return scanPromotion(r.db().QueryRow(`SELECT `+promotionColumns+` FROM promotions WHERE code = $1`, code))
+}
+
+// Create a new promotion
+func (r *PromotionRepository) Create(promotion *entities.Promotion) (string, error) {
+	var id string
+	err := r.db().QueryRow(
+		`INSERT INTO promotions (code, type, percent, amount_off, buy_quantity, get_quantity, product_id, currency, min_order_value,
+			starts_at, ends_at, max_redemptions, max_redemptions_per_user, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id`,
+		promotionArgs(promotion)...).Scan(&id)
+	if err != nil {
+		fmt.Print(err)
+		return "", mapPromotionError(err)
+	}
+	return id, nil
+}
+
+// Update a promotion, the redemption count is left untouched
+func (r *PromotionRepository) Update(promotion *entities.Promotion) (*entities.Promotion, error) {
+	args := append(promotionArgs(promotion), promotion.ID)
+	updated, err := scanPromotion(r.db().QueryRow(
+		`UPDATE promotions
+		SET code = $1, type = $2, percent = $3, amount_off = $4, buy_quantity = $5, get_quantity = $6, product_id = $7,
+			currency = $8, min_order_value = $9, starts_at = $10, ends_at = $11, max_redemptions = $12,
+			max_redemptions_per_user = $13, active = $14, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $15
+		RETURNING `+promotionColumns,
+		args...))
+	if err != nil {
+		return nil, mapPromotionError(err)
+	}
+	return updated, nil
+}
+
+// Delete a promotion that was never redeemed
+func (r *PromotionRepository) Delete(id string) error {
+	res, err := r.db().Exec(`DELETE FROM promotions WHERE id = $1`, id)
+	if err != nil {
+		fmt.Print(err)
+		return mapPromotionError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return apperrors.ErrPromotionNotFound
+	}
+	return nil
+}
+
+func promotionArgs(p *entities.Promotion) []interface{} {
+	return []interface{}{p.Code, p.Type, p.Percent, p.AmountOff, p.BuyQuantity, p.GetQuantity, nullIfEmpty(p.ProductID),
+		nullIfEmpty(p.Currency), p.MinOrderValue, p.StartsAt, p.EndsAt, p.MaxRedemptions, p.MaxRedemptionsPerUser, p.Active}
+}
+
+// scanPromotion reads a promotion row in the order of promotionColumns.
+func scanPromotion(row interface{ Scan(...interface{}) error }) (*entities.Promotion, error) {
+	p := &entities.Promotion{}
+	var productID, currency sql.NullString
+	err := row.Scan(&p.ID, &p.Code, &p.Type, &p.Percent, &p.AmountOff, &p.BuyQuantity, &p.GetQuantity, &productID, &currency,
+		&p.MinOrderValue, &p.StartsAt, &p.EndsAt, &p.MaxRedemptions, &p.MaxRedemptionsPerUser, &p.Redemptions, &p.Active,
+		&p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.ErrPromotionNotFound
+	}
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	p.ProductID, p.Currency = productID.String, currency.String
+	return p, nil
+}
+
+// mapPromotionError translates constraint violations to application errors.
+func mapPromotionError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			return apperrors.ErrPromotionCodeTaken
+		case "23503": // foreign_key_violation
+			return apperrors.ErrPromotionInUse
+		}
+	}
+	return err
+}
+
+// nullIfEmpty maps an empty string to NULL, for optional columns.
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,115 @@
+// adapters/repositories/replicas/router.go
+package replicas
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Router spreads the read-only queries that tolerate a little replication lag over the read replicas,
+// while the writes stay on the primary.
+// After a write of a user, the reads of that user go to the primary for the Stickiness window, so
+// users always see their own writes. Replicas failing their health check get no reads until they
+// pass it again, and the primary serves the reads when no replica is healthy.
+type Router struct {
+	Primary  *sql.DB
+	Replicas []*sql.DB
+	// How long the reads of a user go to the primary after a write of theirs
+	Stickiness time.Duration
+	// The clock, time.Now when not set
+	Now func() time.Time
+
+	next    atomic.Uint64
+	mu      sync.Mutex
+	down    map[int]bool
+	written map[string]time.Time
+}
+
+// NewRouter returns a router over a primary and its replicas, all replicas are healthy until checked.
+func NewRouter(primary *sql.DB, replicas []*sql.DB, stickiness time.Duration) *Router {
+	return &Router{Primary: primary, Replicas: replicas, Stickiness: stickiness}
+}
+
+// Reader returns the database the reads of a user go to: a healthy replica picked round robin,
+// or the primary when the user wrote recently or no replica is healthy.
+// Reads not made on behalf of a user, such as reports, pass an empty userID.
+func (r *Router) Reader(userID string) *sql.DB {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if userID != "" && r.now().Before(r.written[userID]) {
+		return r.Primary
+	}
+	if len(r.Replicas) == 0 {
+		return r.Primary
+	}
+	start := int(r.next.Add(1) % uint64(len(r.Replicas)))
+	for i := range r.Replicas {
+		n := (start + i) % len(r.Replicas)
+		if !r.down[n] {
+			return r.Replicas[n]
+		}
+	}
+	return r.Primary
+}
+
+// Wrote sends the reads of a user to the primary for the Stickiness window.
+func (r *Router) Wrote(userID string) {
+	if userID == "" || r.Stickiness <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.written == nil {
+		r.written = map[string]time.Time{}
+	}
+	r.written[userID] = r.now().Add(r.Stickiness)
+}
+
+// CheckHealth pings every replica and takes the failing ones out of the rotation until they answer again.
+// It also forgets the users whose stickiness window is over.
+func (r *Router) CheckHealth(ctx context.Context, timeout time.Duration) {
+	down := map[int]bool{}
+	for i, replica := range r.Replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := replica.PingContext(pingCtx); err != nil {
+			down[i] = true
+		}
+		cancel()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+	now := r.now()
+	for userID, until := range r.written {
+		if !now.Before(until) {
+			delete(r.written, userID)
+		}
+	}
+}
+
+// Start checks the health of the replicas every interval until ctx is done.
+func (r *Router) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			r.CheckHealth(ctx, interval)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (r *Router) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,125 @@
+// adapters/repositories/reports/report_repository.go
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/replicas"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+// ReportRepository computes the reports with aggregate queries over the orders of a date range,
+// served by the (tenant_id, created_at) index of the orders.
+type ReportRepository struct {
+	Db *sql.DB
+	// The tenant the statements are scoped to, or entities.AllTenants
+	TenantID string
+	// Sends the report queries to the read replicas when set
+	Router *replicas.Router
+}
+
+// ForTenant returns the repository scoped to the orders of a tenant.
+func (r *ReportRepository) ForTenant(tenantID string) usecases.ReportRepository {
+	return &ReportRepository{Db: r.Db, TenantID: tenantID, Router: r.Router}
+}
+
+// db returns the database scoped to the tenant of the repository, a read replica when there is one.
+// Reports only read, and a few seconds of replication lag do not matter to them.
+func (r *ReportRepository) db() *tenancy.DB {
+	if r.Router != nil {
+		return tenancy.Scope(r.Router.Reader(""), r.TenantID)
+	}
+	return tenancy.Scope(r.Db, r.TenantID)
+}
+
+// The columns the top products are ranked by
+var productRanks = map[string]string{
+	entities.ReportByQuantity: "SUM(d.quantity)",
+	entities.ReportByRevenue:  "SUM(d.total_price - d.discount_amount)",
+}
+
+// Get the number, revenue and average value of the orders per period and currency, oldest period first.
+func (r *ReportRepository) OrdersByPeriod(filter entities.ReportFilter) ([]entities.OrdersReportRow, error) {
+	rows, err := r.db().Query(
+		`SELECT date_trunc($1, created_at) AS period, currency, COUNT(*), SUM(total_price), ROUND(AVG(total_price), 2)
+		FROM orders
+		WHERE created_at >= $2 AND created_at < $3 AND status <> $4 AND deleted_at IS NULL
+		GROUP BY period, currency
+		ORDER BY period, currency`,
+		filter.Interval, filter.From, filter.To, entities.OrderStatusCancelled)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []entities.OrdersReportRow{}
+	for rows.Next() {
+		var row entities.OrdersReportRow
+		if err := rows.Scan(&row.Period, &row.Currency, &row.Orders, &row.Revenue, &row.AverageOrderValue); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
+
+// Get the number of orders in each status.
+func (r *ReportRepository) StatusCounts(filter entities.ReportFilter) ([]entities.StatusReportRow, error) {
+	rows, err := r.db().Query(
+		`SELECT status, COUNT(*) FROM orders
+		WHERE created_at >= $1 AND created_at < $2 AND deleted_at IS NULL
+		GROUP BY status
+		ORDER BY status`,
+		filter.From, filter.To)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []entities.StatusReportRow{}
+	for rows.Next() {
+		var row entities.StatusReportRow
+		if err := rows.Scan(&row.Status, &row.Orders); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
+
+// Get the filter.Limit best selling products per currency, ranked by filter.SortBy.
+func (r *ReportRepository) TopProducts(filter entities.ReportFilter) ([]entities.ProductReportRow, error) {
+	rank, ok := productRanks[filter.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown product rank %q", filter.SortBy)
+	}
+	rows, err := r.db().Query(
+		`SELECT d.product_id, o.currency, SUM(d.quantity), SUM(d.total_price - d.discount_amount), COUNT(DISTINCT o.id)
+		FROM order_details d
+		JOIN orders o ON o.id = d.order_id
+		WHERE o.created_at >= $1 AND o.created_at < $2 AND o.status <> $3 AND o.deleted_at IS NULL
+		GROUP BY d.product_id, o.currency
+		ORDER BY `+rank+` DESC, d.product_id
+		LIMIT $4`,
+		filter.From, filter.To, entities.OrderStatusCancelled, filter.Limit)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []entities.ProductReportRow{}
+	for rows.Next() {
+		var row entities.ProductReportRow
+		if err := rows.Scan(&row.ProductID, &row.Currency, &row.Quantity, &row.Revenue, &row.Orders); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
@@ -0,0 +1,206 @@
+// adapters/repositories/returns/return_repository.go
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/history"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+type ReturnRepository struct {
+	Db *sql.DB
+	// The tenant the statements are scoped to, or entities.AllTenants
+	TenantID string
+}
+
+// ForTenant returns the repository scoped to the returns of a tenant.
+func (r *ReturnRepository) ForTenant(tenantID string) usecases.ReturnRepository {
+	return &ReturnRepository{Db: r.Db, TenantID: tenantID}
+}
+
+// db returns the database scoped to the tenant of the repository.
+func (r *ReturnRepository) db() *tenancy.DB {
+	return tenancy.Scope(r.Db, r.TenantID)
+}
+
+// Create a return for a completed order, with its items
+func (r *ReturnRepository) Create(ret *entities.Return, actorID string) (string, error) {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Concurrent returns of the same order are checked one after the other
+	var status int
+	err = tx.QueryRow(`SELECT status FROM orders WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, ret.OrderID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", apperrors.ErrOrderNotFound
+	}
+	if err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+	if status != entities.OrderStatusCompleted {
+		return "", apperrors.ErrOrderNotCompleted
+	}
+
+	for _, item := range ret.Items {
+		var ordered, returned int
+		err := tx.QueryRow(
+			`SELECT d.quantity, COALESCE((SELECT SUM(ri.quantity) FROM return_items ri WHERE ri.order_detail_id = d.id), 0)
+			FROM order_details d WHERE d.id = $1 AND d.order_id = $2`,
+			item.OrderDetailID, ret.OrderID).Scan(&ordered, &returned)
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("%w: line item %s is not part of the order", apperrors.ErrInvalidRequest, item.OrderDetailID)
+		}
+		if err != nil {
+			fmt.Print(err)
+			return "", err
+		}
+		if returned+item.Quantity > ordered {
+			return "", fmt.Errorf("%w: only %d of line item %s can be returned", apperrors.ErrInvalidRequest, ordered-returned, item.OrderDetailID)
+		}
+	}
+
+	var id string
+	err = tx.QueryRow(
+		`INSERT INTO returns (order_id, status, reason, refund_amount) VALUES ($1, $2, $3, $4) RETURNING id`,
+		ret.OrderID, ret.Status, ret.Reason, ret.RefundAmount).Scan(&id)
+	if err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+	for _, item := range ret.Items {
+		_, err := tx.Exec(
+			`INSERT INTO return_items (return_id, order_detail_id, quantity, refund_amount) VALUES ($1, $2, $3, $4)`,
+			id, item.OrderDetailID, item.Quantity, item.RefundAmount)
+		if err != nil {
+			fmt.Print(err)
+			return "", err
+		}
+	}
+
+	if err := touchOrder(tx, ret.OrderID, entities.HistoryReturnRequested, map[string]interface{}{"return_id": id, "refund_amount": ret.RefundAmount}, actorID); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+	return id, nil
+}
+
+// Get a return by ID, with its items
+func (r *ReturnRepository) GetByID(id string) (*entities.Return, error) {
+	returns, err := r.query(`WHERE r.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 {
+		return nil, apperrors.ErrReturnNotFound
+	}
+	return &returns[0], nil
+}
+
+// Get the returns of an order with their items, oldest first. Deleted orders are not found.
+func (r *ReturnRepository) GetByOrderID(orderID string) ([]entities.Return, error) {
+	var exists bool
+	err := r.db().QueryRow(`SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND deleted_at IS NULL)`, orderID).Scan(&exists)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if !exists {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	return r.query(`WHERE r.order_id = $1`, orderID)
+}
+
+// Move a return from one status to another
+func (r *ReturnRepository) UpdateStatus(id string, from string, to string, actorID string) error {
+	tx, err := r.db().Begin()
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var orderID string
+	err = tx.QueryRow(
+		`UPDATE returns SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND status = $3 RETURNING order_id`,
+		to, id, from).Scan(&orderID)
+	if err == sql.ErrNoRows {
+		return apperrors.ErrReturnState
+	}
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+
+	if err := touchOrder(tx, orderID, "return_"+to, map[string]interface{}{"return_id": id}, actorID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Print(err)
+		return err
+	}
+	return nil
+}
+
+// query reads the returns matching the condition on r (returns) with their items.
+func (r *ReturnRepository) query(where string, arg interface{}) ([]entities.Return, error) {
+	rows, err := r.db().Query(
+		`SELECT r.id, r.order_id, r.status, r.reason, r.refund_amount, r.created_at, r.updated_at,
+			ri.order_detail_id, ri.quantity, ri.refund_amount
+		FROM returns r
+		JOIN return_items ri ON ri.return_id = r.id
+		`+where+`
+		ORDER BY r.created_at, r.id, ri.order_detail_id`,
+		arg)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := []entities.Return{}
+	for rows.Next() {
+		var ret entities.Return
+		var reason sql.NullString
+		var item entities.ReturnItem
+		err := rows.Scan(&ret.ID, &ret.OrderID, &ret.Status, &reason, &ret.RefundAmount, &ret.CreatedAt, &ret.UpdatedAt,
+			&item.OrderDetailID, &item.Quantity, &item.RefundAmount)
+		if err != nil {
+			fmt.Print(err)
+			return nil, err
+		}
+		if n := len(returns); n == 0 || returns[n-1].ID != ret.ID {
+			ret.Reason = reason.String
+			returns = append(returns, ret)
+		}
+		last := &returns[len(returns)-1]
+		last.Items = append(last.Items, item)
+	}
+	return returns, rows.Err()
+}
+
+// touchOrder bumps the version of an order (the orders_bump_version trigger runs on every update)
+// and records the change in its history. An empty actorID marks a change made by the system.
+func touchOrder(tx *sql.Tx, orderID string, event string, details interface{}, actorID string) error {
+	if _, err := tx.Exec(`UPDATE orders SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, orderID); err != nil {
+		fmt.Print(err)
+		return err
+	}
+	err := history.Insert(tx, orderID, event, details, actorID)
+	if err != nil {
+		fmt.Print(err)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+// adapters/repositories/sagas/saga_repository.go
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+)
+
+type SagaRepository struct {
+	Db *sql.DB
+	// The tenant the statements are scoped to, or entities.AllTenants
+	TenantID string
+}
+
+// ForTenant returns the repository scoped to the sagas of a tenant.
+func (r *SagaRepository) ForTenant(tenantID string) usecases.SagaRepository {
+	return &SagaRepository{Db: r.Db, TenantID: tenantID}
+}
+
+// db returns the database scoped to the tenant of the repository.
+func (r *SagaRepository) db() *tenancy.DB {
+	return tenancy.Scope(r.Db, r.TenantID)
+}
+
+const sagaColumns = `id, order_id, state, items, error, created_at, updated_at`
+
+// Create a new saga
+func (r *SagaRepository) Create(saga *entities.OrderSaga) (string, error) {
+	items, err := json.Marshal(saga.Items)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	err = r.db().QueryRow(`INSERT INTO order_sagas (state, items) VALUES ($1, $2) RETURNING id`, saga.State, items).Scan(&id)
+	if err != nil {
+		fmt.Print(err)
+		return "", err
+	}
+	return id, nil
+}
+
+// Get a saga by ID
+func (r *SagaRepository) GetByID(id string) (*entities.OrderSaga, error) {
+	return scanSaga(r.db().QueryRow(`SELECT `+sagaColumns+` FROM order_sagas WHERE id = $1`, id))
+}
+
+// Get the saga of an order
+func (r *SagaRepository) GetByOrderID(orderID string) (*entities.OrderSaga, error) {
+	return scanSaga(r.db().QueryRow(`SELECT `+sagaColumns+` FROM order_sagas WHERE order_id = $1`, orderID))
+}
+
+// Get the sagas with a step left to run, oldest first: sagas without a stored order,
+// and sagas whose order was completed, cancelled or deleted
+func (r *SagaRepository) GetResumable(before time.Time, limit int) ([]*entities.OrderSaga, error) {
+	rows, err := r.db().Query(
+		`SELECT s.id, s.order_id, s.state, s.items, s.error, s.created_at, s.updated_at
+		FROM order_sagas s
+		LEFT JOIN orders o ON o.id = s.order_id
+		WHERE s.updated_at < $1
+			AND (s.state IN ('started', 'reserved')
+				OR (s.state = 'order_created' AND (o.id IS NULL OR o.status IN ($2, $3))))
+		ORDER BY s.updated_at
+		LIMIT $4`,
+		before, entities.OrderStatusCompleted, entities.OrderStatusCancelled, limit)
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sagas []*entities.OrderSaga
+	for rows.Next() {
+		saga, err := scanSaga(rows)
+		if err != nil {
+			return nil, err
+		}
+		sagas = append(sagas, saga)
+	}
+	return sagas, rows.Err()
+}
+
+// Move a saga to a state, recording the failure of its last step
+func (r *SagaRepository) UpdateState(id string, state entities.SagaState, errMsg string) error {
+	var nullableErr interface{}
+	if errMsg != "" {
+		nullableErr = errMsg
+	}
+	res, err := r.db().Exec(
+		`UPDATE order_sagas SET state = $1, error = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		state, nullableErr, id)
+	if err != nil {
+		fmt.Print(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return apperrors.ErrSagaNotFound
+	}
+	return nil
+}
+
+// scanSaga reads a saga row in the order of sagaColumns.
+func scanSaga(row interface{ Scan(...interface{}) error }) (*entities.OrderSaga, error) {
+	saga := &entities.OrderSaga{}
+	var orderID, errMsg sql.NullString
+	var items []byte
+	err := row.Scan(&saga.ID, &orderID, &saga.State, &items, &errMsg, &saga.CreatedAt, &saga.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.ErrSagaNotFound
+	}
+	if err != nil {
+		fmt.Print(err)
+		return nil, err
+	}
+	if err := json.Unmarshal(items, &saga.Items); err != nil {
+		return nil, fmt.Errorf("saga %s: invalid items: %v", saga.ID, err)
+	}
+	saga.OrderID, saga.Error = orderID.String, errMsg.String
+	return saga, nil
+}
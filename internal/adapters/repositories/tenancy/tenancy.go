@@ -0,0 +1,136 @@
+// adapters/repositories/tenancy/tenancy.go
+package tenancy
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// DB runs the statements of a repository in transactions scoped to one tenant.
+// Every transaction starts with SET LOCAL app.tenant_id, which the row level security policies
+// compare with the tenant_id of the rows, so the statements only see and write the rows of the tenant.
+// A DB without a tenant refuses to run any statement.
+type DB struct {
+	db       *sql.DB
+	tenantID string
+}
+
+// Scope returns db scoped to a tenant.
+func Scope(db *sql.DB, tenantID string) *DB {
+	return &DB{db: db, tenantID: tenantID}
+}
+
+// SetTenant scopes a transaction to a tenant, it lasts until the transaction ends.
+func SetTenant(ctx context.Context, tx *sql.Tx, tenantID string) error {
+	if tenantID == "" {
+		return apperrors.ErrTenantRequired
+	}
+	// SET does not take bind parameters
+	_, err := tx.ExecContext(ctx, `SET LOCAL app.tenant_id = `+pq.QuoteLiteral(tenantID))
+	return err
+}
+
+// Begin starts a transaction scoped to the tenant.
+func (d *DB) Begin() (*sql.Tx, error) {
+	return d.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a transaction scoped to the tenant with the given options.
+func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if d.tenantID == "" {
+		return nil, apperrors.ErrTenantRequired
+	}
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := SetTenant(ctx, tx, d.tenantID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Exec runs a statement in its own transaction.
+func (d *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return res, tx.Commit()
+}
+
+// Query runs a query in its own transaction, which ends when the rows are closed.
+func (d *DB) Query(query string, args ...interface{}) (*Rows, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &Rows{Rows: rows, tx: tx}, nil
+}
+
+// QueryRow runs a query returning at most one row in its own transaction, which ends when the row is scanned.
+func (d *DB) QueryRow(query string, args ...interface{}) *Row {
+	tx, err := d.Begin()
+	if err != nil {
+		return &Row{err: err}
+	}
+	return &Row{row: tx.QueryRow(query, args...), tx: tx}
+}
+
+// Rows are the result of DB.Query. Closing them commits the transaction of the query,
+// or rolls it back when reading the rows failed.
+type Rows struct {
+	*sql.Rows
+	tx     *sql.Tx
+	closed bool
+}
+
+// Close closes the rows and ends their transaction.
+func (r *Rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if err := r.Rows.Close(); err != nil {
+		r.tx.Rollback()
+		return err
+	}
+	if r.Rows.Err() != nil {
+		return r.tx.Rollback()
+	}
+	return r.tx.Commit()
+}
+
+// Row is the result of DB.QueryRow.
+type Row struct {
+	row *sql.Row
+	tx  *sql.Tx
+	err error
+}
+
+// Scan copies the columns of the row into dest and ends the transaction of the query.
+// Like sql.Row, it returns sql.ErrNoRows when the query selected no row.
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if err := r.row.Scan(dest...); err != nil {
+		r.tx.Rollback()
+		return err
+	}
+	return r.tx.Commit()
+}
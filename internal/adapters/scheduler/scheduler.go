@@ -0,0 +1,66 @@
+// internal/adapters/scheduler/scheduler.go
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a task run periodically by the scheduler.
+type Job struct {
+	// Name identifies the job in the logs
+	Name string
+	// Interval is the time between the end of a run and the start of the next one
+	Interval time.Duration
+	// Run performs one run of the job
+	Run func(ctx context.Context) error
+}
+
+// Scheduler runs jobs in the background of the service, each in its own goroutine.
+// Jobs that must run on a single replica take care of it themselves, e.g. with an advisory lock.
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+// Add registers a job, it is run once Start is called.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job right away and then after each interval, until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			for {
+				runJob(ctx, job)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(job.Interval):
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait blocks until all jobs stopped after their context was cancelled.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// runJob runs a job once, a failing or panicking run is logged and the job keeps its schedule.
+func runJob(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", job.Name, r)
+		}
+	}()
+	if err := job.Run(ctx); err != nil {
+		log.Printf("Job %s failed: %v", job.Name, err)
+	}
+}
@@ -0,0 +1,145 @@
+// internal/entities/address.go
+package entities
+
+import "regexp"
+
+// Delivery methods.
+const (
+	DeliveryStandard = "standard"
+	DeliveryExpress  = "express"
+	DeliveryPickup   = "pickup"
+)
+
+// IsValidDeliveryMethod reports whether method is one of the known delivery methods.
+func IsValidDeliveryMethod(method string) bool {
+	return method == DeliveryStandard || method == DeliveryExpress || method == DeliveryPickup
+}
+
+// RequiresShippingAddress reports whether orders delivered with method must carry a shipping address.
+func RequiresShippingAddress(method string) bool {
+	return method == DeliveryStandard || method == DeliveryExpress
+}
+
+// Address kinds, as stored in order_addresses.
+const (
+	AddressShipping = "shipping"
+	AddressBilling  = "billing"
+)
+
+// Address is a postal address of an order.
+type Address struct {
+	// The name of the recipient
+	// example: Jane Doe
+	Name string `json:"name" example:"Jane Doe"`
+	// example: 1 Main Street
+	Line1 string `json:"line1" example:"1 Main Street"`
+	// example: Apartment 4
+	Line2 string `json:"line2,omitempty" example:"Apartment 4"`
+	// example: Springfield
+	City string `json:"city" example:"Springfield"`
+	// The state, province or region, required in the countries that use one in addresses
+	// example: IL
+	Region string `json:"region,omitempty" example:"IL"`
+	// The postal code, required in the countries that have them
+	// example: 62701
+	PostalCode string `json:"postal_code,omitempty" example:"62701"`
+	// The ISO 3166-1 alpha-2 country code
+	// example: US
+	Country string `json:"country" example:"US" minLength:"2" maxLength:"2"`
+	// example: +1 217 555 0100
+	Phone string `json:"phone,omitempty" example:"+1 217 555 0100"`
+}
+
+// Fulfilment holds the delivery details of an order.
+type Fulfilment struct {
+	DeliveryMethod  string
+	ShippingAddress *Address
+	BillingAddress  *Address
+	TrackingNumber  string
+	Carrier         string
+}
+
+// FulfilmentRequest sets the shipment of an order that is processing or completed.
+type FulfilmentRequest struct {
+	// The tracking number given by the carrier
+	// example: 1Z999AA10123456784
+	TrackingNumber string `json:"tracking_number" binding:"required" example:"1Z999AA10123456784"`
+	// The carrier that delivers the order
+	// example: UPS
+	Carrier string `json:"carrier" binding:"required" example:"UPS"`
+}
+
+// countryFormat describes the addresses of a country.
+type countryFormat struct {
+	// Matches the postal codes of the country, nil when the country has none
+	postalCode *regexp.Regexp
+	// Whether addresses must carry a state, province or region
+	region bool
+}
+
+func postalCode(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`^(?:` + pattern + `)$`)
+}
+
+// countryFormats holds the address formats of the countries orders can be shipped to, by ISO 3166-1 alpha-2 code.
+var countryFormats = map[string]countryFormat{
+	"AT": {postalCode: postalCode(`\d{4}`)},
+	"AU": {postalCode: postalCode(`\d{4}`), region: true},
+	"BE": {postalCode: postalCode(`\d{4}`)},
+	"BR": {postalCode: postalCode(`\d{5}-?\d{3}`), region: true},
+	"CA": {postalCode: postalCode(`[A-Z]\d[A-Z] ?\d[A-Z]\d`), region: true},
+	"CH": {postalCode: postalCode(`\d{4}`)},
+	"CL": {postalCode: postalCode(`\d{7}`)},
+	"CN": {postalCode: postalCode(`\d{6}`), region: true},
+	"CZ": {postalCode: postalCode(`\d{3} ?\d{2}`)},
+	"DE": {postalCode: postalCode(`\d{5}`)},
+	"DK": {postalCode: postalCode(`\d{4}`)},
+	"ES": {postalCode: postalCode(`\d{5}`)},
+	"FI": {postalCode: postalCode(`\d{5}`)},
+	"FR": {postalCode: postalCode(`\d{5}`)},
+	"GB": {postalCode: postalCode(`[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}`)},
+	"HK": {},
+	"HU": {postalCode: postalCode(`\d{4}`)},
+	"IE": {postalCode: postalCode(`[A-Z]\d[\dW] ?[A-Z\d]{4}`)},
+	"IL": {postalCode: postalCode(`\d{7}`)},
+	"IN": {postalCode: postalCode(`\d{6}`), region: true},
+	"IS": {postalCode: postalCode(`\d{3}`)},
+	"IT": {postalCode: postalCode(`\d{5}`)},
+	"JP": {postalCode: postalCode(`\d{3}-?\d{4}`), region: true},
+	"KR": {postalCode: postalCode(`\d{5}`)},
+	"MX": {postalCode: postalCode(`\d{5}`), region: true},
+	"NL": {postalCode: postalCode(`\d{4} ?[A-Z]{2}`)},
+	"NO": {postalCode: postalCode(`\d{4}`)},
+	"NZ": {postalCode: postalCode(`\d{4}`)},
+	"PL": {postalCode: postalCode(`\d{2}-\d{3}`)},
+	"PT": {postalCode: postalCode(`\d{4}-\d{3}`)},
+	"SE": {postalCode: postalCode(`\d{3} ?\d{2}`)},
+	"SG": {postalCode: postalCode(`\d{6}`)},
+	"TR": {postalCode: postalCode(`\d{5}`)},
+	"US": {postalCode: postalCode(`\d{5}(?:-\d{4})?`), region: true},
+	"VN": {postalCode: postalCode(`\d{6}`)},
+	"ZA": {postalCode: postalCode(`\d{4}`)},
+}
+
+// IsKnownCountry reports whether code is the ISO 3166-1 alpha-2 code of a country orders can be shipped to.
+func IsKnownCountry(code string) bool {
+	_, ok := countryFormats[code]
+	return ok
+}
+
+// HasPostalCodes reports whether the addresses of the country carry a postal code.
+func HasPostalCodes(country string) bool {
+	return countryFormats[country].postalCode != nil
+}
+
+// IsValidPostalCode reports whether code is a well-formed postal code of the country.
+// Codes are expected in upper case.
+func IsValidPostalCode(country string, code string) bool {
+	pattern := countryFormats[country].postalCode
+	return pattern != nil && pattern.MatchString(code)
+}
+
+// RequiresRegion reports whether the addresses of the country must carry a state, province or region.
+func RequiresRegion(country string) bool {
+	return countryFormats[country].region
+}
@@ -0,0 +1,56 @@
+// internal/entities/bulk.go
+package entities
+
+// Result statuses of a single bulk item.
+const (
+	BulkItemSuccess = "success"
+	BulkItemFailed  = "failed"
+)
+
+// BulkOrderRequest represents a request to create many orders at once.
+type BulkOrderRequest struct {
+	// The orders to create
+	// required: true
+	Orders []OrderRequest `json:"orders" binding:"required,dive"`
+	// When true, either all orders are created or none are (all-or-nothing mode).
+	// When false, every order is attempted and the result is reported per item (best-effort mode).
+	// example: true
+	Atomic bool `json:"atomic" example:"true"`
+}
+
+// StatusUpdate represents a status change of a single order.
+type StatusUpdate struct {
+	// The UUID of the order
+	// example: 6204037c-30e6-408b-8aaa-dd8219860b4b
+	// required: true
+	ID string `json:"id" binding:"required,uuid" example:"6204037c-30e6-408b-8aaa-dd8219860b4b" minLength:"36"`
+	// The new status of the order (1=created/pending, 2=processing, 3=completed, 4=cancelled)
+	// example: 2
+	// required: true
+	Status int `json:"status" binding:"min=1,max=4" example:"2" format:"int32" minimum:"1" maximum:"4"`
+}
+
+// BulkStatusRequest represents a request to change the status of many orders at once.
+type BulkStatusRequest struct {
+	// The status changes to apply
+	// required: true
+	Updates []StatusUpdate `json:"updates" binding:"required,dive"`
+	// When true, either all updates are applied or none are.
+	// example: false
+	Atomic bool `json:"atomic" example:"false"`
+}
+
+// BulkItemResult represents the outcome of a single item of a bulk request.
+type BulkItemResult struct {
+	// The position of the item in the request
+	// example: 0
+	Index int `json:"index" example:"0"`
+	// The UUID of the created or updated order
+	// example: 6204037c-30e6-408b-8aaa-dd8219860b4b
+	ID string `json:"id,omitempty" example:"6204037c-30e6-408b-8aaa-dd8219860b4b"`
+	// The outcome of the item (success or failed)
+	// example: success
+	Status string `json:"status" example:"success"`
+	// The reason the item failed
+	Error string `json:"error,omitempty"`
+}
@@ -4,4 +4,6 @@ package entities
 // IDRequest represents a request to get any entity by its ID.
 type IDRequest struct {
 	ID string `uri:"id" binding:"required" example:"451fa817-41f4-40cf-8dc2-c9f22aa98a4f" minLength:"36"`
-}
\ No newline at end of file
+}
+// RoleAdmin is the value of the JWT role claim that grants access to other users' data.
+const RoleAdmin = "admin"
@@ -0,0 +1,67 @@
+// internal/entities/currency.go
+package entities
+
+// DefaultCurrency is the currency of orders created without one, when no allow-list is configured.
+const DefaultCurrency = "USD"
+
+// currencyMinorUnits holds the ISO 4217 minor units (number of decimal places) of the known currencies.
+// Currencies with three minor units (e.g. BHD, KWD) are left out, since Money keeps two decimal places.
+var currencyMinorUnits = map[string]int{
+	"AUD": 2,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"CNY": 2,
+	"CZK": 2,
+	"DKK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"HUF": 2,
+	"ILS": 2,
+	"INR": 2,
+	"ISK": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"MXN": 2,
+	"NOK": 2,
+	"NZD": 2,
+	"PLN": 2,
+	"SEK": 2,
+	"SGD": 2,
+	"TRY": 2,
+	"USD": 2,
+	"VND": 0,
+	"ZAR": 2,
+}
+
+// IsKnownCurrency reports whether code is a supported ISO 4217 currency code.
+func IsKnownCurrency(code string) bool {
+	_, ok := currencyMinorUnits[code]
+	return ok
+}
+
+// CurrencyMinorUnits returns the number of decimal places used by the currency.
+// Unknown or empty codes fall back to MoneyScale.
+func CurrencyMinorUnits(code string) int {
+	if units, ok := currencyMinorUnits[code]; ok {
+		return units
+	}
+	return MoneyScale
+}
+
+// FitsCurrency reports whether the amount can be expressed in the minor units of the currency,
+// e.g. 100.50 is not a valid JPY amount.
+func (m Money) FitsCurrency(code string) bool {
+	divisor := int64(1)
+	for i := CurrencyMinorUnits(code); i < MoneyScale; i++ {
+		divisor *= 10
+	}
+	return m.cents%divisor == 0
+}
+
+// FormatCurrency formats the amount with the minor units of the currency, e.g. "1500" for JPY.
+func (m Money) FormatCurrency(code string) string {
+	return m.StringFixed(CurrencyMinorUnits(code))
+}
@@ -0,0 +1,27 @@
+// internal/entities/filter.go
+package entities
+
+import "time"
+
+// OrderFilter narrows down a set of orders.
+// Zero values mean "no restriction" for the matching field.
+type OrderFilter struct {
+	// Only orders of this user, empty means all users
+	UserID string
+	// Only orders in this status
+	Status int
+	// Only orders in this currency
+	Currency string
+	// Only orders created at or after this time
+	From *time.Time
+	// Only orders created before this time
+	To *time.Time
+	// Only orders with a line item of this product
+	ProductID string
+	// Only orders whose ID starts with this prefix
+	IDPrefix string
+	// Also the orders moved to the archive
+	IncludeArchived bool
+	// The number of orders per page, zero uses the default page size
+	PageSize int
+}
@@ -0,0 +1,265 @@
+// internal/entities/money.go
+package entities
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MoneyScale is the number of decimal places kept by Money, matching the NUMERIC(10, 2) columns.
+const MoneyScale = 2
+
+var (
+	// ErrMoneyFormat is returned when parsing a value that is not a plain decimal number.
+	ErrMoneyFormat = errors.New("invalid money amount")
+	// ErrMoneyScale is returned when a value has more decimal places than MoneyScale.
+	ErrMoneyScale = fmt.Errorf("money amounts cannot have more than %d decimal places", MoneyScale)
+	// ErrMoneyRange is returned when a value does not fit in Money.
+	ErrMoneyRange = errors.New("money amount out of range")
+	// ErrMoneyDivision is returned when dividing an amount by zero.
+	ErrMoneyDivision = errors.New("money amount divided by zero")
+)
+
+// Money is an exact fixed-point amount, stored as a number of cents.
+// It is read from and written to JSON as a plain decimal number (e.g. 100.50)
+// and to the database as a NUMERIC string, so no amount ever goes through float64.
+type Money struct {
+	cents int64
+}
+
+// MoneyFromCents returns the amount of the given number of cents.
+func MoneyFromCents(cents int64) Money {
+	return Money{cents: cents}
+}
+
+// ParseMoney parses a decimal string such as "12", "-3.5" or "100.25".
+// Values with more than MoneyScale decimal places are rejected rather than rounded.
+func ParseMoney(value string) (Money, error) {
+	s := strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyFormat, value)
+	}
+	// Trailing zeros do not add precision, so "1.500" is accepted.
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > MoneyScale {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyScale, value)
+	}
+	fraction += strings.Repeat("0", MoneyScale-len(fraction))
+
+	if whole == "" {
+		whole = "0"
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (math.MaxInt64-99)/100 {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyRange, value)
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+	cents += units * 100
+	if negative {
+		cents = -cents
+	}
+	return Money{cents: cents}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics on error. Meant for constants and tests.
+func MustParseMoney(value string) Money {
+	m, err := ParseMoney(value)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Cents returns the amount as a number of cents.
+func (m Money) Cents() int64 {
+	return m.cents
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.cents == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.cents < 0
+}
+
+// Add returns m + other.
+func (m Money) Add(other Money) Money {
+	return Money{cents: m.cents + other.cents}
+}
+
+// Sub returns m - other.
+func (m Money) Sub(other Money) Money {
+	return Money{cents: m.cents - other.cents}
+}
+
+// Mul returns m multiplied by a quantity. Line totals are exact, no rounding is involved.
+// The result wraps around when it does not fit in Money, use MulChecked for amounts that are not bounded.
+func (m Money) Mul(quantity int) Money {
+	return Money{cents: m.cents * int64(quantity)}
+}
+
+// MulChecked is Mul failing with ErrMoneyRange when the result does not fit in Money.
+func (m Money) MulChecked(quantity int) (Money, error) {
+	cents, ok := mulInt64(m.cents, int64(quantity))
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrMoneyRange, m, quantity)
+	}
+	return Money{cents: cents}, nil
+}
+
+// MulFraction returns m * numerator / denominator, rounded to the nearest cent with
+// halves rounded away from zero. It is used for percentages such as tax rates and discounts.
+// The result wraps around when m * numerator does not fit in Money and it panics when denominator
+// is zero, use MulFractionChecked for values that are not bounded.
+func (m Money) MulFraction(numerator, denominator int64) Money {
+	product := m.cents * numerator
+	quotient, remainder := product/denominator, product%denominator
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if abs(denominator) <= 2*remainder {
+		if (product < 0) != (denominator < 0) {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return Money{cents: quotient}
+}
+
+// MulFractionChecked is MulFraction failing with ErrMoneyRange when m * numerator or the result
+// does not fit in Money, and with ErrMoneyDivision when denominator is zero.
+func (m Money) MulFractionChecked(numerator, denominator int64) (Money, error) {
+	if denominator == 0 {
+		return Money{}, fmt.Errorf("%w: %s * %d / 0", ErrMoneyDivision, m, numerator)
+	}
+	product, ok := mulInt64(m.cents, numerator)
+	if ok && denominator == -1 {
+		_, ok = mulInt64(product, -1)
+	}
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s * %d / %d", ErrMoneyRange, m, numerator, denominator)
+	}
+	return m.MulFraction(numerator, denominator), nil
+}
+
+// mulInt64 returns a * b, and false when the product does not fit in an int64.
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// String formats the amount with MoneyScale decimal places, e.g. "100.50".
+func (m Money) String() string {
+	return m.StringFixed(MoneyScale)
+}
+
+// StringFixed formats the amount with the given number of decimal places (0 to MoneyScale).
+// Digits beyond places are dropped, callers are expected to only use it for amounts without them.
+func (m Money) StringFixed(places int) string {
+	sign := ""
+	cents := m.cents
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	units, fraction := cents/100, fmt.Sprintf("%02d", cents%100)
+	if places <= 0 {
+		return fmt.Sprintf("%s%d", sign, units)
+	}
+	return fmt.Sprintf("%s%d.%s", sign, units, fraction[:min(places, MoneyScale)])
+}
+
+// MarshalJSON writes the amount as a plain JSON number.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding a decimal number.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner. NULL is read as zero.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case []byte:
+		parsed, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case int64:
+		*m = Money{cents: v * 100}
+		return nil
+	case float64:
+		*m = Money{cents: int64(math.Round(v * 100))}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+}
+
+// Value implements driver.Valuer, the amount is sent as a NUMERIC literal.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -2,10 +2,24 @@ package entities
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 )
 
+// Order statuses.
+const (
+	OrderStatusPending    = 1
+	OrderStatusProcessing = 2
+	OrderStatusCompleted  = 3
+	OrderStatusCancelled  = 4
+)
+
+// IsValidOrderStatus reports whether status is one of the known order statuses.
+func IsValidOrderStatus(status int) bool {
+	return status >= OrderStatusPending && status <= OrderStatusCancelled
+}
+
 // Order represents an order entity for this application.
 // swagger:model
 type Order struct {
@@ -16,20 +30,61 @@ type Order struct {
 	// example: 451fa817-41f4-40cf-8dc2-c9f22aa98a4f
 	// required: true
 	UserID string `json:"user_id" example:"451fa817-41f4-40cf-8dc2-c9f22aa98a4f" minLength:"36"`
-	// The total price of the order
+	// The grand total of the order: subtotal - discount total + tax total + shipping
 	// example: 100.00
 	// required: true
-	TotalPrice float64 `json:"total_price" example:"100.00" format:"float64"`
+	TotalPrice Money `json:"total_price" example:"100.00" swaggertype:"number"`
+	// The sum of the line totals, before discounts and tax
+	// example: 90.00
+	Subtotal Money `json:"subtotal" example:"90.00" swaggertype:"number"`
+	// The sum of the line discounts
+	// example: 5.00
+	DiscountTotal Money `json:"discount_total" example:"5.00" swaggertype:"number"`
+	// The sum of the line taxes
+	// example: 10.00
+	TaxTotal Money `json:"tax_total" example:"10.00" swaggertype:"number"`
+	// The shipping cost of the order
+	// example: 5.00
+	ShippingTotal Money `json:"shipping_total" example:"5.00" swaggertype:"number"`
 	// The status of the order (1=created/pending, 2=processing, 3=completed, 4=cancelled)
 	// example: 2
 	// required: true
 	Status int `json:"status" example:"1" format:"int32" minimum:"1"`
+	// The ISO 4217 currency code of the order amounts
+	// example: USD
+	Currency string `json:"currency" example:"USD" minLength:"3" maxLength:"3"`
 	// The date and time the order was created
 	// example: 2024-07-01T12:00:00Z
 	CreatedAt time.Time `json:"created_at" example:"2024-07-01T12:00:00Z" minLength:"20"`
 	// The date and time the order was last updated
 	// example: 2025-01-01T12:00:00Z
 	UpdatedAt time.Time `json:"updated_at"`
+	// The version of the order, incremented on every change
+	// example: 1
+	Version int `json:"version" example:"1" format:"int32"`
+	// How the order is delivered (standard, express, pickup)
+	// example: standard
+	DeliveryMethod string `json:"delivery_method,omitempty" example:"standard"`
+	// The address the order is shipped to
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	// The address the order is billed to
+	BillingAddress *Address `json:"billing_address,omitempty"`
+	// The tracking number of the shipment, set once the order is processing or completed
+	// example: 1Z999AA10123456784
+	TrackingNumber string `json:"tracking_number,omitempty" example:"1Z999AA10123456784"`
+	// The carrier of the shipment
+	// example: UPS
+	Carrier string `json:"carrier,omitempty" example:"UPS"`
+	// The order line items, only loaded when requested
+	OrderDetails []OrderDetail `json:"order_details,omitempty"`
+	// The sum of the refunds of the order returns, only loaded with the returns
+	// example: 0.00
+	RefundedTotal Money `json:"refunded_total" example:"0.00" swaggertype:"number"`
+	// The returns of the order, only loaded when requested
+	Returns []Return `json:"returns,omitempty"`
+	// The date and time the order was moved to the archive, empty for orders that are not archived
+	// example: 2027-01-01T03:00:00Z
+	ArchivedAt *time.Time `json:"archived_at,omitempty" example:"2027-01-01T03:00:00Z"`
 }
 
 // OrderDetail represents an order line item entity.
@@ -44,19 +99,27 @@ type OrderDetail struct {
 	// The UUID of the related product
 	// example: 063d0ff7-e17e-4957-8d92-a988caeda8a1
 	// required: true
-	ProductID string `json:"product_id" example:"063d0ff7-e17e-4957-8d92-a988caeda8a1" minLength:"36"`
+	ProductID string `json:"product_id" binding:"required,uuid" example:"063d0ff7-e17e-4957-8d92-a988caeda8a1" minLength:"36"`
 	// The quantity of the product
 	// example: 2
 	// required: true
-	Quantity int `json:"quantity" example:"1" format:"int32" minimum:"1"`
+	Quantity int `json:"quantity" binding:"required,min=1,max=10000" example:"1" format:"int32" minimum:"1" maximum:"10000"`
 	// The unit price of the product
 	// example: 50.00
 	// required: true
-	UnitPrice float64 `json:"unit_price" example:"50.00" format:"float64"`
-	// The date and time the order detail was created
-	// example: 2024-07-01T12:00:00Z
-	// required: true
-	TotalPrice float64 `json:"total_price" example:"55.00" format:"float64"`
+	UnitPrice Money `json:"unit_price" binding:"money_min=0,money_max=1000000" example:"50.00" swaggertype:"number"`
+	// The line total before discount and tax (quantity * unit price)
+	// example: 100.00
+	TotalPrice Money `json:"total_price" example:"100.00" swaggertype:"number"`
+	// The discount granted on the line, computed when the order is priced
+	// example: 5.00
+	DiscountAmount Money `json:"discount_amount" example:"5.00" swaggertype:"number"`
+	// The tax charged on the line after its discount, computed when the order is priced
+	// example: 16.15
+	TaxAmount Money `json:"tax_amount" example:"16.15" swaggertype:"number"`
+	// The ISO 4217 currency code of the line item, must match the order currency when set
+	// example: USD
+	Currency string `json:"currency,omitempty" binding:"omitempty,len=3" example:"USD" minLength:"3" maxLength:"3"`
 	// The date and time the order detail was created
 	// swagger:ignore
 	CreatedAt time.Time `json:"created_at" swaggerignore:"true"`
@@ -70,20 +133,94 @@ type OrderRequest struct {
 	// The user that creates the order
 	// example: 451fa817-41f4-40cf-8dc2-c9f22aa98a4f
 	// required: true
-	UserID string `json:"user_id" example:"063d0ff7-e17e-4957-8d92-a988caeda8a1" minLength:"36"`
-	// The total price of the order
+	UserID string `json:"user_id" binding:"required,uuid" example:"063d0ff7-e17e-4957-8d92-a988caeda8a1" minLength:"36"`
+	// The grand total of the order, computed when the order is priced
 	// example: 100.00
-	// required: true
-	TotalPrice float64 `json:"total_price" example:"100.00" format:"float64"`
+	TotalPrice Money `json:"total_price" example:"100.00" swaggertype:"number"`
+	// The shipping cost of the order
+	// example: 5.00
+	ShippingTotal Money `json:"shipping_total" binding:"money_min=0,money_max=1000000" example:"5.00" swaggertype:"number"`
+	// The price breakdown, computed when the order is priced
+	Subtotal      Money `json:"-"`
+	DiscountTotal Money `json:"-"`
+	TaxTotal      Money `json:"-"`
 	// The status of the order (1=created/pending, 2=processing, 3=completed, 4=cancelled)
 	// example: 1
 	// required: true
-	Status int `json:"status" example:"1" format:"int32" minimum:"1"`
-	// Array of the order line items.
-	// example: [{ "product_id": "063d0ff7-e17e-4957-8d92-a988caeda8a1", "quantity": 1, "unit_price": 101.00, "total_price": 102.00 }]
+	Status int `json:"status" binding:"omitempty,min=1,max=4" example:"1" format:"int32" minimum:"1"`
+	// The ISO 4217 currency code of the order amounts, defaults to the first allowed currency
+	// example: USD
+	Currency string `json:"currency" binding:"omitempty,len=3" example:"USD" minLength:"3" maxLength:"3"`
+	// The coupon code of a promotion to apply
+	// example: SUMMER25
+	CouponCode string `json:"coupon_code,omitempty" binding:"omitempty,max=32" example:"SUMMER25"`
+	// How the order is delivered (standard, express, pickup), defaults to standard when a shipping address is given.
+	// Standard and express delivery require a shipping address.
+	// example: standard
+	DeliveryMethod string `json:"delivery_method,omitempty" example:"standard"`
+	// The address the order is shipped to
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	// The address the order is billed to
+	BillingAddress *Address `json:"billing_address,omitempty"`
+	// The promotion of the coupon code, resolved when the order is priced
+	PromotionID string `json:"-"`
+	// The inventory saga of the order, attached to the order when it is stored
+	SagaID string `json:"-"`
+	// Array of the order line items, up to 100 lines with one line per product.
+	// example: [{ "product_id": "063d0ff7-e17e-4957-8d92-a988caeda8a1", "quantity": 1, "unit_price": 101.00 }]
 	// required: true
-	OrderDetails []OrderDetail `json:"order_details"`
+	OrderDetails []OrderDetail `json:"order_details" binding:"required,min=1,max=100,unique=ProductID,dive"`
 }
 
 // Convert order details to database-compatible array
-func (v OrderDetail) Value() (driver.Value, error) { return []byte(fmt.Sprintf("(%s,%d,%f)", v.ProductID, v.Quantity, v.UnitPrice)), nil }
\ No newline at end of file
+func (v OrderDetail) Value() (driver.Value, error) { return []byte(fmt.Sprintf("(%s,%d,%s,%s,%s)", v.ProductID, v.Quantity, v.UnitPrice, v.DiscountAmount, v.TaxAmount)), nil }
+
+// MarshalJSON writes the order amounts with the minor units of the order currency,
+// so that a JPY order total reads 1500 rather than 1500.00.
+func (o Order) MarshalJSON() ([]byte, error) {
+	type order Order
+	details := make([]orderDetailJSON, len(o.OrderDetails))
+	for i, detail := range o.OrderDetails {
+		details[i] = newOrderDetailJSON(detail, o.Currency)
+	}
+	return json.Marshal(struct {
+		order
+		TotalPrice    json.Number       `json:"total_price"`
+		Subtotal      json.Number       `json:"subtotal"`
+		DiscountTotal json.Number       `json:"discount_total"`
+		TaxTotal      json.Number       `json:"tax_total"`
+		ShippingTotal json.Number       `json:"shipping_total"`
+		OrderDetails  []orderDetailJSON `json:"order_details,omitempty"`
+		RefundedTotal json.Number       `json:"refunded_total"`
+	}{
+		order:         order(o),
+		TotalPrice:    json.Number(o.TotalPrice.FormatCurrency(o.Currency)),
+		Subtotal:      json.Number(o.Subtotal.FormatCurrency(o.Currency)),
+		DiscountTotal: json.Number(o.DiscountTotal.FormatCurrency(o.Currency)),
+		TaxTotal:      json.Number(o.TaxTotal.FormatCurrency(o.Currency)),
+		ShippingTotal: json.Number(o.ShippingTotal.FormatCurrency(o.Currency)),
+		OrderDetails:  details,
+		RefundedTotal: json.Number(o.RefundedTotal.FormatCurrency(o.Currency)),
+	})
+}
+
+type orderDetail OrderDetail
+
+// orderDetailJSON is the JSON form of a line item, with its amounts in the order currency.
+type orderDetailJSON struct {
+	orderDetail
+	UnitPrice      json.Number `json:"unit_price"`
+	TotalPrice     json.Number `json:"total_price"`
+	DiscountAmount json.Number `json:"discount_amount"`
+	TaxAmount      json.Number `json:"tax_amount"`
+}
+
+func newOrderDetailJSON(detail OrderDetail, currency string) orderDetailJSON {
+	return orderDetailJSON{
+		orderDetail:    orderDetail(detail),
+		UnitPrice:      json.Number(detail.UnitPrice.FormatCurrency(currency)),
+		TotalPrice:     json.Number(detail.TotalPrice.FormatCurrency(currency)),
+		DiscountAmount: json.Number(detail.DiscountAmount.FormatCurrency(currency)),
+		TaxAmount:      json.Number(detail.TaxAmount.FormatCurrency(currency)),
+	}
+}
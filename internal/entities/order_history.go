@@ -0,0 +1,50 @@
+// internal/entities/order_history.go
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Order history events.
+const (
+	HistoryItemAdded       = "item_added"
+	HistoryItemUpdated     = "item_updated"
+	HistoryItemRemoved     = "item_removed"
+	HistoryPaymentCaptured = "payment_captured"
+	HistoryReturnRequested = "return_requested"
+	HistoryReturnReceived  = "return_received"
+	HistoryReturnRefunded  = "return_refunded"
+	HistoryFulfilmentSet   = "fulfilment_set"
+	HistoryAutoCancelled   = "auto_cancelled"
+	HistoryStatusChanged   = "status_changed"
+	HistoryStatusForced    = "status_forced"
+	HistoryDeleted         = "deleted"
+)
+
+// OrderHistory represents a recorded change of an order.
+type OrderHistory struct {
+	// The UUID of the history entry
+	ID string `json:"id" example:"2f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2"`
+	// The UUID of the order
+	OrderID string `json:"order_id" example:"6204037c-30e6-408b-8aaa-dd8219860b4b"`
+	// The kind of change
+	// example: item_added
+	Event string `json:"event" example:"item_added"`
+	// Event specific details
+	Details json.RawMessage `json:"details,omitempty" swaggertype:"object"`
+	// The user who made the change, empty for changes made by the system
+	ActorID string `json:"actor_id,omitempty" example:"451fa817-41f4-40cf-8dc2-c9f22aa98a4f"`
+	// The date and time of the change
+	CreatedAt time.Time `json:"created_at" example:"2024-07-01T12:00:00Z"`
+}
+
+// ForceStatusRequest sets the status of an order regardless of the status rules.
+type ForceStatusRequest struct {
+	// The new status of the order
+	// example: 4
+	Status int `json:"status" binding:"required,min=1,max=4" example:"4"`
+	// Why the status is forced, recorded in the order history
+	// example: Customer called support to cancel
+	Reason string `json:"reason" binding:"required,max=500" example:"Customer called support to cancel"`
+}
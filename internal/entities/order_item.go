@@ -0,0 +1,44 @@
+// internal/entities/order_item.go
+package entities
+
+// OrderItemRequest represents a request to add a line item to a pending order.
+type OrderItemRequest struct {
+	// The UUID of the product
+	// example: 063d0ff7-e17e-4957-8d92-a988caeda8a1
+	// required: true
+	ProductID string `json:"product_id" binding:"required,uuid" example:"063d0ff7-e17e-4957-8d92-a988caeda8a1" minLength:"36"`
+	// The quantity of the product
+	// example: 1
+	// required: true
+	Quantity int `json:"quantity" binding:"required,min=1,max=10000" example:"1" format:"int32" minimum:"1" maximum:"10000"`
+	// The unit price of the product
+	// example: 50.00
+	// required: true
+	UnitPrice Money `json:"unit_price" binding:"money_min=0,money_max=1000000" example:"50.00" swaggertype:"number"`
+	// The ISO 4217 currency code of the line item, must match the order currency when set
+	// example: USD
+	Currency string `json:"currency,omitempty" binding:"omitempty,len=3" example:"USD" minLength:"3" maxLength:"3"`
+	// The line discount and tax, computed when the item is priced
+	DiscountAmount Money `json:"-"`
+	TaxAmount      Money `json:"-"`
+}
+
+// OrderItemUpdate represents a request to change a line item of a pending order.
+// Omitted fields are left unchanged.
+type OrderItemUpdate struct {
+	// The new quantity of the product
+	// example: 2
+	Quantity *int `json:"quantity,omitempty" binding:"omitempty,min=1,max=10000" example:"2" format:"int32" minimum:"1" maximum:"10000"`
+	// The new unit price of the product
+	// example: 45.00
+	UnitPrice *Money `json:"unit_price,omitempty" binding:"omitempty,money_min=0,money_max=1000000" example:"45.00" swaggertype:"number"`
+	// The line discount and tax, recomputed when the item is priced
+	DiscountAmount *Money `json:"-"`
+	TaxAmount      *Money `json:"-"`
+}
+
+// OrderItemURI represents the path of a single line item.
+type OrderItemURI struct {
+	ID     string `uri:"id" binding:"required" example:"451fa817-41f4-40cf-8dc2-c9f22aa98a4f" minLength:"36"`
+	ItemID string `uri:"itemId" binding:"required" example:"9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d" minLength:"36"`
+}
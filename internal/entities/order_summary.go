@@ -0,0 +1,57 @@
+// internal/entities/order_summary.go
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OrderSummary sums up the orders of a user.
+type OrderSummary struct {
+	// The number of orders, in any status
+	Orders int `json:"orders" example:"12"`
+	// The date and time of the last order, empty when the user has no orders
+	LastOrderAt *time.Time `json:"last_order_at" example:"2024-07-01T12:00:00Z"`
+	// The sum of the grand totals of the orders per currency, cancelled orders left out
+	TotalSpent []CurrencyAmount `json:"total_spent"`
+	// The number of orders in each status
+	ByStatus OrderStatusCounts `json:"by_status"`
+}
+
+// CurrencyAmount is an amount in a currency.
+type CurrencyAmount struct {
+	// The ISO 4217 currency code
+	Currency string `json:"currency" example:"USD"`
+	// The amount, with the minor units of the currency
+	Amount Money `json:"amount" example:"1250.00" swaggertype:"number"`
+}
+
+// MarshalJSON writes the amount with the minor units of its currency.
+func (a CurrencyAmount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Currency string      `json:"currency"`
+		Amount   json.Number `json:"amount"`
+	}{a.Currency, json.Number(a.Amount.FormatCurrency(a.Currency))})
+}
+
+// OrderStatusCounts holds a number of orders per status.
+type OrderStatusCounts struct {
+	Pending    int `json:"pending" example:"1"`
+	Processing int `json:"processing" example:"2"`
+	Completed  int `json:"completed" example:"8"`
+	Cancelled  int `json:"cancelled" example:"1"`
+}
+
+// Add adds n orders in status to the counts.
+func (c *OrderStatusCounts) Add(status int, n int) {
+	switch status {
+	case OrderStatusPending:
+		c.Pending += n
+	case OrderStatusProcessing:
+		c.Processing += n
+	case OrderStatusCompleted:
+		c.Completed += n
+	case OrderStatusCancelled:
+		c.Cancelled += n
+	}
+}
@@ -0,0 +1,70 @@
+// internal/entities/payment.go
+package entities
+
+import "time"
+
+// Payment statuses.
+const (
+	// The provider holds the amount on the payment method
+	PaymentAuthorized = "authorized"
+	// The held amount was collected
+	PaymentCaptured = "captured"
+	// The collected amount was paid back, in part or in full
+	PaymentRefunded = "refunded"
+	// The provider declined the payment or it could not be completed
+	PaymentFailed = "failed"
+)
+
+// Payment webhook event types.
+const (
+	PaymentEventAuthorized = "payment.authorized"
+	PaymentEventCaptured   = "payment.captured"
+	PaymentEventRefunded   = "payment.refunded"
+	PaymentEventFailed     = "payment.failed"
+)
+
+// Payment is a payment of an order through a payment provider.
+type Payment struct {
+	// The UUID of the payment
+	// example: 2c9a6f4e-1b7d-4e3a-8f5c-6d0e9b1a2c3d
+	ID string `json:"id" example:"2c9a6f4e-1b7d-4e3a-8f5c-6d0e9b1a2c3d"`
+	// The UUID of the paid order
+	// example: 6204037c-30e6-408b-8aaa-dd8219860b4b
+	OrderID string `json:"order_id" example:"6204037c-30e6-408b-8aaa-dd8219860b4b"`
+	// The payment provider and its reference for the payment
+	// example: fake
+	Provider    string `json:"provider" example:"fake"`
+	ProviderRef string `json:"provider_ref" example:"fake_7f3e2a1b"`
+	// The status of the payment (authorized, captured, refunded, failed)
+	// example: captured
+	Status string `json:"status" example:"captured"`
+	// The ISO 4217 currency of the amounts
+	// example: USD
+	Currency string `json:"currency" example:"USD"`
+	// The amount held, collected and paid back
+	AuthorizedAmount Money `json:"authorized_amount" example:"100.00" swaggertype:"number"`
+	CapturedAmount   Money `json:"captured_amount" example:"100.00" swaggertype:"number"`
+	RefundedAmount   Money `json:"refunded_amount" example:"0.00" swaggertype:"number"`
+	// The amount of the failed attempt, and why it failed
+	FailedAmount  Money     `json:"failed_amount" example:"0.00" swaggertype:"number"`
+	FailureReason string    `json:"failure_reason,omitempty" example:"card declined"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PaymentEvent is a verified callback of the payment provider.
+type PaymentEvent struct {
+	// The provider event ID, each event is applied once
+	ID string `json:"id"`
+	// One of the PaymentEvent types
+	Type        string `json:"type"`
+	ProviderRef string `json:"provider_ref"`
+	Amount      Money  `json:"amount"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// PaymentURI identifies a payment of an order in a request path.
+type PaymentURI struct {
+	ID        string `uri:"id" binding:"required" example:"6204037c-30e6-408b-8aaa-dd8219860b4b" minLength:"36"`
+	PaymentID string `uri:"paymentId" binding:"required" example:"2c9a6f4e-1b7d-4e3a-8f5c-6d0e9b1a2c3d" minLength:"36"`
+}
@@ -0,0 +1,78 @@
+// internal/entities/privacy.go
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// UserDataExport is the bundle of the data tied to a user, for privacy requests.
+type UserDataExport struct {
+	// The UUID of the user
+	UserID string `json:"user_id" example:"451fa817-41f4-40cf-8dc2-c9f22aa98a4f"`
+	// The date and time the bundle was produced
+	GeneratedAt time.Time `json:"generated_at" example:"2024-07-01T12:00:00Z"`
+	// The orders of the user, deleted and archived ones included, oldest first
+	Orders []UserDataOrder `json:"orders"`
+}
+
+// UserDataOrder is an order of a user data bundle, with its line items, addresses and history.
+type UserDataOrder struct {
+	Order
+	// The date and time the order was deleted, empty for orders that are not deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty" example:"2025-01-01T12:00:00Z"`
+	// The recorded changes of the order, oldest first
+	History []OrderHistory `json:"history"`
+}
+
+// MarshalJSON inlines the fields of the order, whose own MarshalJSON would otherwise hide the other fields.
+func (o UserDataOrder) MarshalJSON() ([]byte, error) {
+	order, err := json.Marshal(o.Order)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := json.Marshal(struct {
+		DeletedAt *time.Time     `json:"deleted_at,omitempty"`
+		History   []OrderHistory `json:"history"`
+	}{o.DeletedAt, o.History})
+	if err != nil {
+		return nil, err
+	}
+	// Both are JSON objects, the order one is never empty
+	return append(append(order[:len(order)-1], ','), extra[1:]...), nil
+}
+
+// ErasureRequest is the body of a user data erasure.
+type ErasureRequest struct {
+	// Why the data is erased, e.g. the reference of the privacy request
+	// example: Privacy request #1234
+	Reason string `json:"reason" binding:"required,max=500" example:"Privacy request #1234"`
+}
+
+// ErasureRecord is an entry of the erasure audit log. The log keeps a hash of the user ID,
+// so that an erasure can be looked up by user without keeping the ID itself.
+type ErasureRecord struct {
+	// The UUID of the entry
+	ID string `json:"id" example:"2f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2"`
+	// The SHA-256 hash of the user ID, see ErasureSubjectHash
+	SubjectHash string `json:"subject_hash" example:"5f0c0d1b8f4c3a0e9f5e1b4c7d2a6e8f0b3c5d7e9a1b2c4d6e8f0a2b4c6d8e0f"`
+	// The number of orders given a pseudonymous user ID
+	Orders int `json:"orders" example:"12"`
+	// The number of addresses wiped
+	Addresses int `json:"addresses" example:"8"`
+	// Why the data was erased
+	Reason string `json:"reason" example:"Privacy request #1234"`
+	// The UUID of the admin who erased the data
+	ActorID string `json:"actor_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	// The date and time of the erasure
+	CreatedAt time.Time `json:"created_at" example:"2024-07-01T12:00:00Z"`
+}
+
+// ErasureSubjectHash returns the hash a user ID is recorded under in the erasure audit log.
+func ErasureSubjectHash(userID string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(userID)))
+	return hex.EncodeToString(sum[:])
+}
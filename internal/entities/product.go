@@ -0,0 +1,21 @@
+// internal/entities/product.go
+package entities
+
+// Product is the catalog view of a product, as returned by the product service.
+type Product struct {
+	// The UUID of the product
+	// example: 063d0ff7-e17e-4957-8d92-a988caeda8a1
+	ID string `json:"id" example:"063d0ff7-e17e-4957-8d92-a988caeda8a1" minLength:"36"`
+	// The product name
+	// example: Coffee mug
+	Name string `json:"name" example:"Coffee mug"`
+	// The current unit price
+	// example: 12.50
+	Price Money `json:"price" example:"12.50" swaggertype:"number"`
+	// The ISO 4217 currency of the price
+	// example: USD
+	Currency string `json:"currency" example:"USD" minLength:"3" maxLength:"3"`
+	// Inactive products cannot be ordered
+	// example: true
+	Active bool `json:"active" example:"true"`
+}
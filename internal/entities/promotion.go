@@ -0,0 +1,89 @@
+// internal/entities/promotion.go
+package entities
+
+import "time"
+
+// Promotion types.
+const (
+	// PromotionPercentage takes a percentage off the eligible lines.
+	PromotionPercentage = "percentage"
+	// PromotionFixedAmount takes a fixed amount off the eligible lines, spread in proportion to their totals.
+	PromotionFixedAmount = "fixed_amount"
+	// PromotionBuyXGetY gives GetQuantity free units for every BuyQuantity units bought of an eligible line.
+	PromotionBuyXGetY = "buy_x_get_y"
+)
+
+// IsValidPromotionType reports whether t is one of the known promotion types.
+func IsValidPromotionType(t string) bool {
+	return t == PromotionPercentage || t == PromotionFixedAmount || t == PromotionBuyXGetY
+}
+
+// Promotion represents a promotion redeemed with a coupon code at checkout.
+type Promotion struct {
+	// The UUID of the promotion
+	// example: 3f1c2a7e-8d4b-4e0f-9a55-1b2c3d4e5f60
+	ID string `json:"id" example:"3f1c2a7e-8d4b-4e0f-9a55-1b2c3d4e5f60" minLength:"36"`
+	// The coupon code, case insensitive and stored upper case
+	// example: SUMMER25
+	// required: true
+	Code string `json:"code" example:"SUMMER25" minLength:"3" maxLength:"32"`
+	// The promotion type (percentage, fixed_amount or buy_x_get_y)
+	// example: percentage
+	// required: true
+	Type string `json:"type" example:"percentage" enums:"percentage,fixed_amount,buy_x_get_y"`
+	// The percentage taken off, for percentage promotions
+	// example: 25
+	Percent int `json:"percent,omitempty" example:"25" format:"int32" minimum:"1" maximum:"100"`
+	// The amount taken off, for fixed_amount promotions
+	// example: 10.00
+	AmountOff Money `json:"amount_off" example:"10.00" swaggertype:"number"`
+	// The number of units to buy and the number of units given for free, for buy_x_get_y promotions
+	// example: 2
+	BuyQuantity int `json:"buy_quantity,omitempty" example:"2" format:"int32" minimum:"1"`
+	// example: 1
+	GetQuantity int `json:"get_quantity,omitempty" example:"1" format:"int32" minimum:"1"`
+	// Restricts the promotion to the lines of a single product, when set
+	// example: 063d0ff7-e17e-4957-8d92-a988caeda8a1
+	ProductID string `json:"product_id,omitempty" example:"063d0ff7-e17e-4957-8d92-a988caeda8a1" minLength:"36"`
+	// The ISO 4217 currency of AmountOff and MinOrderValue, orders in other currencies are not eligible.
+	// Required for fixed_amount promotions and promotions with a minimum order value.
+	// example: USD
+	Currency string `json:"currency,omitempty" example:"USD" minLength:"3" maxLength:"3"`
+	// The minimum order subtotal
+	// example: 50.00
+	MinOrderValue Money `json:"min_order_value" example:"50.00" swaggertype:"number"`
+	// The promotion is valid from StartsAt (inclusive) until EndsAt (exclusive), both optional
+	// example: 2025-06-01T00:00:00Z
+	StartsAt *time.Time `json:"starts_at,omitempty" example:"2025-06-01T00:00:00Z"`
+	// example: 2025-09-01T00:00:00Z
+	EndsAt *time.Time `json:"ends_at,omitempty" example:"2025-09-01T00:00:00Z"`
+	// The maximum number of redemptions overall and per user, zero means unlimited
+	// example: 1000
+	MaxRedemptions int `json:"max_redemptions" example:"1000" format:"int32" minimum:"0"`
+	// example: 1
+	MaxRedemptionsPerUser int `json:"max_redemptions_per_user" example:"1" format:"int32" minimum:"0"`
+	// The number of times the promotion was redeemed
+	// example: 12
+	Redemptions int `json:"redemptions" example:"12" format:"int32" readonly:"true"`
+	// Inactive promotions cannot be redeemed
+	// example: true
+	Active bool `json:"active" example:"true"`
+	// The date and time the promotion was created
+	CreatedAt time.Time `json:"created_at" readonly:"true"`
+	// The date and time the promotion was last updated
+	UpdatedAt time.Time `json:"updated_at" readonly:"true"`
+}
+
+// IsValidAt reports whether the promotion is active and within its validity window at t.
+func (p *Promotion) IsValidAt(t time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
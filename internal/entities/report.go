@@ -0,0 +1,75 @@
+// internal/entities/report.go
+package entities
+
+import "time"
+
+// Report intervals, the orders of a period are grouped by the start of the period.
+// Weeks start on Monday.
+const (
+	ReportDay   = "day"
+	ReportWeek  = "week"
+	ReportMonth = "month"
+)
+
+// Top products orders.
+const (
+	ReportByQuantity = "quantity"
+	ReportByRevenue  = "revenue"
+)
+
+// IsValidReportInterval checks that an interval is one of the report intervals.
+func IsValidReportInterval(interval string) bool {
+	return interval == ReportDay || interval == ReportWeek || interval == ReportMonth
+}
+
+// ReportFilter selects the orders a report is computed over.
+type ReportFilter struct {
+	// Orders created at or after this time
+	From time.Time
+	// Orders created before this time
+	To time.Time
+	// The length of the periods of an orders report
+	Interval string
+	// The number of products of a top products report
+	Limit int
+	// What the top products are ranked by, ReportByQuantity or ReportByRevenue
+	SortBy string
+}
+
+// OrdersReportRow holds the orders placed in a currency during a period.
+// Cancelled orders are left out.
+type OrdersReportRow struct {
+	// The start of the period
+	Period time.Time `json:"period" example:"2024-07-01T00:00:00Z"`
+	// The ISO 4217 currency of the orders
+	Currency string `json:"currency" example:"USD"`
+	// The number of orders
+	Orders int `json:"orders" example:"42"`
+	// The sum of the grand totals of the orders
+	Revenue Money `json:"revenue" swaggertype:"number" example:"4200.00"`
+	// The average grand total of an order
+	AverageOrderValue Money `json:"average_order_value" swaggertype:"number" example:"100.00"`
+}
+
+// StatusReportRow holds the number of orders in a status.
+type StatusReportRow struct {
+	// The status of the orders
+	Status int `json:"status" example:"1"`
+	// The number of orders
+	Orders int `json:"orders" example:"42"`
+}
+
+// ProductReportRow holds the sales of a product in a currency.
+// Cancelled orders are left out.
+type ProductReportRow struct {
+	// The UUID of the product
+	ProductID string `json:"product_id" example:"063d0ff7-e17e-4957-8d92-a988caeda8a1"`
+	// The ISO 4217 currency of the orders
+	Currency string `json:"currency" example:"USD"`
+	// The quantity sold
+	Quantity int `json:"quantity" example:"120"`
+	// The sum of the line totals after discounts, without tax
+	Revenue Money `json:"revenue" swaggertype:"number" example:"6000.00"`
+	// The number of orders with the product
+	Orders int `json:"orders" example:"35"`
+}
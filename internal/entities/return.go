@@ -0,0 +1,84 @@
+// internal/entities/return.go
+package entities
+
+import "time"
+
+// Return statuses, in the order a return goes through them.
+const (
+	ReturnRequested = "requested"
+	ReturnReceived  = "received"
+	ReturnRefunded  = "refunded"
+)
+
+// NextReturnStatus returns the status that follows status, or "" when the return is done.
+func NextReturnStatus(status string) string {
+	switch status {
+	case ReturnRequested:
+		return ReturnReceived
+	case ReturnReceived:
+		return ReturnRefunded
+	default:
+		return ""
+	}
+}
+
+// Return is a return authorization for some of the line items of a completed order.
+type Return struct {
+	// The UUID of the return
+	// example: 5d2b8e1a-3c4f-4a6b-9d7e-8f0a1b2c3d4e
+	ID string `json:"id" example:"5d2b8e1a-3c4f-4a6b-9d7e-8f0a1b2c3d4e"`
+	// The UUID of the order
+	OrderID string `json:"order_id" example:"6204037c-30e6-408b-8aaa-dd8219860b4b"`
+	// The status of the return (requested, received, refunded)
+	// example: requested
+	Status string `json:"status" example:"requested"`
+	// Why the items are returned
+	// example: Wrong size
+	Reason string `json:"reason,omitempty" example:"Wrong size"`
+	// The amount refunded for the returned items
+	// example: 25.50
+	RefundAmount Money `json:"refund_amount" example:"25.50" swaggertype:"number"`
+	// The returned line items
+	Items     []ReturnItem `json:"items"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// ReturnItem is a returned quantity of a line item.
+type ReturnItem struct {
+	// The UUID of the returned line item
+	OrderDetailID string `json:"order_detail_id" example:"9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"`
+	// The returned quantity
+	// example: 1
+	Quantity int `json:"quantity" example:"1"`
+	// The amount refunded for the quantity: its share of the line total after discount, with tax
+	// example: 25.50
+	RefundAmount Money `json:"refund_amount" example:"25.50" swaggertype:"number"`
+}
+
+// ReturnRequest is the body of a return request.
+type ReturnRequest struct {
+	// Why the items are returned
+	// example: Wrong size
+	Reason string `json:"reason" example:"Wrong size"`
+	// The line items and quantities to return
+	Items []ReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// ReturnItemRequest is a line item quantity to return.
+type ReturnItemRequest struct {
+	OrderDetailID string `json:"order_detail_id" binding:"required" example:"9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"`
+	Quantity      int    `json:"quantity" binding:"required,min=1" example:"1"`
+}
+
+// ReturnStatusRequest moves a return to its next status.
+type ReturnStatusRequest struct {
+	// example: received
+	Status string `json:"status" binding:"required" example:"received"`
+}
+
+// ReturnURI identifies a return of an order in a request path.
+type ReturnURI struct {
+	ID       string `uri:"id" binding:"required" example:"6204037c-30e6-408b-8aaa-dd8219860b4b" minLength:"36"`
+	ReturnID string `uri:"returnId" binding:"required" example:"5d2b8e1a-3c4f-4a6b-9d7e-8f0a1b2c3d4e" minLength:"36"`
+}
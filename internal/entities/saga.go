@@ -0,0 +1,46 @@
+// internal/entities/saga.go
+package entities
+
+import "time"
+
+// SagaState is the step an order saga has reached.
+type SagaState string
+
+// Order saga states. Compensated, released and confirmed are final.
+const (
+	// The saga is recorded, stock may or may not be reserved yet
+	SagaStarted SagaState = "started"
+	// Stock is reserved, the order is not stored yet
+	SagaReserved SagaState = "reserved"
+	// The order is stored, the reservation waits for the order to complete or be cancelled
+	SagaOrderCreated SagaState = "order_created"
+	// The order could not be created and the reservation was released
+	SagaCompensated SagaState = "compensated"
+	// The order was cancelled and the reservation was released
+	SagaReleased SagaState = "released"
+	// The order completed and the reservation was confirmed
+	SagaConfirmed SagaState = "confirmed"
+)
+
+// IsFinal reports whether the saga has nothing left to do.
+func (s SagaState) IsFinal() bool {
+	return s == SagaCompensated || s == SagaReleased || s == SagaConfirmed
+}
+
+// StockItem is a quantity of a product held by an inventory reservation.
+type StockItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// OrderSaga tracks the inventory reservation of an order, so it can be resumed after a crash.
+// Its ID is also the reservation ID sent to the inventory service.
+type OrderSaga struct {
+	ID        string
+	OrderID   string // empty until the order is stored
+	State     SagaState
+	Items     []StockItem
+	Error     string // last failure, retried when the saga is resumed
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,72 @@
+// internal/entities/tenant.go
+package entities
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// AllTenants scopes repositories to the rows of every tenant.
+// It is only used by the background jobs and the payment provider callbacks, never taken from a token.
+const AllTenants = "*"
+
+// MaxPageSize is the largest page size a tenant can configure.
+const MaxPageSize = 100
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// IsValidTenantID reports whether id is a well-formed tenant ID: up to 63 lower-case letters, digits, dashes or underscores.
+func IsValidTenantID(id string) bool {
+	return tenantIDPattern.MatchString(id)
+}
+
+// TenantConfig overrides the settings of the service for one tenant.
+// Zero values keep the settings of the service.
+type TenantConfig struct {
+	// The number of orders per page
+	PageSize int `json:"page_size"`
+	// The ISO 4217 codes orders may be placed in, the first one is the default
+	AllowedCurrencies []string `json:"allowed_currencies"`
+	// The statuses an order may move to, by current status
+	StatusTransitions StatusTransitions `json:"status_transitions"`
+}
+
+// StatusTransitions lists the statuses an order may move to, by current status.
+// Statuses without an entry cannot be left. A nil StatusTransitions allows any change.
+type StatusTransitions map[int][]int
+
+// Validate checks the settings of a tenant configuration.
+func (t TenantConfig) Validate() error {
+	if t.PageSize < 0 || t.PageSize > MaxPageSize {
+		return fmt.Errorf("page_size must be between 1 and %d", MaxPageSize)
+	}
+	for _, code := range t.AllowedCurrencies {
+		if !IsKnownCurrency(code) {
+			return fmt.Errorf("unknown currency %q", code)
+		}
+	}
+	for from, to := range t.StatusTransitions {
+		if !IsValidOrderStatus(from) {
+			return fmt.Errorf("invalid status %d", from)
+		}
+		for _, status := range to {
+			if !IsValidOrderStatus(status) {
+				return fmt.Errorf("invalid status %d", status)
+			}
+		}
+	}
+	return nil
+}
+
+// Allows reports whether an order may move from one status to another.
+func (t StatusTransitions) Allows(from int, to int) bool {
+	if t == nil || from == to {
+		return true
+	}
+	for _, status := range t[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
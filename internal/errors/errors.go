@@ -1 +1,67 @@
-package errors
\ No newline at end of file
+// internal/errors/errors.go
+package errors
+
+import "errors"
+
+var (
+	// ErrBulkLimitExceeded is returned when a bulk request carries more items than allowed.
+	ErrBulkLimitExceeded = errors.New("bulk request exceeds the maximum number of items")
+	// ErrCatalogUnavailable is returned when the product catalog cannot be reached.
+	ErrCatalogUnavailable = errors.New("product catalog unavailable")
+	// ErrEmptyBulkRequest is returned when a bulk request carries no items.
+	ErrEmptyBulkRequest = errors.New("bulk request contains no items")
+	// ErrInvalidRequest is returned when a request fails validation.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrInvalidStatus is returned when an order status is outside the known range.
+	ErrInvalidStatus = errors.New("invalid order status")
+	// ErrInvalidSignature is returned when a webhook payload does not carry a valid signature.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrItemNotFound is returned when a line item does not exist in the order.
+	ErrItemNotFound = errors.New("order item not found")
+	// ErrItemsLocked is returned when changing the line items of an order whose stock is reserved or that redeemed a promotion.
+	ErrItemsLocked = errors.New("line items of the order cannot be changed")
+	// ErrJobRunning is returned when a background job is already running, possibly on another replica.
+	ErrJobRunning = errors.New("job is already running")
+	// ErrLastItem is returned when removing the only line item of an order.
+	ErrLastItem = errors.New("an order must keep at least one line item")
+	// ErrOrderNotFound is returned when an order does not exist.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderNotCompleted is returned when returning items of an order that is not completed.
+	ErrOrderNotCompleted = errors.New("order is not completed")
+	// ErrOrderNotFulfillable is returned when setting the shipment of an order that is not processing or completed.
+	ErrOrderNotFulfillable = errors.New("order is not processing or completed")
+	// ErrOrderOpen is returned when deleting an order that is still pending or processing.
+	ErrOrderOpen = errors.New("order is still pending or processing")
+	// ErrOrderNotPending is returned when changing the line items of an order that is no longer pending.
+	ErrOrderNotPending = errors.New("order is no longer pending")
+	// ErrOutOfStock is returned when the inventory cannot reserve the stock of an order.
+	ErrOutOfStock = errors.New("insufficient stock")
+	// ErrPaymentDeclined is returned when the payment provider declines a payment.
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrPaymentExists is returned when starting a payment for an order that already has an open or captured payment.
+	ErrPaymentExists = errors.New("order already has a payment")
+	// ErrPaymentNotFound is returned when a payment does not exist.
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrPaymentState is returned when a payment is not in a state that allows the change.
+	ErrPaymentState = errors.New("payment cannot be changed in its current state")
+	// ErrPromotionCodeTaken is returned when creating a promotion with a code that is already in use.
+	ErrPromotionCodeTaken = errors.New("promotion code already exists")
+	// ErrPromotionInUse is returned when deleting a promotion that was already redeemed.
+	ErrPromotionInUse = errors.New("promotion was redeemed and can only be deactivated")
+	// ErrPromotionLimitReached is returned when a promotion reached its overall or per-user redemption limit.
+	ErrPromotionLimitReached = errors.New("promotion redemption limit reached")
+	// ErrPromotionNotFound is returned when a promotion does not exist.
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrReturnNotFound is returned when a return does not exist.
+	ErrReturnNotFound = errors.New("return not found")
+	// ErrReturnState is returned when a return cannot move to the requested status.
+	ErrReturnState = errors.New("return cannot move to this status")
+	// ErrSagaNotFound is returned when an order has no inventory saga.
+	ErrSagaNotFound = errors.New("order saga not found")
+	// ErrStatusTransition is returned when the status rules of the tenant do not allow the status change.
+	ErrStatusTransition = errors.New("order status change not allowed")
+	// ErrTenantRequired is returned when a repository is used without a tenant.
+	ErrTenantRequired = errors.New("tenant required")
+	// ErrVersionMismatch is returned when the order was changed since the client last read it.
+	ErrVersionMismatch = errors.New("order version does not match")
+)
@@ -0,0 +1,68 @@
+// usecases/catalog.go
+package usecases
+
+import (
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// ProductCatalog looks up products in the product service.
+type ProductCatalog interface {
+	// GetProducts returns the products with the given IDs, keyed by ID. Unknown products are left out,
+	// a catalog that cannot be reached returns an error wrapping apperrors.ErrCatalogUnavailable.
+	GetProducts(ids []string) (map[string]*entities.Product, error)
+}
+
+// lookupProducts fetches the catalog entries of the products of the line items, or nil when no catalog is set.
+func (uc *OrderUsecase) lookupProducts(details ...entities.OrderDetail) (map[string]*entities.Product, error) {
+	if uc.Catalog == nil || len(details) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(details))
+	seen := make(map[string]bool, len(details))
+	for _, detail := range details {
+		if !seen[detail.ProductID] {
+			seen[detail.ProductID] = true
+			ids = append(ids, detail.ProductID)
+		}
+	}
+	return uc.Catalog.GetProducts(ids)
+}
+
+// checkProducts makes sure every line item is for an active catalog product at its current price.
+func (uc *OrderUsecase) checkProducts(currency string, details ...entities.OrderDetail) error {
+	products, err := uc.lookupProducts(details...)
+	if err != nil {
+		return err
+	}
+	if err := matchCatalog(products, currency, details); err != nil {
+		return fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	return nil
+}
+
+// matchCatalog compares the line items of an order in the currency with the catalog products.
+// A nil products map means there is no catalog to compare with.
+func matchCatalog(products map[string]*entities.Product, currency string, details []entities.OrderDetail) error {
+	if products == nil {
+		return nil
+	}
+	for _, detail := range details {
+		product, ok := products[detail.ProductID]
+		if !ok {
+			return fmt.Errorf("unknown product %s", detail.ProductID)
+		}
+		if !product.Active {
+			return fmt.Errorf("product %s is not available", detail.ProductID)
+		}
+		if product.Currency != currency {
+			return fmt.Errorf("product %s is not sold in %s", detail.ProductID, currency)
+		}
+		if product.Price != detail.UnitPrice {
+			return fmt.Errorf("unit price of product %s is %s, not %s", detail.ProductID, product.Price.FormatCurrency(currency), detail.UnitPrice.FormatCurrency(currency))
+		}
+	}
+	return nil
+}
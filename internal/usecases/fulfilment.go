@@ -0,0 +1,138 @@
+// usecases/fulfilment.go
+package usecases
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// FulfilmentRepository stores the delivery method, addresses and shipment of orders.
+// The delivery method and addresses are written together with the order.
+type FulfilmentRepository interface {
+	GetFulfilment(orderID string) (*entities.Fulfilment, error)
+	// SetFulfilment sets the shipment of an order that is processing or completed and bumps the order version.
+	SetFulfilment(orderID string, req *entities.FulfilmentRequest, expectedVersion int, actorID string) (*entities.Order, error)
+	// ForTenant returns the repository scoped to the rows of a tenant.
+	ForTenant(tenantID string) FulfilmentRepository
+}
+
+const (
+	maxAddressFieldLength  = 128
+	maxShipmentFieldLength = 64
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{5,19}$`)
+
+// SetFulfilment records the tracking number and carrier of an order once it is processing or completed.
+// A non-zero expectedVersion makes the change fail when the order was modified in the meantime.
+func (uc *OrderUsecase) SetFulfilment(orderID string, req *entities.FulfilmentRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	if uc.FulfilmentRepo == nil {
+		return nil, fmt.Errorf("%w: order fulfilment is not supported", apperrors.ErrInvalidRequest)
+	}
+	req.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
+	req.Carrier = strings.TrimSpace(req.Carrier)
+	if req.TrackingNumber == "" || len(req.TrackingNumber) > maxShipmentFieldLength {
+		return nil, fmt.Errorf("%w: tracking_number must be 1 to %d characters", apperrors.ErrInvalidRequest, maxShipmentFieldLength)
+	}
+	if req.Carrier == "" || len(req.Carrier) > maxShipmentFieldLength {
+		return nil, fmt.Errorf("%w: carrier must be 1 to %d characters", apperrors.ErrInvalidRequest, maxShipmentFieldLength)
+	}
+
+	order, err := uc.FulfilmentRepo.SetFulfilment(orderID, req, expectedVersion, actorID)
+	invalidateOrder(uc.OrderRepo, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.loadFulfilment(order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// loadFulfilment sets the delivery details of an order, they are not loaded without a FulfilmentRepo.
+func (uc *OrderUsecase) loadFulfilment(order *entities.Order) error {
+	if uc.FulfilmentRepo == nil {
+		return nil
+	}
+	fulfilment, err := uc.FulfilmentRepo.GetFulfilment(order.ID)
+	if err != nil || fulfilment == nil {
+		return err
+	}
+	order.DeliveryMethod = fulfilment.DeliveryMethod
+	order.ShippingAddress = fulfilment.ShippingAddress
+	order.BillingAddress = fulfilment.BillingAddress
+	order.TrackingNumber = fulfilment.TrackingNumber
+	order.Carrier = fulfilment.Carrier
+	return nil
+}
+
+// validateDelivery normalizes the delivery method and addresses of a new order and checks them.
+// Orders with a shipping address and no delivery method are delivered with standard delivery.
+func validateDelivery(orderRequest *entities.OrderRequest) error {
+	method := strings.ToLower(strings.TrimSpace(orderRequest.DeliveryMethod))
+	if method == "" && orderRequest.ShippingAddress != nil {
+		method = entities.DeliveryStandard
+	}
+	if method != "" && !entities.IsValidDeliveryMethod(method) {
+		return fmt.Errorf("invalid delivery method %q", orderRequest.DeliveryMethod)
+	}
+	orderRequest.DeliveryMethod = method
+
+	if orderRequest.ShippingAddress == nil && entities.RequiresShippingAddress(method) {
+		return fmt.Errorf("%s delivery requires a shipping address", method)
+	}
+	if orderRequest.ShippingAddress != nil {
+		if method == entities.DeliveryPickup {
+			return fmt.Errorf("pickup orders have no shipping address")
+		}
+		if err := validateAddress(orderRequest.ShippingAddress); err != nil {
+			return fmt.Errorf("shipping_address: %v", err)
+		}
+	}
+	if orderRequest.BillingAddress != nil {
+		if err := validateAddress(orderRequest.BillingAddress); err != nil {
+			return fmt.Errorf("billing_address: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateAddress trims the fields of an address, upper-cases its country and postal code
+// and checks them against the address format of the country.
+func validateAddress(a *entities.Address) error {
+	fields := []*string{&a.Name, &a.Line1, &a.Line2, &a.City, &a.Region, &a.PostalCode, &a.Country, &a.Phone}
+	for _, field := range fields {
+		*field = strings.TrimSpace(*field)
+		if len(*field) > maxAddressFieldLength {
+			return fmt.Errorf("fields must not exceed %d characters", maxAddressFieldLength)
+		}
+	}
+	a.Country = strings.ToUpper(a.Country)
+	a.PostalCode = strings.ToUpper(a.PostalCode)
+
+	if a.Name == "" || a.Line1 == "" || a.City == "" {
+		return fmt.Errorf("name, line1 and city are required")
+	}
+	if !entities.IsKnownCountry(a.Country) {
+		return fmt.Errorf("unsupported country %q", a.Country)
+	}
+	if entities.HasPostalCodes(a.Country) {
+		if !entities.IsValidPostalCode(a.Country, a.PostalCode) {
+			return fmt.Errorf("invalid postal code %q for %s", a.PostalCode, a.Country)
+		}
+	} else {
+		// The country has no postal codes, whatever was sent is dropped
+		a.PostalCode = ""
+	}
+	if a.Region == "" && entities.RequiresRegion(a.Country) {
+		return fmt.Errorf("region is required for %s addresses", a.Country)
+	}
+	if a.Phone != "" && !phonePattern.MatchString(a.Phone) {
+		return fmt.Errorf("invalid phone number %q", a.Phone)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// usecases/inventory.go
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// InventoryService holds stock for orders. All calls are idempotent for a reservation ID,
+// so a saga step can safely be retried after a crash.
+type InventoryService interface {
+	// Reserve holds the stock of the items, or fails with apperrors.ErrOutOfStock and holds nothing.
+	Reserve(reservationID string, items []entities.StockItem) error
+	// Release returns the held stock, releasing an unknown reservation is not an error.
+	Release(reservationID string) error
+	// Confirm turns the held stock into a sale.
+	Confirm(reservationID string) error
+}
+
+// SagaRepository persists the state of order sagas.
+type SagaRepository interface {
+	Create(saga *entities.OrderSaga) (string, error)
+	GetByID(id string) (*entities.OrderSaga, error)
+	GetByOrderID(orderID string) (*entities.OrderSaga, error)
+	// GetResumable returns the sagas last changed before the given time that have a step left to run:
+	// sagas that never got their order stored and sagas whose order was completed or cancelled.
+	GetResumable(before time.Time, limit int) ([]*entities.OrderSaga, error)
+	// UpdateState moves a saga to a state, errMsg records why its last step failed.
+	UpdateState(id string, state entities.SagaState, errMsg string) error
+	// ForTenant returns the repository scoped to the rows of a tenant.
+	ForTenant(tenantID string) SagaRepository
+}
+
+// Maximum number of sagas resumed by a single ResumeSagas call.
+const resumeBatchSize = 100
+
+// createWithReservation stores an order once its stock is reserved, as a saga:
+// started -> reserved -> order_created. When a step fails the reservation is released (compensated).
+func (uc *OrderUsecase) createWithReservation(orderRequest *entities.OrderRequest) (string, error) {
+	saga := &entities.OrderSaga{State: entities.SagaStarted, Items: stockItems(orderRequest.OrderDetails)}
+	sagaID, err := uc.SagaRepo.Create(saga)
+	if err != nil {
+		return "", err
+	}
+	saga.ID = sagaID
+
+	if err := uc.Inventory.Reserve(saga.ID, saga.Items); err != nil {
+		uc.compensate(saga, err)
+		return "", err
+	}
+	if err := uc.SagaRepo.UpdateState(saga.ID, entities.SagaReserved, ""); err != nil {
+		uc.compensate(saga, err)
+		return "", err
+	}
+	saga.State = entities.SagaReserved
+
+	// The repository moves the saga to order_created in the transaction that stores the order
+	orderRequest.SagaID = saga.ID
+	id, err := uc.OrderRepo.Create(orderRequest)
+	if err != nil {
+		uc.compensate(saga, err)
+		return "", err
+	}
+	return id, nil
+}
+
+// compensate releases the reservation of a saga whose order could not be created.
+// When the release fails the saga keeps its state and is retried by ResumeSagas.
+func (uc *OrderUsecase) compensate(saga *entities.OrderSaga, cause error) error {
+	// The order may have been stored after all, e.g. when only its commit acknowledgement was lost
+	current, err := uc.SagaRepo.GetByID(saga.ID)
+	if err != nil {
+		log.Printf("saga %s: %v", saga.ID, err)
+		return err
+	}
+	if current.State != entities.SagaStarted && current.State != entities.SagaReserved {
+		return nil
+	}
+
+	if err := uc.Inventory.Release(saga.ID); err != nil {
+		log.Printf("saga %s: release failed: %v", saga.ID, err)
+		uc.SagaRepo.UpdateState(saga.ID, current.State, fmt.Sprintf("%v; release failed: %v", cause, err))
+		return err
+	}
+	return uc.SagaRepo.UpdateState(saga.ID, entities.SagaCompensated, cause.Error())
+}
+
+// settleReservation confirms the reservation of a completed order and releases the one of a cancelled order.
+// The status change is already stored, failures are left to ResumeSagas.
+func (uc *OrderUsecase) settleReservation(orderID string, status int) {
+	if uc.Inventory == nil || (status != entities.OrderStatusCompleted && status != entities.OrderStatusCancelled) {
+		return
+	}
+	saga, err := uc.SagaRepo.GetByOrderID(orderID)
+	if errors.Is(err, apperrors.ErrSagaNotFound) {
+		// Orders created before the saga was introduced, or without inventory
+		return
+	}
+	if err != nil {
+		log.Printf("order %s: %v", orderID, err)
+		return
+	}
+	if err := uc.finishSaga(saga, status); err != nil {
+		log.Printf("saga %s: %v", saga.ID, err)
+	}
+}
+
+// finishSaga runs the last step of a saga whose order reached the given status.
+func (uc *OrderUsecase) finishSaga(saga *entities.OrderSaga, status int) error {
+	if saga.State != entities.SagaOrderCreated {
+		return nil
+	}
+
+	var err error
+	var next entities.SagaState
+	switch status {
+	case entities.OrderStatusCancelled:
+		err, next = uc.Inventory.Release(saga.ID), entities.SagaReleased
+	case entities.OrderStatusCompleted:
+		err, next = uc.Inventory.Confirm(saga.ID), entities.SagaConfirmed
+	default:
+		return nil
+	}
+	if err != nil {
+		uc.SagaRepo.UpdateState(saga.ID, saga.State, err.Error())
+		return err
+	}
+	return uc.SagaRepo.UpdateState(saga.ID, next, "")
+}
+
+// ResumeSagas runs the remaining steps of the sagas left behind by a crash or a failed step.
+// Only sagas unchanged for olderThan are resumed, so requests still in flight are left alone.
+// It returns the number of sagas that were resumed successfully.
+func (uc *OrderUsecase) ResumeSagas(olderThan time.Duration) (int, error) {
+	if uc.Inventory == nil {
+		return 0, nil
+	}
+	sagas, err := uc.SagaRepo.GetResumable(time.Now().Add(-olderThan), resumeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for _, saga := range sagas {
+		var err error
+		switch saga.State {
+		case entities.SagaStarted, entities.SagaReserved:
+			err = uc.compensate(saga, errors.New("order creation was interrupted"))
+		case entities.SagaOrderCreated:
+			status := entities.OrderStatusCancelled // the order was deleted
+			if saga.OrderID != "" {
+				var order *entities.Order
+				if order, err = uc.OrderRepo.GetByID(saga.OrderID); err == nil && order.ID != "" {
+					status = order.Status
+				}
+			}
+			if err == nil {
+				err = uc.finishSaga(saga, status)
+			}
+		}
+		if err != nil {
+			log.Printf("saga %s: resume failed: %v", saga.ID, err)
+			continue
+		}
+		resumed++
+	}
+	return resumed, nil
+}
+
+// stockItems returns the quantity of each product of the line items.
+func stockItems(details []entities.OrderDetail) []entities.StockItem {
+	var items []entities.StockItem
+	index := make(map[string]int, len(details))
+	for _, detail := range details {
+		if i, ok := index[detail.ProductID]; ok {
+			items[i].Quantity += detail.Quantity
+			continue
+		}
+		index[detail.ProductID] = len(items)
+		items = append(items, entities.StockItem{ProductID: detail.ProductID, Quantity: detail.Quantity})
+	}
+	return items
+}
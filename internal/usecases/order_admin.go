@@ -0,0 +1,45 @@
+// usecases/order_admin.go
+package usecases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+const maxForceReasonLength = 500
+
+// SearchOrders returns a page of the orders of all users matching the filter, for support staff.
+func (uc *OrderUsecase) SearchOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	if filter.Currency != "" && !uc.isAllowedCurrency(filter.Currency) {
+		return nil, fmt.Errorf("%w: currency %q is not supported", apperrors.ErrInvalidRequest, filter.Currency)
+	}
+	filter.PageSize = uc.PageSize
+	return uc.OrderRepo.SearchOrders(page, filter)
+}
+
+// ForceStatus sets the status of an order bypassing the status rules, e.g. to fix an order stuck in a status.
+// The reason is required and recorded with the actor in the order history.
+// A non-zero expectedVersion makes the change fail when the order was modified in the meantime.
+func (uc *OrderUsecase) ForceStatus(id string, status int, reason string, expectedVersion int, actorID string) (*entities.Order, error) {
+	if !entities.IsValidOrderStatus(status) {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, apperrors.ErrInvalidStatus)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxForceReasonLength {
+		return nil, fmt.Errorf("%w: reason must be 1 to %d characters", apperrors.ErrInvalidRequest, maxForceReasonLength)
+	}
+	order, err := uc.OrderRepo.ForceStatus(id, status, reason, expectedVersion, actorID)
+	if err != nil {
+		return nil, err
+	}
+	uc.settleReservation(id, status)
+	return order, nil
+}
+
+// GetOrderHistory returns the recorded changes of an order, oldest first, soft deleted orders included.
+func (uc *OrderUsecase) GetOrderHistory(orderID string) ([]entities.OrderHistory, error) {
+	return uc.OrderRepo.GetOrderHistory(orderID)
+}
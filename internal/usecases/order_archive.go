@@ -0,0 +1,37 @@
+// usecases/order_archive.go
+package usecases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// Number of orders moved to the archive per transaction by ArchiveOrders.
+const archiveBatchSize = 500
+
+// DeleteOrder soft deletes a completed or cancelled order, ErrOrderOpen for other orders.
+// The order and its history are kept until it is archived, but it is no longer listed or found.
+// A non-zero expectedVersion makes the deletion fail when the order was modified in the meantime.
+func (uc *OrderUsecase) DeleteOrder(id string, expectedVersion int, actorID string) error {
+	return uc.OrderRepo.DeleteOrder(id, expectedVersion, actorID)
+}
+
+// ArchiveOrders moves the completed, cancelled and deleted orders created longer than ArchiveAfter ago to the archive.
+// It returns the number of archived orders, ErrJobRunning when another replica is archiving.
+func (uc *OrderUsecase) ArchiveOrders() (int, error) {
+	if uc.ArchiveAfter <= 0 {
+		return 0, fmt.Errorf("%w: archival of orders is disabled", apperrors.ErrInvalidRequest)
+	}
+	ids, err := uc.OrderRepo.ArchiveOrders(time.Now().Add(-uc.ArchiveAfter), archiveBatchSize)
+	// The batches committed before a failure stay archived
+	return len(ids), err
+}
+
+// GetArchivedByID returns an archived order with its line items.
+// Like GetByID, the order has no ID when there is no such order in the archive.
+func (uc *OrderUsecase) GetArchivedByID(id string) (*entities.Order, error) {
+	return uc.OrderRepo.GetArchivedByID(id)
+}
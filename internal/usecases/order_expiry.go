@@ -0,0 +1,28 @@
+// usecases/order_expiry.go
+package usecases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// Number of stale orders cancelled per transaction by CancelStaleOrders.
+const autoCancelBatchSize = 100
+
+// CancelStaleOrders cancels the orders left pending for longer than PendingTTL, and releases their stock.
+// It returns the number of cancelled orders, ErrJobRunning when another replica is sweeping.
+func (uc *OrderUsecase) CancelStaleOrders() (int, error) {
+	if uc.PendingTTL <= 0 {
+		return 0, fmt.Errorf("%w: auto-cancellation of pending orders is disabled", apperrors.ErrInvalidRequest)
+	}
+	reason := fmt.Sprintf("not paid within %s", uc.PendingTTL)
+	ids, err := uc.OrderRepo.CancelStalePending(time.Now().Add(-uc.PendingTTL), autoCancelBatchSize, reason)
+	// The batches committed before a failure stay cancelled
+	for _, id := range ids {
+		uc.settleReservation(id, entities.OrderStatusCancelled)
+	}
+	return len(ids), err
+}
@@ -2,32 +2,503 @@
 package usecases
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/pkg/utils"
 )
 
 type OrderRepository interface {
-	GetAllOrders(page int, userID string) ([]*entities.Order, error)
+	GetAllOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error)
 	GetByID(id string) (*entities.Order, error)
 	Create(orderRequest *entities.OrderRequest) (string, error)
-	UpdateStatus(id string, status int) (*entities.Order, error)
+	UpdateStatus(id string, status int, expectedVersion int, actorID string) (*entities.Order, error)
+	CreateBulk(orders []*entities.OrderRequest, atomic bool) ([]entities.BulkItemResult, error)
+	UpdateStatusBulk(updates []entities.StatusUpdate, atomic bool, actorID string) ([]entities.BulkItemResult, error)
+	ExportOrders(filter entities.OrderFilter, includeItems bool, fn func(*entities.Order) error) error
+	GetOrderDetails(orderID string) ([]entities.OrderDetail, error)
+	AddOrderItem(orderID string, item *entities.OrderItemRequest, expectedVersion int, actorID string) (*entities.Order, error)
+	UpdateOrderItem(orderID string, itemID string, update *entities.OrderItemUpdate, expectedVersion int, actorID string) (*entities.Order, error)
+	RemoveOrderItem(orderID string, itemID string, expectedVersion int, actorID string) (*entities.Order, error)
+	CancelStalePending(before time.Time, batchSize int, reason string) ([]string, error)
+	SearchOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error)
+	GetOrderSummary(filter entities.OrderFilter) (*entities.OrderSummary, error)
+	// ForceStatus sets the status of an order regardless of the status rules and records the reason in its history.
+	ForceStatus(id string, status int, reason string, expectedVersion int, actorID string) (*entities.Order, error)
+	// GetOrderHistory returns the history of an order, soft deleted or not, ErrOrderNotFound when there is no such order.
+	GetOrderHistory(orderID string) ([]entities.OrderHistory, error)
+	// DeleteOrder soft deletes a completed or cancelled order, it is kept until archived but no longer found.
+	DeleteOrder(id string, expectedVersion int, actorID string) error
+	// ArchiveOrders moves the finished and deleted orders created before before to the archive tables.
+	ArchiveOrders(before time.Time, batchSize int) ([]string, error)
+	GetArchivedByID(id string) (*entities.Order, error)
+	// ForTenant returns the repository scoped to the rows of a tenant.
+	ForTenant(tenantID string) OrderRepository
+}
+
+// OrderCache is implemented by the order repositories keeping orders in a cache.
+// The usecases changing an order through another repository drop its cached copy.
+type OrderCache interface {
+	Invalidate(ids ...string)
+}
+
+// invalidateOrder drops the cached copy of an order changed outside of the order repository, if repo caches orders.
+func invalidateOrder(repo OrderRepository, id string) {
+	if cache, ok := repo.(OrderCache); ok {
+		cache.Invalidate(id)
+	}
 }
 
 type OrderUsecase struct {
 	OrderRepo OrderRepository
+	// Maximum number of items accepted by a bulk request, zero means no limit.
+	BulkLimit int
+	// ISO 4217 codes orders may be placed in, the first one is the default.
+	// When empty, any known currency is accepted and entities.DefaultCurrency is the default.
+	AllowedCurrencies []string
+	// Computes the price breakdown of new orders and changed line items.
+	// When nil, FlatTaxCalculator without tax is used.
+	Pricing PricingCalculator
+	// Looks up the promotions of coupon codes, orders with a coupon code are rejected when nil.
+	PromotionRepo PromotionRepository
+	// Confirms the products and unit prices of new line items, they are not checked when nil.
+	Catalog ProductCatalog
+	// Reserves the stock of new orders, the saga state is kept in SagaRepo.
+	// Stock is not reserved when nil.
+	Inventory InventoryService
+	SagaRepo  SagaRepository
+	// Loads the returns shown on an order, they are not loaded when nil.
+	ReturnRepo ReturnRepository
+	// Loads and sets the delivery details of orders, they are not loaded when nil.
+	FulfilmentRepo FulfilmentRepository
+	// Orders left pending for longer are cancelled by CancelStaleOrders, zero disables it.
+	PendingTTL time.Duration
+	// Finished and deleted orders created longer ago are moved to the archive by ArchiveOrders, zero disables it.
+	ArchiveAfter time.Duration
+	// Number of orders per page, zero uses the page size of the repository.
+	PageSize int
+	// The status changes allowed, any change is allowed when nil.
+	StatusTransitions entities.StatusTransitions
+	// The settings overridden per tenant, applied by ForTenant.
+	Tenants map[string]entities.TenantConfig
 }
 
-func (uc *OrderUsecase) GetOrders(page int, userID string) ([]*entities.Order, error) {
-	return uc.OrderRepo.GetAllOrders(page, userID)
+func (uc *OrderUsecase) GetOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	if filter.Currency != "" && !uc.isAllowedCurrency(filter.Currency) {
+		return nil, fmt.Errorf("%w: currency %q is not supported", apperrors.ErrInvalidRequest, filter.Currency)
+	}
+	filter.PageSize = uc.PageSize
+	return uc.OrderRepo.GetAllOrders(page, filter)
 }
 
+// GetSummary returns the order count, spend per currency, last order date and counts by status
+// of the orders of filter.UserID, optionally created in the filter date range.
+func (uc *OrderUsecase) GetSummary(filter entities.OrderFilter) (*entities.OrderSummary, error) {
+	if filter.UserID == "" {
+		return nil, fmt.Errorf("%w: user id is required", apperrors.ErrInvalidRequest)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", apperrors.ErrInvalidRequest)
+	}
+	return uc.OrderRepo.GetOrderSummary(entities.OrderFilter{UserID: filter.UserID, From: filter.From, To: filter.To})
+}
+
+// GetByID returns an order with its delivery details, returns and refunded total.
 func (uc *OrderUsecase) GetByID(id string) (*entities.Order, error) {
-	return uc.OrderRepo.GetByID(id)
+	order, err := uc.OrderRepo.GetByID(id)
+	if err != nil || order == nil || order.ID == "" {
+		return order, err
+	}
+	if err := uc.loadFulfilment(order); err != nil {
+		return nil, err
+	}
+	if uc.ReturnRepo == nil {
+		return order, nil
+	}
+	order.Returns, err = uc.ReturnRepo.GetByOrderID(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, ret := range order.Returns {
+		if ret.Status == entities.ReturnRefunded {
+			order.RefundedTotal = order.RefundedTotal.Add(ret.RefundAmount)
+		}
+	}
+	return order, nil
 }
 
 func (uc *OrderUsecase) Create(orderRequest *entities.OrderRequest) (string, error) {
+	if err := uc.validateCurrency(orderRequest); err != nil {
+		return "", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	if err := validateDelivery(orderRequest); err != nil {
+		return "", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	if err := uc.checkProducts(orderRequest.Currency, orderRequest.OrderDetails...); err != nil {
+		return "", err
+	}
+	promotion, err := uc.findCoupon(orderRequest.CouponCode)
+	if err != nil {
+		return "", err
+	}
+	if err := uc.price(orderRequest, promotion); err != nil {
+		return "", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	if uc.Inventory != nil {
+		return uc.createWithReservation(orderRequest)
+	}
 	return uc.OrderRepo.Create(orderRequest)
 }
 
-func (uc *OrderUsecase) UpdateStatus(id string, status int) (*entities.Order, error) {
-	return uc.OrderRepo.UpdateStatus(id, status)
-}
\ No newline at end of file
+// UpdateStatus changes the status of an order, when the status rules allow it.
+// A non-zero expectedVersion makes the change fail when the order was modified in the meantime.
+func (uc *OrderUsecase) UpdateStatus(id string, status int, expectedVersion int, actorID string) (*entities.Order, error) {
+	if !entities.IsValidOrderStatus(status) {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, apperrors.ErrInvalidStatus)
+	}
+	expectedVersion, err := uc.checkTransition(id, status, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	order, err := uc.OrderRepo.UpdateStatus(id, status, expectedVersion, actorID)
+	if err != nil {
+		return nil, err
+	}
+	uc.settleReservation(id, status)
+	return order, nil
+}
+
+// CreateBulk creates many orders at once.
+// Invalid orders fail the whole request in atomic mode, otherwise they are reported per item
+// and only the valid orders are sent to the repository.
+// Bulk orders are imports of orders placed elsewhere, their stock is not reserved.
+func (uc *OrderUsecase) CreateBulk(req *entities.BulkOrderRequest) ([]entities.BulkItemResult, error) {
+	if err := uc.checkBulkSize(len(req.Orders)); err != nil {
+		return nil, err
+	}
+
+	// Look up the products of all orders at once
+	var details []entities.OrderDetail
+	for _, orderRequest := range req.Orders {
+		details = append(details, orderRequest.OrderDetails...)
+	}
+	products, err := uc.lookupProducts(details...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]entities.BulkItemResult, len(req.Orders))
+	valid := make([]*entities.OrderRequest, 0, len(req.Orders))
+	positions := make([]int, 0, len(req.Orders))
+	for i := range req.Orders {
+		if err := uc.validateOrderRequest(&req.Orders[i], products); err != nil {
+			if req.Atomic {
+				return nil, fmt.Errorf("%w: order %d: %v", apperrors.ErrInvalidRequest, i, err)
+			}
+			results[i] = entities.BulkItemResult{Index: i, Status: entities.BulkItemFailed, Error: err.Error()}
+			continue
+		}
+		valid = append(valid, &req.Orders[i])
+		positions = append(positions, i)
+	}
+
+	if len(valid) > 0 {
+		created, err := uc.OrderRepo.CreateBulk(valid, req.Atomic)
+		if err != nil {
+			return nil, err
+		}
+		for j, res := range created {
+			res.Index = positions[j]
+			results[positions[j]] = res
+		}
+	}
+	return results, nil
+}
+
+// UpdateStatusBulk changes the status of many orders at once, with the same
+// atomic and best-effort semantics as CreateBulk.
+func (uc *OrderUsecase) UpdateStatusBulk(req *entities.BulkStatusRequest, actorID string) ([]entities.BulkItemResult, error) {
+	if err := uc.checkBulkSize(len(req.Updates)); err != nil {
+		return nil, err
+	}
+
+	results := make([]entities.BulkItemResult, len(req.Updates))
+	valid := make([]entities.StatusUpdate, 0, len(req.Updates))
+	positions := make([]int, 0, len(req.Updates))
+	seen := make(map[string]bool, len(req.Updates))
+	for i, u := range req.Updates {
+		var err error
+		switch {
+		case !utils.IsValidUUID(u.ID):
+			err = fmt.Errorf("invalid order id %q", u.ID)
+		case !entities.IsValidOrderStatus(u.Status):
+			err = apperrors.ErrInvalidStatus
+		case seen[u.ID]:
+			err = fmt.Errorf("duplicate order id %s", u.ID)
+		default:
+			_, err = uc.checkTransition(u.ID, u.Status, 0)
+		}
+		if err != nil {
+			if req.Atomic {
+				return nil, fmt.Errorf("%w: update %d: %v", apperrors.ErrInvalidRequest, i, err)
+			}
+			results[i] = entities.BulkItemResult{Index: i, ID: u.ID, Status: entities.BulkItemFailed, Error: err.Error()}
+			continue
+		}
+		seen[u.ID] = true
+		valid = append(valid, u)
+		positions = append(positions, i)
+	}
+
+	if len(valid) > 0 {
+		updated, err := uc.OrderRepo.UpdateStatusBulk(valid, req.Atomic, actorID)
+		if err != nil {
+			return nil, err
+		}
+		for j, res := range updated {
+			res.Index = positions[j]
+			results[positions[j]] = res
+			if res.Status == entities.BulkItemSuccess {
+				uc.settleReservation(valid[j].ID, valid[j].Status)
+			}
+		}
+	}
+	return results, nil
+}
+
+// ExportOrders streams every order matching the filter to fn, optionally with its line items.
+func (uc *OrderUsecase) ExportOrders(filter entities.OrderFilter, includeItems bool, fn func(*entities.Order) error) error {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return fmt.Errorf("%w: the start of the date range must be before its end", apperrors.ErrInvalidRequest)
+	}
+	if filter.Status != 0 && !entities.IsValidOrderStatus(filter.Status) {
+		return fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, apperrors.ErrInvalidStatus)
+	}
+	if filter.Currency != "" && !uc.isAllowedCurrency(filter.Currency) {
+		return fmt.Errorf("%w: currency %q is not supported", apperrors.ErrInvalidRequest, filter.Currency)
+	}
+	return uc.OrderRepo.ExportOrders(filter, includeItems, fn)
+}
+
+// AddItem adds a line item to a pending order.
+// A non-zero expectedVersion makes the change fail when the order was modified in the meantime.
+func (uc *OrderUsecase) AddItem(orderID string, item *entities.OrderItemRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	if !utils.IsValidUUID(item.ProductID) {
+		return nil, fmt.Errorf("%w: invalid product id %q", apperrors.ErrInvalidRequest, item.ProductID)
+	}
+	if item.Quantity < 1 {
+		return nil, fmt.Errorf("%w: quantity must be at least 1", apperrors.ErrInvalidRequest)
+	}
+	if item.UnitPrice.IsNegative() {
+		return nil, fmt.Errorf("%w: unit price must not be negative", apperrors.ErrInvalidRequest)
+	}
+	order, err := uc.checkItemCurrency(orderID, item.Currency, item.UnitPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	line := entities.OrderDetail{ProductID: item.ProductID, Quantity: item.Quantity, UnitPrice: item.UnitPrice}
+	if err := uc.checkProducts(order.Currency, line); err != nil {
+		return nil, err
+	}
+	if err := uc.priceLine(order.Currency, &line); err != nil {
+		return nil, err
+	}
+	item.DiscountAmount, item.TaxAmount = line.DiscountAmount, line.TaxAmount
+	return uc.OrderRepo.AddOrderItem(orderID, item, expectedVersion, actorID)
+}
+
+// UpdateItem changes the quantity and/or unit price of a line item of a pending order.
+func (uc *OrderUsecase) UpdateItem(orderID string, itemID string, update *entities.OrderItemUpdate, expectedVersion int, actorID string) (*entities.Order, error) {
+	if update.Quantity == nil && update.UnitPrice == nil {
+		return nil, fmt.Errorf("%w: nothing to update", apperrors.ErrInvalidRequest)
+	}
+	if update.Quantity != nil && *update.Quantity < 1 {
+		return nil, fmt.Errorf("%w: quantity must be at least 1", apperrors.ErrInvalidRequest)
+	}
+	if update.UnitPrice != nil && update.UnitPrice.IsNegative() {
+		return nil, fmt.Errorf("%w: unit price must not be negative", apperrors.ErrInvalidRequest)
+	}
+
+	// The line is priced from its current values, pass expectedVersion to make sure they did not change since.
+	details, err := uc.OrderRepo.GetOrderDetails(orderID)
+	if err != nil {
+		return nil, err
+	}
+	var line *entities.OrderDetail
+	for i := range details {
+		if details[i].ID == itemID {
+			line = &details[i]
+		}
+	}
+	if line == nil {
+		return nil, apperrors.ErrItemNotFound
+	}
+	if update.Quantity != nil {
+		line.Quantity = *update.Quantity
+	}
+	if update.UnitPrice != nil {
+		line.UnitPrice = *update.UnitPrice
+	}
+
+	order, err := uc.checkItemCurrency(orderID, "", line.UnitPrice)
+	if err != nil {
+		return nil, err
+	}
+	// A changed unit price must be the current catalog price
+	if update.UnitPrice != nil {
+		if err := uc.checkProducts(order.Currency, *line); err != nil {
+			return nil, err
+		}
+	}
+	if err := uc.priceLine(order.Currency, line); err != nil {
+		return nil, err
+	}
+	update.DiscountAmount, update.TaxAmount = &line.DiscountAmount, &line.TaxAmount
+	return uc.OrderRepo.UpdateOrderItem(orderID, itemID, update, expectedVersion, actorID)
+}
+
+// RemoveItem removes a line item from a pending order.
+func (uc *OrderUsecase) RemoveItem(orderID string, itemID string, expectedVersion int, actorID string) (*entities.Order, error) {
+	return uc.OrderRepo.RemoveOrderItem(orderID, itemID, expectedVersion, actorID)
+}
+
+func (uc *OrderUsecase) checkBulkSize(n int) error {
+	if n == 0 {
+		return apperrors.ErrEmptyBulkRequest
+	}
+	if uc.BulkLimit > 0 && n > uc.BulkLimit {
+		return fmt.Errorf("%w (%d > %d)", apperrors.ErrBulkLimitExceeded, n, uc.BulkLimit)
+	}
+	return nil
+}
+
+// isAllowedCurrency reports whether orders may be placed in the currency.
+func (uc *OrderUsecase) isAllowedCurrency(code string) bool {
+	if len(uc.AllowedCurrencies) == 0 {
+		return entities.IsKnownCurrency(code)
+	}
+	for _, allowed := range uc.AllowedCurrencies {
+		if allowed == code {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCurrency defaults the currency of the order, checks it against the allow-list and makes
+// sure all line items share it and all amounts can be expressed in its minor units.
+func (uc *OrderUsecase) validateCurrency(orderRequest *entities.OrderRequest) error {
+	if orderRequest.Currency == "" {
+		orderRequest.Currency = entities.DefaultCurrency
+		if len(uc.AllowedCurrencies) > 0 {
+			orderRequest.Currency = uc.AllowedCurrencies[0]
+		}
+	}
+	orderRequest.Currency = strings.ToUpper(orderRequest.Currency)
+	if !uc.isAllowedCurrency(orderRequest.Currency) {
+		return fmt.Errorf("currency %q is not supported", orderRequest.Currency)
+	}
+	if !orderRequest.ShippingTotal.FitsCurrency(orderRequest.Currency) {
+		return fmt.Errorf("shipping %s has more decimal places than %s allows", orderRequest.ShippingTotal, orderRequest.Currency)
+	}
+	for _, detail := range orderRequest.OrderDetails {
+		if detail.Currency != "" && !strings.EqualFold(detail.Currency, orderRequest.Currency) {
+			return fmt.Errorf("line item currency %s does not match the order currency %s", detail.Currency, orderRequest.Currency)
+		}
+		if !detail.UnitPrice.FitsCurrency(orderRequest.Currency) {
+			return fmt.Errorf("unit price %s has more decimal places than %s allows", detail.UnitPrice, orderRequest.Currency)
+		}
+	}
+	return nil
+}
+
+// checkItemCurrency makes sure a line item amount (and currency, when given) suits the order currency.
+// It returns the order.
+func (uc *OrderUsecase) checkItemCurrency(orderID string, currency string, unitPrice entities.Money) (*entities.Order, error) {
+	order, err := uc.OrderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.ID == "" {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	if currency != "" && !strings.EqualFold(currency, order.Currency) {
+		return nil, fmt.Errorf("%w: line item currency %s does not match the order currency %s", apperrors.ErrInvalidRequest, currency, order.Currency)
+	}
+	if !unitPrice.FitsCurrency(order.Currency) {
+		return nil, fmt.Errorf("%w: unit price %s has more decimal places than %s allows", apperrors.ErrInvalidRequest, unitPrice, order.Currency)
+	}
+	return order, nil
+}
+
+func (uc *OrderUsecase) pricing() PricingCalculator {
+	if uc.Pricing == nil {
+		return FlatTaxCalculator{}
+	}
+	return uc.Pricing
+}
+
+// price computes the price breakdown of a new order, with the discounts of the promotion when set.
+// Discounts and taxes sent by the client are ignored.
+func (uc *OrderUsecase) price(orderRequest *entities.OrderRequest, promotion *entities.Promotion) error {
+	for i := range orderRequest.OrderDetails {
+		orderRequest.OrderDetails[i].DiscountAmount = entities.Money{}
+		orderRequest.OrderDetails[i].TaxAmount = entities.Money{}
+	}
+	orderRequest.PromotionID = ""
+	if promotion != nil {
+		if err := applyPromotion(promotion, orderRequest, time.Now()); err != nil {
+			return err
+		}
+	}
+	return uc.pricing().Price(orderRequest)
+}
+
+// priceLine computes the discount and tax of a single line item of an order in the currency.
+func (uc *OrderUsecase) priceLine(currency string, line *entities.OrderDetail) error {
+	orderRequest := &entities.OrderRequest{Currency: currency, OrderDetails: []entities.OrderDetail{*line}}
+	if err := uc.pricing().Price(orderRequest); err != nil {
+		return fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	*line = orderRequest.OrderDetails[0]
+	return nil
+}
+
+// validateOrderRequest performs the basic sanity checks shared by the bulk endpoints.
+// The line items are compared with the catalog products, when given.
+func (uc *OrderUsecase) validateOrderRequest(orderRequest *entities.OrderRequest, products map[string]*entities.Product) error {
+	if !utils.IsValidUUID(orderRequest.UserID) {
+		return fmt.Errorf("invalid user id %q", orderRequest.UserID)
+	}
+	if !entities.IsValidOrderStatus(orderRequest.Status) {
+		return apperrors.ErrInvalidStatus
+	}
+	if len(orderRequest.OrderDetails) == 0 {
+		return fmt.Errorf("order has no line items")
+	}
+	for _, detail := range orderRequest.OrderDetails {
+		if !utils.IsValidUUID(detail.ProductID) {
+			return fmt.Errorf("invalid product id %q", detail.ProductID)
+		}
+		if detail.Quantity < 1 {
+			return fmt.Errorf("invalid quantity %d for product %s", detail.Quantity, detail.ProductID)
+		}
+	}
+	if orderRequest.CouponCode != "" {
+		return fmt.Errorf("coupon codes are not supported in bulk requests")
+	}
+	if err := uc.validateCurrency(orderRequest); err != nil {
+		return err
+	}
+	if err := validateDelivery(orderRequest); err != nil {
+		return err
+	}
+	if err := matchCatalog(products, orderRequest.Currency, orderRequest.OrderDetails); err != nil {
+		return err
+	}
+	return uc.price(orderRequest, nil)
+}
@@ -0,0 +1,188 @@
+// usecases/payment_usecase.go
+package usecases
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// PaymentGateway is the port to a payment provider.
+type PaymentGateway interface {
+	// Name identifies the provider in stored payments.
+	Name() string
+	// Authorize holds the amount and returns the provider reference of the payment.
+	// A declined payment fails with an error wrapping apperrors.ErrPaymentDeclined.
+	Authorize(orderID string, amount entities.Money, currency string) (string, error)
+	// Capture collects an authorized amount.
+	Capture(providerRef string, amount entities.Money) error
+	// Refund pays back part or all of a captured amount.
+	// A retried refund with the same idempotency key is paid back once.
+	Refund(providerRef string, amount entities.Money, idempotencyKey string) error
+	// ParseWebhook verifies the signature of a provider callback and decodes its event.
+	// An invalid signature fails with an error wrapping apperrors.ErrInvalidSignature.
+	ParseWebhook(payload []byte, signature string) (*entities.PaymentEvent, error)
+}
+
+type PaymentRepository interface {
+	// Create stores a new payment. A second authorized or captured payment of an order
+	// fails with apperrors.ErrPaymentExists.
+	Create(payment *entities.Payment) (string, error)
+	GetByID(id string) (*entities.Payment, error)
+	GetByOrderID(orderID string) ([]*entities.Payment, error)
+	GetByProviderRef(provider string, providerRef string) (*entities.Payment, error)
+	// Update stores the status and amounts of a payment that is still in status from,
+	// otherwise it fails with apperrors.ErrPaymentState. A payment that becomes captured moves
+	// its pending order to processing in the same transaction.
+	Update(payment *entities.Payment, from string) error
+	// ApplyEvent is Update for a provider event, recorded in the same transaction.
+	// It reports false, and changes nothing, when the event was already applied.
+	ApplyEvent(payment *entities.Payment, from string, eventID string) (bool, error)
+	// ForTenant returns the repository scoped to the rows of a tenant.
+	ForTenant(tenantID string) PaymentRepository
+}
+
+type PaymentUsecase struct {
+	PaymentRepo PaymentRepository
+	Gateway     PaymentGateway
+	OrderRepo   OrderRepository
+}
+
+// GetPayments returns the payments of an order, oldest first.
+func (uc *PaymentUsecase) GetPayments(orderID string) ([]*entities.Payment, error) {
+	return uc.PaymentRepo.GetByOrderID(orderID)
+}
+
+// StartPayment authorizes the total of a pending order with the payment provider.
+// A declined payment is stored as failed and returned along with the error.
+func (uc *PaymentUsecase) StartPayment(orderID string) (*entities.Payment, error) {
+	order, err := uc.OrderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.ID == "" {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	if order.Status != entities.OrderStatusPending {
+		return nil, apperrors.ErrOrderNotPending
+	}
+
+	payments, err := uc.PaymentRepo.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range payments {
+		if p.Status == entities.PaymentAuthorized || p.Status == entities.PaymentCaptured {
+			return nil, apperrors.ErrPaymentExists
+		}
+	}
+
+	payment := &entities.Payment{OrderID: orderID, Provider: uc.Gateway.Name(), Currency: order.Currency}
+	ref, err := uc.Gateway.Authorize(orderID, order.TotalPrice, order.Currency)
+	switch {
+	case errors.Is(err, apperrors.ErrPaymentDeclined):
+		payment.ProviderRef, payment.Status = ref, entities.PaymentFailed
+		payment.FailedAmount, payment.FailureReason = order.TotalPrice, err.Error()
+	case err != nil:
+		return nil, err
+	default:
+		payment.ProviderRef, payment.Status = ref, entities.PaymentAuthorized
+		payment.AuthorizedAmount = order.TotalPrice
+	}
+
+	id, createErr := uc.PaymentRepo.Create(payment)
+	if createErr != nil {
+		return nil, createErr
+	}
+	payment.ID = id
+	return payment, err
+}
+
+// Capture collects the authorized amount of a payment and moves its order to processing.
+func (uc *PaymentUsecase) Capture(orderID string, paymentID string) (*entities.Payment, error) {
+	payment, err := uc.PaymentRepo.GetByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.OrderID != orderID {
+		return nil, apperrors.ErrPaymentNotFound
+	}
+	if payment.Status != entities.PaymentAuthorized {
+		return nil, fmt.Errorf("%w: payment is %s", apperrors.ErrPaymentState, payment.Status)
+	}
+
+	if err := uc.Gateway.Capture(payment.ProviderRef, payment.AuthorizedAmount); err != nil {
+		return nil, err
+	}
+	payment.Status, payment.CapturedAmount = entities.PaymentCaptured, payment.AuthorizedAmount
+	err = uc.PaymentRepo.Update(payment, entities.PaymentAuthorized)
+	// A captured payment moves its order to processing
+	invalidateOrder(uc.OrderRepo, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// HandleWebhook applies a signed provider callback to its payment.
+// Retried callbacks are applied once, and events that no longer fit the payment state are ignored.
+func (uc *PaymentUsecase) HandleWebhook(payload []byte, signature string) error {
+	event, err := uc.Gateway.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+	payment, err := uc.PaymentRepo.GetByProviderRef(uc.Gateway.Name(), event.ProviderRef)
+	if err != nil {
+		return err
+	}
+
+	from := payment.Status
+	applies, err := applyPaymentEvent(payment, event)
+	if err != nil || !applies {
+		return err
+	}
+	_, err = uc.PaymentRepo.ApplyEvent(payment, from, event.ID)
+	invalidateOrder(uc.OrderRepo, payment.OrderID)
+	return err
+}
+
+// applyPaymentEvent moves a payment to the state reported by a provider event.
+// It reports false when the event does not change the payment, e.g. a late authorization of a captured payment.
+func applyPaymentEvent(payment *entities.Payment, event *entities.PaymentEvent) (bool, error) {
+	if event.Amount.IsNegative() {
+		return false, fmt.Errorf("%w: negative amount in event %s", apperrors.ErrInvalidRequest, event.ID)
+	}
+
+	switch event.Type {
+	case entities.PaymentEventAuthorized:
+		// Payments are stored as authorized when they are started
+		return false, nil
+	case entities.PaymentEventCaptured:
+		if payment.Status != entities.PaymentAuthorized {
+			return false, nil
+		}
+		payment.Status, payment.CapturedAmount = entities.PaymentCaptured, payment.AuthorizedAmount
+		if !event.Amount.IsZero() {
+			payment.CapturedAmount = event.Amount
+		}
+	case entities.PaymentEventRefunded:
+		if payment.Status != entities.PaymentCaptured && payment.Status != entities.PaymentRefunded {
+			return false, nil
+		}
+		refunded := payment.RefundedAmount.Add(event.Amount)
+		if payment.CapturedAmount.Sub(refunded).IsNegative() {
+			return false, fmt.Errorf("%w: refunds exceed the captured amount of payment %s", apperrors.ErrInvalidRequest, payment.ID)
+		}
+		payment.Status, payment.RefundedAmount = entities.PaymentRefunded, refunded
+	case entities.PaymentEventFailed:
+		if payment.Status != entities.PaymentAuthorized {
+			return false, nil
+		}
+		payment.Status, payment.FailedAmount, payment.FailureReason = entities.PaymentFailed, payment.AuthorizedAmount, event.Reason
+	default:
+		return false, fmt.Errorf("%w: unknown event type %q", apperrors.ErrInvalidRequest, event.Type)
+	}
+	return true, nil
+}
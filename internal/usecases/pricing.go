@@ -0,0 +1,87 @@
+// usecases/pricing.go
+package usecases
+
+import (
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/entities"
+)
+
+// PricingCalculator computes the price breakdown of an order.
+type PricingCalculator interface {
+	// Price fills in the line totals, discounts and taxes of the request and its subtotal,
+	// discount total, tax total and grand total (TotalPrice). The request currency is already set.
+	Price(orderRequest *entities.OrderRequest) error
+}
+
+// FlatTaxCalculator charges the same tax rate on every line, after the line discount.
+// Line taxes are rounded to the minor units of the order currency, half away from zero.
+type FlatTaxCalculator struct {
+	// Tax rate in basis points (hundredths of a percent), e.g. 1700 for 17%.
+	TaxRate int64
+}
+
+func (c FlatTaxCalculator) Price(orderRequest *entities.OrderRequest) error {
+	if c.TaxRate < 0 {
+		return fmt.Errorf("invalid tax rate %d", c.TaxRate)
+	}
+	if orderRequest.ShippingTotal.IsNegative() {
+		return fmt.Errorf("shipping must not be negative")
+	}
+
+	var subtotal, discountTotal, taxTotal entities.Money
+	for i := range orderRequest.OrderDetails {
+		detail := &orderRequest.OrderDetails[i]
+		if detail.UnitPrice.IsNegative() {
+			return fmt.Errorf("unit price of product %s must not be negative", detail.ProductID)
+		}
+		if detail.DiscountAmount.IsNegative() {
+			return fmt.Errorf("discount of product %s must not be negative", detail.ProductID)
+		}
+
+		totalPrice, err := detail.UnitPrice.MulChecked(detail.Quantity)
+		if err != nil {
+			return fmt.Errorf("total of product %s: %w", detail.ProductID, err)
+		}
+		detail.TotalPrice = totalPrice
+		// A line is never discounted below zero
+		if detail.TotalPrice.Sub(detail.DiscountAmount).IsNegative() {
+			detail.DiscountAmount = detail.TotalPrice
+		}
+		detail.TaxAmount, err = applyRate(detail.TotalPrice.Sub(detail.DiscountAmount), c.TaxRate, orderRequest.Currency)
+		if err != nil {
+			return fmt.Errorf("tax of product %s: %w", detail.ProductID, err)
+		}
+
+		subtotal = subtotal.Add(detail.TotalPrice)
+		discountTotal = discountTotal.Add(detail.DiscountAmount)
+		taxTotal = taxTotal.Add(detail.TaxAmount)
+	}
+
+	orderRequest.Subtotal = subtotal
+	orderRequest.DiscountTotal = discountTotal
+	orderRequest.TaxTotal = taxTotal
+	orderRequest.TotalPrice = subtotal.Sub(discountTotal).Add(taxTotal).Add(orderRequest.ShippingTotal)
+	return nil
+}
+
+// applyRate returns amount * rate basis points, rounded to the minor units of the currency.
+func applyRate(amount entities.Money, rate int64, currency string) (entities.Money, error) {
+	return mulFraction(amount, rate, 10000, currency)
+}
+
+// mulFraction returns amount * numerator / denominator, rounded to the minor units of the currency.
+// It fails with entities.ErrMoneyRange when the computation overflows, and with
+// entities.ErrMoneyDivision when denominator is zero.
+func mulFraction(amount entities.Money, numerator int64, denominator int64, currency string) (entities.Money, error) {
+	// Number of cents in the smallest unit of the currency, 100 for JPY
+	unit := int64(1)
+	for i := entities.CurrencyMinorUnits(currency); i < entities.MoneyScale; i++ {
+		unit *= 10
+	}
+	units, err := amount.MulFractionChecked(numerator, denominator*unit)
+	if err != nil {
+		return entities.Money{}, err
+	}
+	return units.MulChecked(int(unit))
+}
@@ -0,0 +1,82 @@
+// usecases/privacy_usecase.go
+package usecases
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+type PrivacyRepository interface {
+	ExportUserData(userID string) ([]entities.UserDataOrder, error)
+	// EraseUser pseudonymizes the orders of a user, wipes their personal fields and records the erasure in the audit log.
+	// It returns the IDs of the erased orders.
+	EraseUser(userID string, pseudonym string, entry *entities.ErasureRecord) ([]string, error)
+	GetErasures(subjectHash string) ([]entities.ErasureRecord, error)
+	// ForTenant returns the repository scoped to the rows of a tenant.
+	ForTenant(tenantID string) PrivacyRepository
+}
+
+// PrivacyUsecase answers the privacy requests of users: the export and the erasure of their data.
+type PrivacyUsecase struct {
+	PrivacyRepo PrivacyRepository
+	// The order repository, whose cached copies of the erased orders are dropped, when set
+	OrderRepo OrderRepository
+	// Returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+const maxErasureReasonLength = 500
+
+// ExportUserData returns the bundle of the orders of a user, with their line items, addresses and history.
+func (uc *PrivacyUsecase) ExportUserData(userID string) (*entities.UserDataExport, error) {
+	if !utils.IsValidUUID(userID) {
+		return nil, fmt.Errorf("%w: invalid user id %q", apperrors.ErrInvalidRequest, userID)
+	}
+	orders, err := uc.PrivacyRepo.ExportUserData(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.UserDataExport{UserID: userID, GeneratedAt: uc.now(), Orders: orders}, nil
+}
+
+// EraseUser erases the personal data of a user, keeping the amounts of the orders for accounting.
+// The orders get a new random user ID, which is not recorded anywhere, so they cannot be linked back to the user.
+// The reason is required and recorded with the actor in the erasure audit log.
+func (uc *PrivacyUsecase) EraseUser(userID string, reason string, actorID string) (*entities.ErasureRecord, error) {
+	if !utils.IsValidUUID(userID) {
+		return nil, fmt.Errorf("%w: invalid user id %q", apperrors.ErrInvalidRequest, userID)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxErasureReasonLength {
+		return nil, fmt.Errorf("%w: reason must be 1 to %d characters", apperrors.ErrInvalidRequest, maxErasureReasonLength)
+	}
+	entry := &entities.ErasureRecord{SubjectHash: entities.ErasureSubjectHash(userID), Reason: reason, ActorID: actorID}
+	orderIDs, err := uc.PrivacyRepo.EraseUser(userID, utils.CreateNewUUID().String(), entry)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range orderIDs {
+		invalidateOrder(uc.OrderRepo, id)
+	}
+	return entry, nil
+}
+
+// GetErasures returns the erasures recorded for a user, oldest first.
+func (uc *PrivacyUsecase) GetErasures(userID string) ([]entities.ErasureRecord, error) {
+	if !utils.IsValidUUID(userID) {
+		return nil, fmt.Errorf("%w: invalid user id %q", apperrors.ErrInvalidRequest, userID)
+	}
+	return uc.PrivacyRepo.GetErasures(entities.ErasureSubjectHash(userID))
+}
+
+func (uc *PrivacyUsecase) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now()
+	}
+	return time.Now()
+}
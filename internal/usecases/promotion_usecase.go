@@ -0,0 +1,215 @@
+// usecases/promotion_usecase.go
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/pkg/utils"
+)
+
+type PromotionRepository interface {
+	GetAll(page int) ([]*entities.Promotion, error)
+	GetByID(id string) (*entities.Promotion, error)
+	GetByCode(code string) (*entities.Promotion, error)
+	Create(promotion *entities.Promotion) (string, error)
+	Update(promotion *entities.Promotion) (*entities.Promotion, error)
+	Delete(id string) error
+	// ForTenant returns the repository scoped to the rows of a tenant.
+	ForTenant(tenantID string) PromotionRepository
+}
+
+type PromotionUsecase struct {
+	PromotionRepo PromotionRepository
+}
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+func (uc *PromotionUsecase) GetPromotions(page int) ([]*entities.Promotion, error) {
+	return uc.PromotionRepo.GetAll(page)
+}
+
+func (uc *PromotionUsecase) GetByID(id string) (*entities.Promotion, error) {
+	return uc.PromotionRepo.GetByID(id)
+}
+
+func (uc *PromotionUsecase) Create(promotion *entities.Promotion) (string, error) {
+	if err := validatePromotion(promotion); err != nil {
+		return "", fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	return uc.PromotionRepo.Create(promotion)
+}
+
+// Update replaces the settings of a promotion, its redemption count is kept.
+func (uc *PromotionUsecase) Update(id string, promotion *entities.Promotion) (*entities.Promotion, error) {
+	if err := validatePromotion(promotion); err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	promotion.ID = id
+	return uc.PromotionRepo.Update(promotion)
+}
+
+// Delete removes a promotion that was never redeemed, redeemed promotions can only be deactivated.
+func (uc *PromotionUsecase) Delete(id string) error {
+	return uc.PromotionRepo.Delete(id)
+}
+
+// validatePromotion normalizes the code and currency of a promotion and checks its settings.
+// Settings that do not belong to the promotion type are cleared.
+func validatePromotion(p *entities.Promotion) error {
+	p.Code = normalizeCouponCode(p.Code)
+	if !couponCodePattern.MatchString(p.Code) {
+		return fmt.Errorf("code must be 3 to 32 letters, digits, dashes or underscores")
+	}
+
+	switch p.Type {
+	case entities.PromotionPercentage:
+		if p.Percent < 1 || p.Percent > 100 {
+			return fmt.Errorf("percent must be between 1 and 100")
+		}
+		p.AmountOff, p.BuyQuantity, p.GetQuantity = entities.Money{}, 0, 0
+	case entities.PromotionFixedAmount:
+		if p.AmountOff.IsNegative() || p.AmountOff.IsZero() {
+			return fmt.Errorf("amount_off must be positive")
+		}
+		if p.Currency == "" {
+			return fmt.Errorf("fixed amount promotions require a currency")
+		}
+		p.Percent, p.BuyQuantity, p.GetQuantity = 0, 0, 0
+	case entities.PromotionBuyXGetY:
+		if p.BuyQuantity < 1 || p.GetQuantity < 1 {
+			return fmt.Errorf("buy_quantity and get_quantity must be at least 1")
+		}
+		p.Percent, p.AmountOff = 0, entities.Money{}
+	default:
+		return fmt.Errorf("invalid promotion type %q", p.Type)
+	}
+
+	if p.ProductID != "" && !utils.IsValidUUID(p.ProductID) {
+		return fmt.Errorf("invalid product id %q", p.ProductID)
+	}
+	if p.MinOrderValue.IsNegative() {
+		return fmt.Errorf("min_order_value must not be negative")
+	}
+	if !p.MinOrderValue.IsZero() && p.Currency == "" {
+		return fmt.Errorf("a minimum order value requires a currency")
+	}
+	if p.Currency != "" {
+		p.Currency = strings.ToUpper(p.Currency)
+		if !entities.IsKnownCurrency(p.Currency) {
+			return fmt.Errorf("unknown currency %q", p.Currency)
+		}
+		if !p.AmountOff.FitsCurrency(p.Currency) || !p.MinOrderValue.FitsCurrency(p.Currency) {
+			return fmt.Errorf("amounts have more decimal places than %s allows", p.Currency)
+		}
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.StartsAt.Before(*p.EndsAt) {
+		return fmt.Errorf("starts_at must be before ends_at")
+	}
+	if p.MaxRedemptions < 0 || p.MaxRedemptionsPerUser < 0 {
+		return fmt.Errorf("redemption limits must not be negative")
+	}
+	return nil
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// findCoupon returns the promotion of a coupon code, or nil when no code was given.
+func (uc *OrderUsecase) findCoupon(code string) (*entities.Promotion, error) {
+	if code == "" {
+		return nil, nil
+	}
+	if uc.PromotionRepo == nil {
+		return nil, fmt.Errorf("%w: coupon codes are not supported", apperrors.ErrInvalidRequest)
+	}
+	promotion, err := uc.PromotionRepo.GetByCode(normalizeCouponCode(code))
+	if errors.Is(err, apperrors.ErrPromotionNotFound) {
+		return nil, fmt.Errorf("%w: unknown coupon code %q", apperrors.ErrInvalidRequest, code)
+	}
+	return promotion, err
+}
+
+// applyPromotion sets the line discounts granted by the promotion on an order placed at now.
+// The redemption limits are enforced when the order is stored.
+func applyPromotion(p *entities.Promotion, orderRequest *entities.OrderRequest, now time.Time) error {
+	if !p.IsValidAt(now) {
+		return fmt.Errorf("coupon %s is not valid", p.Code)
+	}
+	if p.Currency != "" && p.Currency != orderRequest.Currency {
+		return fmt.Errorf("coupon %s does not apply to %s orders", p.Code, orderRequest.Currency)
+	}
+
+	var subtotal, eligibleTotal entities.Money
+	var eligible []*entities.OrderDetail
+	for i := range orderRequest.OrderDetails {
+		detail := &orderRequest.OrderDetails[i]
+		// Checked once here, the line totals below cannot overflow anymore
+		lineTotal, err := detail.UnitPrice.MulChecked(detail.Quantity)
+		if err != nil {
+			return err
+		}
+		subtotal = subtotal.Add(lineTotal)
+		if p.ProductID == "" || detail.ProductID == p.ProductID {
+			eligible = append(eligible, detail)
+			eligibleTotal = eligibleTotal.Add(lineTotal)
+		}
+	}
+	if subtotal.Sub(p.MinOrderValue).IsNegative() {
+		return fmt.Errorf("coupon %s requires a minimum order of %s %s", p.Code, p.MinOrderValue.FormatCurrency(p.Currency), p.Currency)
+	}
+
+	if eligibleTotal.IsZero() {
+		return fmt.Errorf("coupon %s does not apply to the items of this order", p.Code)
+	}
+
+	var discount entities.Money
+	switch p.Type {
+	case entities.PromotionPercentage:
+		for _, detail := range eligible {
+			share, err := mulFraction(detail.UnitPrice.Mul(detail.Quantity), int64(p.Percent), 100, orderRequest.Currency)
+			if err != nil {
+				return err
+			}
+			detail.DiscountAmount = share
+			discount = discount.Add(detail.DiscountAmount)
+		}
+	case entities.PromotionFixedAmount:
+		// Spread the amount over the eligible lines in proportion to their totals, the last line takes the rounding difference
+		amount := p.AmountOff
+		if eligibleTotal.Sub(amount).IsNegative() {
+			amount = eligibleTotal
+		}
+		remaining := amount
+		for i, detail := range eligible {
+			share := remaining
+			if i < len(eligible)-1 {
+				var err error
+				if share, err = mulFraction(amount, detail.UnitPrice.Mul(detail.Quantity).Cents(), eligibleTotal.Cents(), orderRequest.Currency); err != nil {
+					return err
+				}
+			}
+			detail.DiscountAmount = share
+			remaining = remaining.Sub(share)
+			discount = discount.Add(share)
+		}
+	case entities.PromotionBuyXGetY:
+		for _, detail := range eligible {
+			free := detail.Quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+			detail.DiscountAmount = detail.UnitPrice.Mul(free)
+			discount = discount.Add(detail.DiscountAmount)
+		}
+	}
+
+	if discount.IsZero() {
+		return fmt.Errorf("coupon %s does not apply to the items of this order", p.Code)
+	}
+	orderRequest.PromotionID = p.ID
+	return nil
+}
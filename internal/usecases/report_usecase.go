@@ -0,0 +1,95 @@
+// usecases/report_usecase.go
+package usecases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+type ReportRepository interface {
+	OrdersByPeriod(filter entities.ReportFilter) ([]entities.OrdersReportRow, error)
+	StatusCounts(filter entities.ReportFilter) ([]entities.StatusReportRow, error)
+	TopProducts(filter entities.ReportFilter) ([]entities.ProductReportRow, error)
+	// ForTenant returns the repository scoped to the rows of a tenant.
+	ForTenant(tenantID string) ReportRepository
+}
+
+type ReportUsecase struct {
+	ReportRepo ReportRepository
+	// Returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+const (
+	// The date range of a report without bounds
+	defaultReportRange = 30 * 24 * time.Hour
+	// The longest date range of a report
+	maxReportRange     = 731 * 24 * time.Hour
+	defaultTopProducts = 10
+	maxTopProducts     = 100
+)
+
+// OrdersByPeriod returns the number of orders, revenue and average order value per period and currency.
+func (uc *ReportUsecase) OrdersByPeriod(filter entities.ReportFilter) ([]entities.OrdersReportRow, error) {
+	if filter.Interval == "" {
+		filter.Interval = entities.ReportDay
+	}
+	if !entities.IsValidReportInterval(filter.Interval) {
+		return nil, fmt.Errorf("%w: interval must be day, week or month", apperrors.ErrInvalidRequest)
+	}
+	if err := uc.checkRange(&filter); err != nil {
+		return nil, err
+	}
+	return uc.ReportRepo.OrdersByPeriod(filter)
+}
+
+// StatusCounts returns the number of orders in each status.
+func (uc *ReportUsecase) StatusCounts(filter entities.ReportFilter) ([]entities.StatusReportRow, error) {
+	if err := uc.checkRange(&filter); err != nil {
+		return nil, err
+	}
+	return uc.ReportRepo.StatusCounts(filter)
+}
+
+// TopProducts returns the best selling products, by quantity unless sorted by revenue.
+func (uc *ReportUsecase) TopProducts(filter entities.ReportFilter) ([]entities.ProductReportRow, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = entities.ReportByQuantity
+	}
+	if filter.SortBy != entities.ReportByQuantity && filter.SortBy != entities.ReportByRevenue {
+		return nil, fmt.Errorf("%w: sort must be quantity or revenue", apperrors.ErrInvalidRequest)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultTopProducts
+	}
+	if filter.Limit < 1 || filter.Limit > maxTopProducts {
+		return nil, fmt.Errorf("%w: limit must be 1 to %d", apperrors.ErrInvalidRequest, maxTopProducts)
+	}
+	if err := uc.checkRange(&filter); err != nil {
+		return nil, err
+	}
+	return uc.ReportRepo.TopProducts(filter)
+}
+
+// checkRange defaults the date range of a report to the last 30 days and bounds its length.
+func (uc *ReportUsecase) checkRange(filter *entities.ReportFilter) error {
+	if filter.To.IsZero() {
+		filter.To = time.Now()
+		if uc.Now != nil {
+			filter.To = uc.Now()
+		}
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.Add(-defaultReportRange)
+	}
+	if !filter.From.Before(filter.To) {
+		return fmt.Errorf("%w: from must be before to", apperrors.ErrInvalidRequest)
+	}
+	if filter.To.Sub(filter.From) > maxReportRange {
+		return fmt.Errorf("%w: the date range must not exceed %d days", apperrors.ErrInvalidRequest, int(maxReportRange.Hours()/24))
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// usecases/return_usecase.go
+package usecases
+
+import (
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+type ReturnRepository interface {
+	// Create stores a return with its items. It locks the order and fails when the order is not completed
+	// or when more than the ordered quantity of a line item would be returned.
+	Create(ret *entities.Return, actorID string) (string, error)
+	GetByID(id string) (*entities.Return, error)
+	GetByOrderID(orderID string) ([]entities.Return, error)
+	// UpdateStatus moves a return from one status to another, failing with apperrors.ErrReturnState
+	// when it is no longer in status from.
+	UpdateStatus(id string, from string, to string, actorID string) error
+	// ForTenant returns the repository scoped to the rows of a tenant.
+	ForTenant(tenantID string) ReturnRepository
+}
+
+type ReturnUsecase struct {
+	ReturnRepo ReturnRepository
+	OrderRepo  OrderRepository
+	// Refund the returns paid by a captured payment, returns are only recorded when nil.
+	PaymentRepo PaymentRepository
+	Gateway     PaymentGateway
+}
+
+// GetReturns returns the returns of an order, oldest first.
+func (uc *ReturnUsecase) GetReturns(orderID string) ([]entities.Return, error) {
+	return uc.ReturnRepo.GetByOrderID(orderID)
+}
+
+// RequestReturn creates a return authorization for some line items of a completed order.
+// The refund of each item is its share of what was paid for the line: the line total after discount, with tax.
+// Shipping is not refunded.
+func (uc *ReturnUsecase) RequestReturn(orderID string, req *entities.ReturnRequest, actorID string) (*entities.Return, error) {
+	order, err := uc.OrderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.ID == "" {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	if order.Status != entities.OrderStatusCompleted {
+		return nil, apperrors.ErrOrderNotCompleted
+	}
+
+	details, err := uc.OrderRepo.GetOrderDetails(orderID)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := uc.ReturnRepo.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := newReturn(order, details, previous, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidRequest, err)
+	}
+	ret.ID, err = uc.ReturnRepo.Create(ret, actorID)
+	// Returns bump the version of their order
+	invalidateOrder(uc.OrderRepo, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// AdvanceReturn moves a return to its next status: requested -> received -> refunded.
+// Refunding pays the refund amount back through the payment provider.
+func (uc *ReturnUsecase) AdvanceReturn(orderID string, returnID string, status string, actorID string) (*entities.Return, error) {
+	ret, err := uc.ReturnRepo.GetByID(returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret.OrderID != orderID {
+		return nil, apperrors.ErrReturnNotFound
+	}
+	if entities.NextReturnStatus(ret.Status) != status {
+		return nil, fmt.Errorf("%w: a %s return cannot become %s", apperrors.ErrReturnState, ret.Status, status)
+	}
+
+	if status == entities.ReturnRefunded {
+		if err := uc.refund(ret); err != nil {
+			return nil, err
+		}
+	}
+	err = uc.ReturnRepo.UpdateStatus(ret.ID, ret.Status, status, actorID)
+	invalidateOrder(uc.OrderRepo, orderID)
+	if err != nil {
+		return nil, err
+	}
+	ret.Status = status
+	return ret, nil
+}
+
+// refund pays back the refund amount of a return from a captured payment of its order.
+// Orders without any captured payment were paid outside the payment provider, their refund is recorded only.
+// When no captured payment has enough left to cover the refund, it fails with apperrors.ErrPaymentState.
+// The return ID is the idempotency key of the refund and of its payment event, so a refund retried
+// after a failure is paid and counted once.
+func (uc *ReturnUsecase) refund(ret *entities.Return) error {
+	if uc.PaymentRepo == nil || uc.Gateway == nil || ret.RefundAmount.IsZero() {
+		return nil
+	}
+	payments, err := uc.PaymentRepo.GetByOrderID(ret.OrderID)
+	if err != nil {
+		return err
+	}
+	captured := false
+	for _, payment := range payments {
+		if payment.Status != entities.PaymentCaptured && payment.Status != entities.PaymentRefunded {
+			continue
+		}
+		captured = true
+		if payment.CapturedAmount.Sub(payment.RefundedAmount).Sub(ret.RefundAmount).IsNegative() {
+			continue
+		}
+		if err := uc.Gateway.Refund(payment.ProviderRef, ret.RefundAmount, ret.ID); err != nil {
+			return err
+		}
+		from := payment.Status
+		payment.Status, payment.RefundedAmount = entities.PaymentRefunded, payment.RefundedAmount.Add(ret.RefundAmount)
+		_, err := uc.PaymentRepo.ApplyEvent(payment, from, "return_"+ret.ID)
+		return err
+	}
+	if captured {
+		return fmt.Errorf("%w: no captured payment of the order covers a refund of %s", apperrors.ErrPaymentState, ret.RefundAmount)
+	}
+	// Paid outside the payment provider, the refund is recorded only
+	return nil
+}
+
+// newReturn computes the items and refund amount of a return request.
+// The quantities already held by previous returns of the order cannot be returned again.
+func newReturn(order *entities.Order, details []entities.OrderDetail, previous []entities.Return, req *entities.ReturnRequest) (*entities.Return, error) {
+	lines := make(map[string]entities.OrderDetail, len(details))
+	for _, detail := range details {
+		lines[detail.ID] = detail
+	}
+	returnedQuantity := make(map[string]int)
+	returnedAmount := make(map[string]entities.Money)
+	for _, p := range previous {
+		for _, item := range p.Items {
+			returnedQuantity[item.OrderDetailID] += item.Quantity
+			returnedAmount[item.OrderDetailID] = returnedAmount[item.OrderDetailID].Add(item.RefundAmount)
+		}
+	}
+
+	ret := &entities.Return{OrderID: order.ID, Status: entities.ReturnRequested, Reason: req.Reason}
+	seen := make(map[string]bool, len(req.Items))
+	for _, item := range req.Items {
+		line, ok := lines[item.OrderDetailID]
+		if !ok {
+			return nil, fmt.Errorf("line item %s is not part of the order", item.OrderDetailID)
+		}
+		if seen[item.OrderDetailID] {
+			return nil, fmt.Errorf("line item %s is listed twice", item.OrderDetailID)
+		}
+		seen[item.OrderDetailID] = true
+		if item.Quantity < 1 {
+			return nil, fmt.Errorf("quantity must be at least 1")
+		}
+		remaining := line.Quantity - returnedQuantity[line.ID]
+		if item.Quantity > remaining {
+			return nil, fmt.Errorf("only %d of line item %s can be returned", remaining, line.ID)
+		}
+
+		paid := line.UnitPrice.Mul(line.Quantity).Sub(line.DiscountAmount).Add(line.TaxAmount)
+		refund, err := mulFraction(paid, int64(item.Quantity), int64(line.Quantity), order.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if item.Quantity == remaining {
+			// The last units take the rounding difference, so the line is never refunded more or less than was paid
+			refund = paid.Sub(returnedAmount[line.ID])
+		}
+		ret.Items = append(ret.Items, entities.ReturnItem{OrderDetailID: line.ID, Quantity: item.Quantity, RefundAmount: refund})
+		ret.RefundAmount = ret.RefundAmount.Add(refund)
+	}
+	return ret, nil
+}
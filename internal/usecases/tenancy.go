@@ -0,0 +1,119 @@
+// usecases/tenancy.go
+package usecases
+
+import (
+	"fmt"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+)
+
+// ForTenant returns the usecase scoped to the orders of a tenant, with the settings of the tenant
+// configured in Tenants applied over the settings of the service.
+func (uc *OrderUsecase) ForTenant(tenantID string) *OrderUsecase {
+	scoped := *uc
+	scoped.OrderRepo = uc.OrderRepo.ForTenant(tenantID)
+	if uc.PromotionRepo != nil {
+		scoped.PromotionRepo = uc.PromotionRepo.ForTenant(tenantID)
+	}
+	if uc.SagaRepo != nil {
+		scoped.SagaRepo = uc.SagaRepo.ForTenant(tenantID)
+	}
+	if uc.ReturnRepo != nil {
+		scoped.ReturnRepo = uc.ReturnRepo.ForTenant(tenantID)
+	}
+	if uc.FulfilmentRepo != nil {
+		scoped.FulfilmentRepo = uc.FulfilmentRepo.ForTenant(tenantID)
+	}
+
+	if config, ok := uc.Tenants[tenantID]; ok {
+		if config.PageSize > 0 {
+			scoped.PageSize = config.PageSize
+		}
+		if len(config.AllowedCurrencies) > 0 {
+			scoped.AllowedCurrencies = config.AllowedCurrencies
+		}
+		if config.StatusTransitions != nil {
+			scoped.StatusTransitions = config.StatusTransitions
+		}
+	}
+	return &scoped
+}
+
+// ForTenant returns the usecase scoped to the promotions of a tenant.
+func (uc *PromotionUsecase) ForTenant(tenantID string) *PromotionUsecase {
+	return &PromotionUsecase{PromotionRepo: uc.PromotionRepo.ForTenant(tenantID)}
+}
+
+// ForTenant returns the usecase scoped to the payments of a tenant.
+func (uc *PaymentUsecase) ForTenant(tenantID string) *PaymentUsecase {
+	return &PaymentUsecase{
+		PaymentRepo: uc.PaymentRepo.ForTenant(tenantID),
+		Gateway:     uc.Gateway,
+		OrderRepo:   uc.OrderRepo.ForTenant(tenantID),
+	}
+}
+
+// ForTenant returns the usecase scoped to the returns of a tenant.
+func (uc *ReturnUsecase) ForTenant(tenantID string) *ReturnUsecase {
+	scoped := *uc
+	scoped.ReturnRepo = uc.ReturnRepo.ForTenant(tenantID)
+	scoped.OrderRepo = uc.OrderRepo.ForTenant(tenantID)
+	if uc.PaymentRepo != nil {
+		scoped.PaymentRepo = uc.PaymentRepo.ForTenant(tenantID)
+	}
+	return &scoped
+}
+
+// ForTenant returns the usecase scoped to the orders of a tenant.
+func (uc *ReportUsecase) ForTenant(tenantID string) *ReportUsecase {
+	scoped := *uc
+	scoped.ReportRepo = uc.ReportRepo.ForTenant(tenantID)
+	return &scoped
+}
+
+// ForTenant returns the usecase scoped to the data of a tenant.
+func (uc *PrivacyUsecase) ForTenant(tenantID string) *PrivacyUsecase {
+	scoped := *uc
+	scoped.PrivacyRepo = uc.PrivacyRepo.ForTenant(tenantID)
+	if uc.OrderRepo != nil {
+		scoped.OrderRepo = uc.OrderRepo.ForTenant(tenantID)
+	}
+	return &scoped
+}
+
+// checkTransition checks a status change of an order against the status rules of the tenant.
+// It returns the version of the order that was checked, so that the change fails when the order
+// moved in the meantime, or expectedVersion when there are no rules.
+func (uc *OrderUsecase) checkTransition(id string, status int, expectedVersion int) (int, error) {
+	if uc.StatusTransitions == nil {
+		return expectedVersion, nil
+	}
+	order, err := uc.OrderRepo.GetByID(id)
+	if err != nil {
+		return 0, err
+	}
+	if order == nil || order.ID == "" {
+		return 0, apperrors.ErrOrderNotFound
+	}
+	if expectedVersion != 0 && order.Version != expectedVersion {
+		return 0, apperrors.ErrVersionMismatch
+	}
+	if !uc.StatusTransitions.Allows(order.Status, status) {
+		return 0, fmt.Errorf("%w: from %d to %d", apperrors.ErrStatusTransition, order.Status, status)
+	}
+	return order.Version, nil
+}
+
+// ValidateTenants checks the IDs and settings of the tenant configurations.
+func ValidateTenants(tenants map[string]entities.TenantConfig) error {
+	for id, config := range tenants {
+		if !entities.IsValidTenantID(id) {
+			return fmt.Errorf("invalid tenant id %q", id)
+		}
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf("tenant %s: %v", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+-- Order version, incremented on every change of an order (optimistic concurrency)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Table: order_history
+CREATE TABLE IF NOT EXISTS order_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    details JSONB,
+    actor_id UUID,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_history_order_id ON order_history (order_id, created_at);
+
+-- Function: get_order
+DROP FUNCTION IF EXISTS get_order(UUID);
+CREATE FUNCTION get_order(p_id UUID)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    total_price NUMERIC(10, 2),
+    status INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER
+)
+LANGUAGE sql STABLE AS $$
+    SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version
+    FROM orders o
+    WHERE o.id = p_id;
+$$;
+
+-- Function: get_user_orders
+DROP FUNCTION IF EXISTS get_user_orders(UUID, INTEGER, INTEGER);
+CREATE FUNCTION get_user_orders(p_user_id UUID, p_offset INTEGER, p_limit INTEGER)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    total_price NUMERIC(10, 2),
+    status INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER
+)
+LANGUAGE sql STABLE AS $$
+    SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version
+    FROM orders o
+    WHERE o.user_id = p_user_id
+    ORDER BY o.created_at DESC, o.id
+    OFFSET p_offset
+    LIMIT p_limit;
+$$;
@@ -0,0 +1,17 @@
+-- Function: orders_bump_version
+-- Increments the order version on every update, whichever statement or procedure writes the row.
+CREATE OR REPLACE FUNCTION orders_bump_version()
+RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    NEW.updated_at := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS orders_bump_version ON orders;
+CREATE TRIGGER orders_bump_version
+BEFORE UPDATE ON orders
+FOR EACH ROW
+EXECUTE FUNCTION orders_bump_version();
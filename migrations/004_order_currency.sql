@@ -0,0 +1,93 @@
+-- Order currency (ISO 4217), existing orders were all placed in USD
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id_currency ON orders (user_id, currency);
+
+-- Type: order_detail_type, a line item passed to orders_insert
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'order_detail_type') THEN
+        CREATE TYPE order_detail_type AS (
+            product_id UUID,
+            quantity INTEGER,
+            unit_price NUMERIC(10, 2)
+        );
+    END IF;
+END;
+$$;
+
+-- Procedure: orders_insert
+DROP PROCEDURE IF EXISTS orders_insert(UUID, NUMERIC, INTEGER, order_detail_type[], UUID);
+DROP PROCEDURE IF EXISTS orders_insert(UUID, NUMERIC, INTEGER, order_detail_type[], UUID, CHAR);
+CREATE PROCEDURE orders_insert(
+    p_user_id UUID,
+    p_total_price NUMERIC(10, 2),
+    p_status INTEGER,
+    p_order_details order_detail_type[],
+    p_id UUID,
+    p_currency CHAR(3)
+)
+LANGUAGE plpgsql AS $$
+BEGIN
+    INSERT INTO orders (id, user_id, total_price, status, currency)
+    VALUES (p_id, p_user_id, p_total_price, p_status, p_currency);
+
+    INSERT INTO order_details (order_id, product_id, quantity, unit_price)
+    SELECT p_id, d.product_id, d.quantity, d.unit_price
+    FROM unnest(p_order_details) AS d;
+END;
+$$;
+
+-- Function: get_order
+DROP FUNCTION IF EXISTS get_order(UUID);
+CREATE FUNCTION get_order(p_id UUID)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    total_price NUMERIC(10, 2),
+    status INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER,
+    currency CHAR(3)
+)
+LANGUAGE sql STABLE AS $$
+    SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency
+    FROM orders o
+    WHERE o.id = p_id;
+$$;
+
+-- Function: get_user_orders
+-- The optional filters match the ones of the order export, NULL means no restriction.
+DROP FUNCTION IF EXISTS get_user_orders(UUID, INTEGER, INTEGER);
+CREATE FUNCTION get_user_orders(
+    p_user_id UUID,
+    p_offset INTEGER,
+    p_limit INTEGER,
+    p_status INTEGER DEFAULT NULL,
+    p_currency CHAR(3) DEFAULT NULL,
+    p_from TIMESTAMP DEFAULT NULL,
+    p_to TIMESTAMP DEFAULT NULL
+)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    total_price NUMERIC(10, 2),
+    status INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER,
+    currency CHAR(3)
+)
+LANGUAGE sql STABLE AS $$
+    SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency
+    FROM orders o
+    WHERE o.user_id = p_user_id
+      AND (p_status IS NULL OR o.status = p_status)
+      AND (p_currency IS NULL OR o.currency = p_currency)
+      AND (p_from IS NULL OR o.created_at >= p_from)
+      AND (p_to IS NULL OR o.created_at < p_to)
+    ORDER BY o.created_at DESC, o.id
+    OFFSET p_offset
+    LIMIT p_limit;
+$$;
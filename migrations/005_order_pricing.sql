@@ -0,0 +1,111 @@
+-- Price breakdown of orders and line items.
+-- orders.total_price becomes the grand total: subtotal - discount_total + tax_total + shipping_total.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subtotal NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_total NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_total NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_total NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+-- Existing orders had neither discounts, taxes nor shipping, their total is their subtotal
+UPDATE orders SET subtotal = total_price WHERE subtotal = 0;
+
+-- Type: order_detail_type, a line item passed to orders_insert
+DROP PROCEDURE IF EXISTS orders_insert(UUID, NUMERIC, INTEGER, order_detail_type[], UUID, CHAR);
+DROP PROCEDURE IF EXISTS orders_insert(UUID, NUMERIC, INTEGER, order_detail_type[], UUID, CHAR, NUMERIC, NUMERIC, NUMERIC, NUMERIC);
+DROP TYPE IF EXISTS order_detail_type;
+CREATE TYPE order_detail_type AS (
+    product_id UUID,
+    quantity INTEGER,
+    unit_price NUMERIC(10, 2),
+    discount_amount NUMERIC(10, 2),
+    tax_amount NUMERIC(10, 2)
+);
+
+-- Procedure: orders_insert
+CREATE PROCEDURE orders_insert(
+    p_user_id UUID,
+    p_total_price NUMERIC(10, 2),
+    p_status INTEGER,
+    p_order_details order_detail_type[],
+    p_id UUID,
+    p_currency CHAR(3),
+    p_subtotal NUMERIC(10, 2),
+    p_discount_total NUMERIC(10, 2),
+    p_tax_total NUMERIC(10, 2),
+    p_shipping_total NUMERIC(10, 2)
+)
+LANGUAGE plpgsql AS $$
+BEGIN
+    INSERT INTO orders (id, user_id, total_price, status, currency, subtotal, discount_total, tax_total, shipping_total)
+    VALUES (p_id, p_user_id, p_total_price, p_status, p_currency, p_subtotal, p_discount_total, p_tax_total, p_shipping_total);
+
+    INSERT INTO order_details (order_id, product_id, quantity, unit_price, discount_amount, tax_amount)
+    SELECT p_id, d.product_id, d.quantity, d.unit_price, COALESCE(d.discount_amount, 0), COALESCE(d.tax_amount, 0)
+    FROM unnest(p_order_details) AS d;
+END;
+$$;
+
+-- Function: get_order
+DROP FUNCTION IF EXISTS get_order(UUID);
+CREATE FUNCTION get_order(p_id UUID)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    total_price NUMERIC(10, 2),
+    status INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER,
+    currency CHAR(3),
+    subtotal NUMERIC(10, 2),
+    discount_total NUMERIC(10, 2),
+    tax_total NUMERIC(10, 2),
+    shipping_total NUMERIC(10, 2)
+)
+LANGUAGE sql STABLE AS $$
+    SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency,
+           o.subtotal, o.discount_total, o.tax_total, o.shipping_total
+    FROM orders o
+    WHERE o.id = p_id;
+$$;
+
+-- Function: get_user_orders
+DROP FUNCTION IF EXISTS get_user_orders(UUID, INTEGER, INTEGER, INTEGER, CHAR, TIMESTAMP, TIMESTAMP);
+CREATE FUNCTION get_user_orders(
+    p_user_id UUID,
+    p_offset INTEGER,
+    p_limit INTEGER,
+    p_status INTEGER DEFAULT NULL,
+    p_currency CHAR(3) DEFAULT NULL,
+    p_from TIMESTAMP DEFAULT NULL,
+    p_to TIMESTAMP DEFAULT NULL
+)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    total_price NUMERIC(10, 2),
+    status INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER,
+    currency CHAR(3),
+    subtotal NUMERIC(10, 2),
+    discount_total NUMERIC(10, 2),
+    tax_total NUMERIC(10, 2),
+    shipping_total NUMERIC(10, 2)
+)
+LANGUAGE sql STABLE AS $$
+    SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency,
+           o.subtotal, o.discount_total, o.tax_total, o.shipping_total
+    FROM orders o
+    WHERE o.user_id = p_user_id
+      AND (p_status IS NULL OR o.status = p_status)
+      AND (p_currency IS NULL OR o.currency = p_currency)
+      AND (p_from IS NULL OR o.created_at >= p_from)
+      AND (p_to IS NULL OR o.created_at < p_to)
+    ORDER BY o.created_at DESC, o.id
+    OFFSET p_offset
+    LIMIT p_limit;
+$$;
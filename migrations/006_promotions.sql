@@ -0,0 +1,33 @@
+-- Table: promotions, redeemed with a coupon code at checkout
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(32) NOT NULL UNIQUE,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('percentage', 'fixed_amount', 'buy_x_get_y')),
+    percent INTEGER NOT NULL DEFAULT 0,
+    amount_off NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    buy_quantity INTEGER NOT NULL DEFAULT 0,
+    get_quantity INTEGER NOT NULL DEFAULT 0,
+    product_id UUID,
+    currency CHAR(3),
+    min_order_value NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    max_redemptions INTEGER NOT NULL DEFAULT 0,
+    max_redemptions_per_user INTEGER NOT NULL DEFAULT 0,
+    redemptions INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Table: promotion_redemptions, one row per order placed with a promotion.
+-- A redeemed promotion cannot be deleted, only deactivated.
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promotion_id UUID NOT NULL REFERENCES promotions (id) ON DELETE RESTRICT,
+    user_id UUID NOT NULL,
+    order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_promotion_user ON promotion_redemptions (promotion_id, user_id);
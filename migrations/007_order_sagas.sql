@@ -0,0 +1,16 @@
+-- Table: order_sagas, the inventory reservation of an order, resumed after a crash.
+-- The saga ID is also the reservation ID sent to the inventory service.
+CREATE TABLE IF NOT EXISTS order_sagas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID UNIQUE REFERENCES orders (id) ON DELETE SET NULL,
+    state VARCHAR(16) NOT NULL DEFAULT 'started'
+        CHECK (state IN ('started', 'reserved', 'order_created', 'compensated', 'released', 'confirmed')),
+    items JSONB NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Unfinished sagas are looked up when resuming
+CREATE INDEX IF NOT EXISTS idx_order_sagas_unfinished ON order_sagas (updated_at)
+    WHERE state IN ('started', 'reserved', 'order_created');
@@ -0,0 +1,28 @@
+-- Table: payments, the payments of an order through a payment provider
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    provider VARCHAR(32) NOT NULL,
+    provider_ref VARCHAR(128),
+    status VARCHAR(16) NOT NULL CHECK (status IN ('authorized', 'captured', 'refunded', 'failed')),
+    currency CHAR(3) NOT NULL,
+    authorized_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    captured_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    refunded_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    failed_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    failure_reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, provider_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments (order_id, created_at);
+
+-- Table: payment_events, the provider callbacks already applied, so retried callbacks are applied once
+CREATE TABLE IF NOT EXISTS payment_events (
+    provider VARCHAR(32) NOT NULL,
+    event_id VARCHAR(128) NOT NULL,
+    payment_id UUID NOT NULL REFERENCES payments (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, event_id)
+);
@@ -0,0 +1,23 @@
+-- Table: returns, return authorizations for line items of completed orders
+CREATE TABLE IF NOT EXISTS returns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'received', 'refunded')),
+    reason TEXT,
+    refund_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_returns_order_id ON returns (order_id, created_at);
+
+-- Table: return_items, the returned quantity of each line item
+CREATE TABLE IF NOT EXISTS return_items (
+    return_id UUID NOT NULL REFERENCES returns (id) ON DELETE CASCADE,
+    order_detail_id UUID NOT NULL REFERENCES order_details (id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    refund_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (return_id, order_detail_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_items_order_detail_id ON return_items (order_detail_id);
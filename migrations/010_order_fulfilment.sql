@@ -0,0 +1,24 @@
+-- Table: order_fulfilment, the delivery method and shipment of an order
+CREATE TABLE IF NOT EXISTS order_fulfilment (
+    order_id UUID PRIMARY KEY REFERENCES orders (id) ON DELETE CASCADE,
+    delivery_method VARCHAR(16) CHECK (delivery_method IN ('standard', 'express', 'pickup')),
+    tracking_number VARCHAR(64),
+    carrier VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Table: order_addresses, the shipping and billing addresses of an order
+CREATE TABLE IF NOT EXISTS order_addresses (
+    order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('shipping', 'billing')),
+    name VARCHAR(128) NOT NULL,
+    line1 VARCHAR(128) NOT NULL,
+    line2 VARCHAR(128),
+    city VARCHAR(128) NOT NULL,
+    region VARCHAR(128),
+    postal_code VARCHAR(16),
+    country CHAR(2) NOT NULL,
+    phone VARCHAR(32),
+    PRIMARY KEY (order_id, kind)
+);
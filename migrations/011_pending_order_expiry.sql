@@ -0,0 +1,2 @@
+-- Index: the pending orders, oldest first, scanned by the auto-cancellation sweep
+CREATE INDEX IF NOT EXISTS idx_orders_pending_created_at ON orders (created_at) WHERE status = 1;
@@ -0,0 +1,117 @@
+-- Tenancy: every order, promotion and saga belongs to a tenant, and row level security limits the statements
+-- of a transaction to the rows of the tenant set with SET LOCAL app.tenant_id. The tenant '*' sees every row,
+-- it is used by the background jobs. The service must not connect as a superuser, superusers bypass the policies.
+
+-- The existing rows belong to the default tenant
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE order_sagas ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+-- New rows belong to the tenant of the transaction, inserting without one fails
+ALTER TABLE orders ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE promotions ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+ALTER TABLE order_sagas ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id');
+
+-- Coupon codes are unique per tenant
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_code_key;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'orders'::regclass AND conname = 'orders_tenant_id_check') THEN
+        ALTER TABLE orders ADD CONSTRAINT orders_tenant_id_check CHECK (tenant_id <> '*');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'promotions'::regclass AND conname = 'promotions_tenant_id_check') THEN
+        ALTER TABLE promotions ADD CONSTRAINT promotions_tenant_id_check CHECK (tenant_id <> '*');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'order_sagas'::regclass AND conname = 'order_sagas_tenant_id_check') THEN
+        ALTER TABLE order_sagas ADD CONSTRAINT order_sagas_tenant_id_check CHECK (tenant_id <> '*');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'promotions'::regclass AND conname = 'promotions_tenant_id_code_key') THEN
+        ALTER TABLE promotions ADD CONSTRAINT promotions_tenant_id_code_key UNIQUE (tenant_id, code);
+    END IF;
+END;
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_user_created_at ON orders (tenant_id, user_id, created_at);
+
+-- Function: whether a row of a tenant is visible to the transaction
+CREATE OR REPLACE FUNCTION tenant_visible(p_tenant_id VARCHAR)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+    SELECT p_tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.tenant_id', true) = '*'
+$$;
+
+-- Policies: the tables carrying a tenant_id are filtered by it
+ALTER TABLE orders ENABLE ROW LEVEL SECURITY;
+ALTER TABLE orders FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS orders_tenant ON orders;
+CREATE POLICY orders_tenant ON orders
+    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+ALTER TABLE promotions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE promotions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS promotions_tenant ON promotions;
+CREATE POLICY promotions_tenant ON promotions
+    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+ALTER TABLE order_sagas ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_sagas FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_sagas_tenant ON order_sagas;
+CREATE POLICY order_sagas_tenant ON order_sagas
+    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+-- Policies: the other tables follow the row they belong to, which is itself filtered by its policy
+ALTER TABLE order_details ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_details FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_details_tenant ON order_details;
+CREATE POLICY order_details_tenant ON order_details
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.id = order_id));
+
+ALTER TABLE order_history ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_history FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_history_tenant ON order_history;
+CREATE POLICY order_history_tenant ON order_history
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.id = order_id));
+
+ALTER TABLE order_fulfilment ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_fulfilment FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_fulfilment_tenant ON order_fulfilment;
+CREATE POLICY order_fulfilment_tenant ON order_fulfilment
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.id = order_id));
+
+ALTER TABLE order_addresses ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_addresses FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_addresses_tenant ON order_addresses;
+CREATE POLICY order_addresses_tenant ON order_addresses
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.id = order_id));
+
+ALTER TABLE payments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payments FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS payments_tenant ON payments;
+CREATE POLICY payments_tenant ON payments
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.id = order_id));
+
+ALTER TABLE payment_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payment_events FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS payment_events_tenant ON payment_events;
+CREATE POLICY payment_events_tenant ON payment_events
+    USING (EXISTS (SELECT 1 FROM payments p WHERE p.id = payment_id));
+
+ALTER TABLE returns ENABLE ROW LEVEL SECURITY;
+ALTER TABLE returns FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS returns_tenant ON returns;
+CREATE POLICY returns_tenant ON returns
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.id = order_id));
+
+ALTER TABLE return_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE return_items FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS return_items_tenant ON return_items;
+CREATE POLICY return_items_tenant ON return_items
+    USING (EXISTS (SELECT 1 FROM returns r WHERE r.id = return_id));
+
+ALTER TABLE promotion_redemptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE promotion_redemptions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS promotion_redemptions_tenant ON promotion_redemptions;
+CREATE POLICY promotion_redemptions_tenant ON promotion_redemptions
+    USING (EXISTS (SELECT 1 FROM promotions p WHERE p.id = promotion_id));
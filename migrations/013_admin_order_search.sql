@@ -0,0 +1,5 @@
+-- Index: the orders containing a product, searched by support staff
+CREATE INDEX IF NOT EXISTS idx_order_details_product_id ON order_details (product_id);
+
+-- Index: order ID prefix lookups (id::text LIKE 'prefix%')
+CREATE INDEX IF NOT EXISTS idx_orders_id_text ON orders ((id::text) text_pattern_ops);
@@ -0,0 +1,2 @@
+-- Index: the orders of a tenant in a date range, aggregated by the reports
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_created_at ON orders (tenant_id, created_at);
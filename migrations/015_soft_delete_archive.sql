@@ -0,0 +1,166 @@
+-- Soft delete: deleted orders are kept, with their history, but hidden from every query of the service
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Function: get_order, deleted orders are not found
+DROP FUNCTION IF EXISTS get_order(UUID);
+CREATE FUNCTION get_order(p_id UUID)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    total_price NUMERIC(10, 2),
+    status INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER,
+    currency CHAR(3),
+    subtotal NUMERIC(10, 2),
+    discount_total NUMERIC(10, 2),
+    tax_total NUMERIC(10, 2),
+    shipping_total NUMERIC(10, 2)
+)
+LANGUAGE sql STABLE AS $$
+    SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency,
+           o.subtotal, o.discount_total, o.tax_total, o.shipping_total
+    FROM orders o
+    WHERE o.id = p_id AND o.deleted_at IS NULL;
+$$;
+
+-- Function: get_user_orders, deleted orders are left out
+DROP FUNCTION IF EXISTS get_user_orders(UUID, INTEGER, INTEGER, INTEGER, CHAR, TIMESTAMP, TIMESTAMP);
+CREATE FUNCTION get_user_orders(
+    p_user_id UUID,
+    p_offset INTEGER,
+    p_limit INTEGER,
+    p_status INTEGER DEFAULT NULL,
+    p_currency CHAR(3) DEFAULT NULL,
+    p_from TIMESTAMP DEFAULT NULL,
+    p_to TIMESTAMP DEFAULT NULL
+)
+RETURNS TABLE (
+    id UUID,
+    user_id UUID,
+    total_price NUMERIC(10, 2),
+    status INTEGER,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER,
+    currency CHAR(3),
+    subtotal NUMERIC(10, 2),
+    discount_total NUMERIC(10, 2),
+    tax_total NUMERIC(10, 2),
+    shipping_total NUMERIC(10, 2)
+)
+LANGUAGE sql STABLE AS $$
+    SELECT o.id, o.user_id, o.total_price, o.status, o.created_at, o.updated_at, o.version, o.currency,
+           o.subtotal, o.discount_total, o.tax_total, o.shipping_total
+    FROM orders o
+    WHERE o.user_id = p_user_id
+      AND o.deleted_at IS NULL
+      AND (p_status IS NULL OR o.status = p_status)
+      AND (p_currency IS NULL OR o.currency = p_currency)
+      AND (p_from IS NULL OR o.created_at >= p_from)
+      AND (p_to IS NULL OR o.created_at < p_to)
+    ORDER BY o.created_at DESC, o.id
+    OFFSET p_offset
+    LIMIT p_limit;
+$$;
+
+-- Archive: the orders past the retention period are moved, with every row that belongs to them, to tables
+-- with the same columns. Columns added to a table later on must be added to its archive as well.
+CREATE TABLE IF NOT EXISTS orders_archive (LIKE orders);
+ALTER TABLE orders_archive ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'orders_archive'::regclass AND conname = 'orders_archive_pkey') THEN
+        ALTER TABLE orders_archive ADD CONSTRAINT orders_archive_pkey PRIMARY KEY (id);
+    END IF;
+END;
+$$;
+CREATE INDEX IF NOT EXISTS idx_orders_archive_tenant_user_created_at ON orders_archive (tenant_id, user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS order_details_archive (LIKE order_details);
+CREATE INDEX IF NOT EXISTS idx_order_details_archive_order_id ON order_details_archive (order_id);
+
+CREATE TABLE IF NOT EXISTS order_history_archive (LIKE order_history);
+CREATE INDEX IF NOT EXISTS idx_order_history_archive_order_id ON order_history_archive (order_id, created_at);
+
+CREATE TABLE IF NOT EXISTS order_fulfilment_archive (LIKE order_fulfilment);
+CREATE TABLE IF NOT EXISTS order_addresses_archive (LIKE order_addresses);
+CREATE INDEX IF NOT EXISTS idx_order_fulfilment_archive_order_id ON order_fulfilment_archive (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_addresses_archive_order_id ON order_addresses_archive (order_id);
+
+CREATE TABLE IF NOT EXISTS payments_archive (LIKE payments);
+CREATE TABLE IF NOT EXISTS payment_events_archive (LIKE payment_events);
+CREATE INDEX IF NOT EXISTS idx_payments_archive_order_id ON payments_archive (order_id);
+CREATE INDEX IF NOT EXISTS idx_payment_events_archive_payment_id ON payment_events_archive (payment_id);
+
+CREATE TABLE IF NOT EXISTS returns_archive (LIKE returns);
+CREATE TABLE IF NOT EXISTS return_items_archive (LIKE return_items);
+CREATE INDEX IF NOT EXISTS idx_returns_archive_order_id ON returns_archive (order_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_archive_return_id ON return_items_archive (return_id);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions_archive (LIKE promotion_redemptions);
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_archive_order_id ON promotion_redemptions_archive (order_id);
+-- The redemptions of archived orders count towards max_redemptions_per_user
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_archive_promotion_user ON promotion_redemptions_archive (promotion_id, user_id);
+
+-- Policies: the archive tables are filtered by tenant like the tables they mirror
+ALTER TABLE orders_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE orders_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS orders_archive_tenant ON orders_archive;
+CREATE POLICY orders_archive_tenant ON orders_archive
+    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+ALTER TABLE order_details_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_details_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_details_archive_tenant ON order_details_archive;
+CREATE POLICY order_details_archive_tenant ON order_details_archive
+    USING (EXISTS (SELECT 1 FROM orders_archive o WHERE o.id = order_id));
+
+ALTER TABLE order_history_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_history_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_history_archive_tenant ON order_history_archive;
+CREATE POLICY order_history_archive_tenant ON order_history_archive
+    USING (EXISTS (SELECT 1 FROM orders_archive o WHERE o.id = order_id));
+
+ALTER TABLE order_fulfilment_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_fulfilment_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_fulfilment_archive_tenant ON order_fulfilment_archive;
+CREATE POLICY order_fulfilment_archive_tenant ON order_fulfilment_archive
+    USING (EXISTS (SELECT 1 FROM orders_archive o WHERE o.id = order_id));
+
+ALTER TABLE order_addresses_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_addresses_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS order_addresses_archive_tenant ON order_addresses_archive;
+CREATE POLICY order_addresses_archive_tenant ON order_addresses_archive
+    USING (EXISTS (SELECT 1 FROM orders_archive o WHERE o.id = order_id));
+
+ALTER TABLE payments_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payments_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS payments_archive_tenant ON payments_archive;
+CREATE POLICY payments_archive_tenant ON payments_archive
+    USING (EXISTS (SELECT 1 FROM orders_archive o WHERE o.id = order_id));
+
+ALTER TABLE payment_events_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payment_events_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS payment_events_archive_tenant ON payment_events_archive;
+CREATE POLICY payment_events_archive_tenant ON payment_events_archive
+    USING (EXISTS (SELECT 1 FROM payments_archive p WHERE p.id = payment_id));
+
+ALTER TABLE returns_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE returns_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS returns_archive_tenant ON returns_archive;
+CREATE POLICY returns_archive_tenant ON returns_archive
+    USING (EXISTS (SELECT 1 FROM orders_archive o WHERE o.id = order_id));
+
+ALTER TABLE return_items_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE return_items_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS return_items_archive_tenant ON return_items_archive;
+CREATE POLICY return_items_archive_tenant ON return_items_archive
+    USING (EXISTS (SELECT 1 FROM returns_archive r WHERE r.id = return_id));
+
+ALTER TABLE promotion_redemptions_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE promotion_redemptions_archive FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS promotion_redemptions_archive_tenant ON promotion_redemptions_archive;
+CREATE POLICY promotion_redemptions_archive_tenant ON promotion_redemptions_archive
+    USING (EXISTS (SELECT 1 FROM orders_archive o WHERE o.id = order_id));
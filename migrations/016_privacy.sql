@@ -0,0 +1,44 @@
+-- Table: erasure_audit_log, the erasures of the data of a user. The user is recorded by the SHA-256 hash of its ID,
+-- the pseudonym the orders were given is not recorded, so the erased orders cannot be linked back to the user.
+CREATE TABLE IF NOT EXISTS erasure_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id VARCHAR(63) NOT NULL DEFAULT current_setting('app.tenant_id') CHECK (tenant_id <> '*'),
+    subject_hash CHAR(64) NOT NULL,
+    orders INTEGER NOT NULL DEFAULT 0,
+    addresses INTEGER NOT NULL DEFAULT 0,
+    reason TEXT NOT NULL,
+    actor_id UUID,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_erasure_audit_log_subject ON erasure_audit_log (tenant_id, subject_hash, created_at);
+
+-- The audit log is append only
+CREATE OR REPLACE FUNCTION reject_audit_log_change()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RAISE EXCEPTION '% is append only', TG_TABLE_NAME;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS erasure_audit_log_immutable ON erasure_audit_log;
+CREATE TRIGGER erasure_audit_log_immutable
+    BEFORE UPDATE OR DELETE ON erasure_audit_log
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+
+DROP TRIGGER IF EXISTS erasure_audit_log_no_truncate ON erasure_audit_log;
+CREATE TRIGGER erasure_audit_log_no_truncate
+    BEFORE TRUNCATE ON erasure_audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
+
+ALTER TABLE erasure_audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE erasure_audit_log FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS erasure_audit_log_tenant ON erasure_audit_log;
+CREATE POLICY erasure_audit_log_tenant ON erasure_audit_log
+    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+-- The changes made by a user are looked up when erasing its data
+CREATE INDEX IF NOT EXISTS idx_order_history_actor_id ON order_history (actor_id) WHERE actor_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_user_id ON promotion_redemptions (user_id);
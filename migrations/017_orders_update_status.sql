@@ -0,0 +1,16 @@
+-- Procedure: orders_update_status
+-- Called by the order repository but missing from the earlier migrations, so databases set up from them
+-- got it by hand. It is only created when missing, an existing procedure is left untouched.
+-- The version and updated_at columns are maintained by the orders_bump_version trigger.
+DO $$
+BEGIN
+    IF to_regprocedure('orders_update_status(uuid, integer)') IS NULL THEN
+        CREATE PROCEDURE orders_update_status(p_id UUID, p_status INTEGER)
+        LANGUAGE plpgsql AS $body$
+        BEGIN
+            UPDATE orders SET status = p_status WHERE id = p_id AND deleted_at IS NULL;
+        END;
+        $body$;
+    END IF;
+END;
+$$;
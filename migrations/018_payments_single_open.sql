@@ -0,0 +1,4 @@
+-- Index: an order has at most one authorized or captured payment
+-- StartPayment checks it before authorizing, the index keeps two concurrent checkouts from both storing
+-- their authorization. Orders that already have more than one must be settled by hand before it can be created.
+CREATE UNIQUE INDEX IF NOT EXISTS payments_order_id_open_key ON payments (order_id) WHERE status IN ('authorized', 'captured');
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shayja/orders-service/internal/adapters/controllers"
 	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
 	"github.com/shayja/orders-service/internal/usecases"
 	"github.com/shayja/orders-service/pkg/utils"
 	"github.com/stretchr/testify/assert"
@@ -28,9 +31,18 @@ func setupRouter(orderController *controllers.OrderController) *gin.Engine {
 	api := router.Group("/api/v1")
 	{
 		api.GET("/orders", orderController.GetOrders)
+		api.GET("/order/export", orderController.Export)
+		api.GET("/order/summary", orderController.GetSummary)
 		api.GET("/order/:id", orderController.GetByID)
 		api.POST("/order", orderController.Create)
 		api.PUT("/order/:id/status", orderController.UpdateStatus)
+		api.POST("/order/:id/items", orderController.AddItem)
+		api.POST("/order/stale/cancel", orderController.CancelStaleOrders)
+		api.GET("/admin/orders", orderController.SearchOrders)
+		api.PUT("/admin/orders/:id/status", orderController.ForceStatus)
+		api.GET("/admin/orders/:id/history", orderController.GetHistory)
+		api.DELETE("/admin/orders/:id", orderController.DeleteOrder)
+		api.POST("/admin/orders/archive", orderController.ArchiveOrders)
 	}
 	return router
 }
@@ -57,7 +69,7 @@ func TestGetOrdersIntegration(t *testing.T) {
 	mockOrder := entities.Order{
 		ID:	"1",
 		UserID:	"123e4567-e89b-12d3-a456-426614174000",
-		TotalPrice:	100,
+		TotalPrice:	entities.MustParseMoney("100.00"),
 		Status:	1,// "Pending"
 	}
 
@@ -103,10 +115,10 @@ func TestCreateOrderIntegration(t *testing.T) {
 	// Mock Request Data
 	orderRequest := &entities.OrderRequest{
 		UserID:	"123e4567-e89b-12d3-a456-426614174000",
-		TotalPrice: 150,
+		TotalPrice: entities.MustParseMoney("150.00"),
 		Status: 1,//"Pending",
 		OrderDetails: []entities.OrderDetail{
-			{ProductID: "1", Quantity: 2},
+			{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 2},
 		},
 	}
 	body, _ := json.Marshal(orderRequest)
@@ -133,18 +145,341 @@ func TestCreateOrderIntegration(t *testing.T) {
 	assert.NotNil(t, entity)
 }
 
+func TestExportOrdersIntegration(t *testing.T) {
+	mockRepo := &MockOrderRepository{}
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: mockRepo}
+	orderController := &controllers.OrderController{OrderUsecase: orderUsecase}
+	router := setupRouter(orderController)
+
+	mockRepo.orders = []*entities.Order{
+		{ID: "1", UserID: "123e4567-e89b-12d3-a456-426614174000", TotalPrice: entities.MustParseMoney("100.00"), Status: 1},
+		{ID: "2", UserID: "someone-else", TotalPrice: entities.MustParseMoney("50.00"), Status: 1},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/order/export?format=csv&columns=id,total_price", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id,total_price\n1,100.00\n", w.Body.String())
+
+	// Exporting all users requires the admin role
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/order/export?all_users=true", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAddOrderItemIntegration(t *testing.T) {
+	mockRepo := &MockOrderRepository{}
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: mockRepo}
+	orderController := &controllers.OrderController{OrderUsecase: orderUsecase}
+	router := setupRouter(orderController)
+
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	mockRepo.orders = []*entities.Order{
+		{ID: orderID, UserID: "123e4567-e89b-12d3-a456-426614174000", TotalPrice: entities.MustParseMoney("100.00"), Status: 1, Version: 1},
+	}
+	body := []byte(`{"product_id": "063d0ff7-e17e-4957-8d92-a988caeda8a1", "quantity": 2, "unit_price": 5}`)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/order/"+orderID+"/items", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Equal(t, entities.MustParseMoney("110.00"), mockRepo.orders[0].TotalPrice)
+
+	// A second edit based on the stale version is rejected
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/order/"+orderID+"/items", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestConditionalRequestsIntegration(t *testing.T) {
+	mockRepo := &MockOrderRepository{}
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: mockRepo}
+	orderController := &controllers.OrderController{OrderUsecase: orderUsecase}
+	router := setupRouter(orderController)
+
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	mockRepo.orders = []*entities.Order{
+		{ID: orderID, UserID: "123e4567-e89b-12d3-a456-426614174000", TotalPrice: entities.MustParseMoney("100.00"), Status: 1, Version: 4},
+	}
+
+	// GetByID exposes the version as ETag
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/order/"+orderID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+
+	// Unchanged order
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/order/"+orderID, nil)
+	req.Header.Set("If-None-Match", `"4"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// Stale version
+	body := []byte(`{"status": 2}`)
+	req, _ = http.NewRequest(http.MethodPut, "/api/v1/order/"+orderID+"/status", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// Current version
+	req, _ = http.NewRequest(http.MethodPut, "/api/v1/order/"+orderID+"/status", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"4"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+}
+
+func TestGetOrdersByCurrencyIntegration(t *testing.T) {
+	mockRepo := &MockOrderRepository{}
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: mockRepo, AllowedCurrencies: []string{"USD", "JPY"}}
+	orderController := &controllers.OrderController{OrderUsecase: orderUsecase}
+	router := setupRouter(orderController)
+
+	mockRepo.orders = []*entities.Order{
+		{ID: "1", UserID: "123e4567-e89b-12d3-a456-426614174000", TotalPrice: entities.MustParseMoney("10.50"), Currency: "USD"},
+		{ID: "2", UserID: "123e4567-e89b-12d3-a456-426614174000", TotalPrice: entities.MustParseMoney("1500"), Currency: "JPY"},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/orders?page=1&currency=jpy", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// JPY amounts are written without minor units
+	assert.Contains(t, w.Body.String(), `"total_price":1500,`)
+	assert.NotContains(t, w.Body.String(), `"id":"1"`)
+
+	// Currencies outside the allow-list are rejected
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/orders?page=1&currency=EUR", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOrderSummaryIntegration(t *testing.T) {
+	mockRepo := &MockOrderRepository{}
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: mockRepo}
+	orderController := &controllers.OrderController{OrderUsecase: orderUsecase}
+	router := setupRouter(orderController)
+
+	last := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	mockRepo.orders = []*entities.Order{
+		{ID: "1", UserID: "123e4567-e89b-12d3-a456-426614174000", TotalPrice: entities.MustParseMoney("10.50"), Currency: "USD", Status: entities.OrderStatusCompleted, CreatedAt: last.AddDate(0, -1, 0)},
+		{ID: "2", UserID: "123e4567-e89b-12d3-a456-426614174000", TotalPrice: entities.MustParseMoney("1500"), Currency: "JPY", Status: entities.OrderStatusPending, CreatedAt: last},
+		{ID: "3", UserID: "123e4567-e89b-12d3-a456-426614174000", TotalPrice: entities.MustParseMoney("5.00"), Currency: "USD", Status: entities.OrderStatusCancelled, CreatedAt: last.AddDate(0, -2, 0)},
+		{ID: "4", UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", TotalPrice: entities.MustParseMoney("99.00"), Currency: "USD", Status: entities.OrderStatusCompleted, CreatedAt: last},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/order/summary", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "success", "msg": null, "data": {
+		"orders": 3,
+		"last_order_at": "2024-07-01T12:00:00Z",
+		"total_spent": [{"currency": "USD", "amount": 10.50}, {"currency": "JPY", "amount": 1500}],
+		"by_status": {"pending": 1, "processing": 0, "completed": 1, "cancelled": 1}
+	}}`, w.Body.String())
+
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/order/summary?from=2024-07-02&to=2024-07-01", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 // Mock Repository
+func TestCancelStaleOrdersIntegration(t *testing.T) {
+	mockRepo := &MockOrderRepository{}
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: mockRepo, PendingTTL: time.Hour}
+	orderController := &controllers.OrderController{OrderUsecase: orderUsecase}
+	router := setupRouter(orderController)
+
+	stale := &entities.Order{ID: "6204037c-30e6-408b-8aaa-dd8219860b4b", Status: entities.OrderStatusPending, CreatedAt: time.Now().Add(-2 * time.Hour)}
+	fresh := &entities.Order{ID: "7204037c-30e6-408b-8aaa-dd8219860b4b", Status: entities.OrderStatusPending, CreatedAt: time.Now()}
+	mockRepo.orders = []*entities.Order{stale, fresh}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/order/stale/cancel", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "success", "data": {"cancelled": 1}, "msg": null}`, w.Body.String())
+	assert.Equal(t, entities.OrderStatusCancelled, stale.Status)
+	assert.Equal(t, entities.OrderStatusPending, fresh.Status)
+}
+
+func TestAdminOrdersIntegration(t *testing.T) {
+	mockRepo := &MockOrderRepository{}
+	orderUsecase := &usecases.OrderUsecase{
+		OrderRepo:         mockRepo,
+		StatusTransitions: entities.StatusTransitions{entities.OrderStatusPending: {entities.OrderStatusProcessing}},
+	}
+	orderController := &controllers.OrderController{OrderUsecase: orderUsecase}
+	router := setupRouter(orderController)
+
+	mine := &entities.Order{ID: "6204037c-30e6-408b-8aaa-dd8219860b4b", UserID: "123e4567-e89b-12d3-a456-426614174000", Status: entities.OrderStatusPending, Version: 1}
+	other := &entities.Order{ID: "7204037c-30e6-408b-8aaa-dd8219860b4b", UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", Status: entities.OrderStatusPending, Version: 1}
+	mockRepo.orders = []*entities.Order{mine, other}
+
+	// Orders of another user, looked up by ID prefix
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/admin/orders?page=1&user_id=451fa817-41f4-40cf-8dc2-c9f22aa98a4f&id_prefix=7204", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var found struct {
+		Data []entities.Order `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &found))
+	assert.Len(t, found.Data, 1)
+	assert.Equal(t, other.ID, found.Data[0].ID)
+
+	// Pending to cancelled is not an allowed transition, but can be forced with a reason
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/api/v1/admin/orders/"+other.ID+"/status", bytes.NewBufferString(`{"status": 4}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/api/v1/admin/orders/"+other.ID+"/status", bytes.NewBufferString(`{"status": 4, "reason": "Customer called support"}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, entities.OrderStatusCancelled, other.Status)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/admin/orders/"+other.ID+"/history", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history struct {
+		Data []entities.OrderHistory `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(t, history.Data, 1)
+	assert.Equal(t, entities.HistoryStatusForced, history.Data[0].Event)
+	assert.JSONEq(t, `{"from": 1, "to": 4, "reason": "Customer called support"}`, string(history.Data[0].Details))
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000", history.Data[0].ActorID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/admin/orders/8204037c-30e6-408b-8aaa-dd8219860b4b/history", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSoftDeleteAndArchiveIntegration(t *testing.T) {
+	mockRepo := &MockOrderRepository{}
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: mockRepo, ArchiveAfter: 365 * 24 * time.Hour}
+	orderController := &controllers.OrderController{OrderUsecase: orderUsecase}
+	router := setupRouter(orderController)
+
+	userID := "123e4567-e89b-12d3-a456-426614174000"
+	old := &entities.Order{ID: "6204037c-30e6-408b-8aaa-dd8219860b4b", UserID: userID, Status: entities.OrderStatusCompleted, CreatedAt: time.Now().AddDate(-2, 0, 0)}
+	open := &entities.Order{ID: "7204037c-30e6-408b-8aaa-dd8219860b4b", UserID: userID, Status: entities.OrderStatusProcessing, CreatedAt: time.Now().AddDate(-2, 0, 0)}
+	done := &entities.Order{ID: "8204037c-30e6-408b-8aaa-dd8219860b4b", UserID: userID, Status: entities.OrderStatusCancelled, CreatedAt: time.Now()}
+	mockRepo.orders = []*entities.Order{old, open, done}
+
+	// Open orders cannot be deleted
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/admin/orders/"+open.ID, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "/api/v1/admin/orders/"+done.ID, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The history of a deleted order is still served
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/admin/orders/"+done.ID+"/history", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The old finished order is archived, the open one is kept
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/admin/orders/archive", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "success", "data": {"archived": 1}, "msg": null}`, w.Body.String())
+
+	var listed struct {
+		Data []entities.Order `json:"data"`
+	}
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/orders?page=1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Len(t, listed.Data, 1)
+	assert.Equal(t, open.ID, listed.Data[0].ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/orders?page=1&include_archived=true", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Len(t, listed.Data, 2)
+	assert.Equal(t, old.ID, listed.Data[1].ID)
+	assert.NotNil(t, listed.Data[1].ArchivedAt)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/orders?page=1&include_archived=maybe", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Archived orders are found by ID on request only
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/order/"+old.ID+"?include_archived=true", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/order/"+done.ID+"?include_archived=true", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 type MockOrderRepository struct {
-	orders []*entities.Order
+	orders   []*entities.Order
+	history  []entities.OrderHistory
+	deleted  []*entities.Order
+	archived []*entities.Order
 }
 
-func (m *MockOrderRepository) GetAllOrders(page int, userID string) ([]*entities.Order, error) {
+func (m *MockOrderRepository) GetAllOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
 	var result []*entities.Order
 	for _, order := range m.orders {
-		if order.UserID == userID {
+		if order.UserID == filter.UserID && (filter.Currency == "" || order.Currency == filter.Currency) {
 			result = append(result, order)
 		}
 	}
+	if filter.IncludeArchived {
+		for _, order := range m.archived {
+			if order.UserID == filter.UserID {
+				result = append(result, order)
+			}
+		}
+	}
 	return result, nil
 }
 
@@ -160,21 +495,243 @@ func (m *MockOrderRepository) GetByID(id string) (*entities.Order, error) {
 func (m *MockOrderRepository) Create(orderRequest *entities.OrderRequest) (string, error) {
 	newID := utils.CreateNewUUID().String()
 	newOrder := &entities.Order{
-		ID:         newID,
-		UserID:     orderRequest.UserID,
-		TotalPrice: orderRequest.TotalPrice,
-		Status:     orderRequest.Status,
+		ID:            newID,
+		UserID:        orderRequest.UserID,
+		TotalPrice:    orderRequest.TotalPrice,
+		Subtotal:      orderRequest.Subtotal,
+		DiscountTotal: orderRequest.DiscountTotal,
+		TaxTotal:      orderRequest.TaxTotal,
+		ShippingTotal: orderRequest.ShippingTotal,
+		Status:        orderRequest.Status,
+		Currency:      orderRequest.Currency,
+		CreatedAt:     time.Now(),
 	}
 	m.orders = append(m.orders, newOrder)
 	return newID, nil
 }
 
-func (m *MockOrderRepository) UpdateStatus(id string, status int) (*entities.Order, error) {
+func (m *MockOrderRepository) UpdateStatus(id string, status int, expectedVersion int, actorID string) (*entities.Order, error) {
 	for _, order := range m.orders {
 		if order.ID == id {
+			if expectedVersion != 0 && order.Version != expectedVersion {
+				return nil, apperrors.ErrVersionMismatch
+			}
 			order.Status = status
+			order.Version++
 			return order, nil
 		}
 	}
-	return nil, nil
+	return nil, apperrors.ErrOrderNotFound
+}
+
+func (m *MockOrderRepository) CreateBulk(orders []*entities.OrderRequest, atomic bool) ([]entities.BulkItemResult, error) {
+	results := make([]entities.BulkItemResult, len(orders))
+	for i, orderRequest := range orders {
+		newID, _ := m.Create(orderRequest)
+		results[i] = entities.BulkItemResult{Index: i, ID: newID, Status: entities.BulkItemSuccess}
+	}
+	return results, nil
+}
+
+func (m *MockOrderRepository) UpdateStatusBulk(updates []entities.StatusUpdate, atomic bool, actorID string) ([]entities.BulkItemResult, error) {
+	results := make([]entities.BulkItemResult, len(updates))
+	for i, u := range updates {
+		order, _ := m.UpdateStatus(u.ID, u.Status, 0, actorID)
+		if order == nil {
+			results[i] = entities.BulkItemResult{Index: i, ID: u.ID, Status: entities.BulkItemFailed, Error: "order not found"}
+			continue
+		}
+		results[i] = entities.BulkItemResult{Index: i, ID: u.ID, Status: entities.BulkItemSuccess}
+	}
+	return results, nil
+}
+
+func (m *MockOrderRepository) ExportOrders(filter entities.OrderFilter, includeItems bool, fn func(*entities.Order) error) error {
+	for _, order := range m.orders {
+		if filter.UserID != "" && order.UserID != filter.UserID {
+			continue
+		}
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockOrderRepository) GetOrderDetails(orderID string) ([]entities.OrderDetail, error) {
+	order, _ := m.GetByID(orderID)
+	if order == nil {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	return order.OrderDetails, nil
+}
+
+func (m *MockOrderRepository) AddOrderItem(orderID string, item *entities.OrderItemRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	order, _ := m.GetByID(orderID)
+	if order == nil {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	if expectedVersion != 0 && order.Version != expectedVersion {
+		return nil, apperrors.ErrVersionMismatch
+	}
+	order.OrderDetails = append(order.OrderDetails, entities.OrderDetail{
+		ID:         utils.CreateNewUUID().String(),
+		OrderID:    orderID,
+		ProductID:  item.ProductID,
+		Quantity:   item.Quantity,
+		UnitPrice:  item.UnitPrice,
+		TotalPrice: item.UnitPrice.Mul(item.Quantity),
+	})
+	order.TotalPrice = order.TotalPrice.Add(item.UnitPrice.Mul(item.Quantity))
+	order.Version++
+	return order, nil
+}
+
+func (m *MockOrderRepository) UpdateOrderItem(orderID string, itemID string, update *entities.OrderItemUpdate, expectedVersion int, actorID string) (*entities.Order, error) {
+	return nil, apperrors.ErrItemNotFound
+}
+
+func (m *MockOrderRepository) CancelStalePending(before time.Time, batchSize int, reason string) ([]string, error) {
+	var ids []string
+	for _, order := range m.orders {
+		if order.Status == entities.OrderStatusPending && order.CreatedAt.Before(before) {
+			order.Status = entities.OrderStatusCancelled
+			ids = append(ids, order.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (m *MockOrderRepository) SearchOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	var result []*entities.Order
+	for _, order := range m.orders {
+		if filter.UserID != "" && order.UserID != filter.UserID {
+			continue
+		}
+		if filter.Status != 0 && order.Status != filter.Status {
+			continue
+		}
+		if filter.IDPrefix != "" && !strings.HasPrefix(order.ID, filter.IDPrefix) {
+			continue
+		}
+		result = append(result, order)
+	}
+	return result, nil
+}
+
+func (m *MockOrderRepository) GetOrderSummary(filter entities.OrderFilter) (*entities.OrderSummary, error) {
+	summary := &entities.OrderSummary{TotalSpent: []entities.CurrencyAmount{}}
+	spent := map[string]entities.Money{}
+	var currencies []string
+	for _, order := range m.orders {
+		if order.UserID != filter.UserID {
+			continue
+		}
+		summary.Orders++
+		summary.ByStatus.Add(order.Status, 1)
+		if summary.LastOrderAt == nil || order.CreatedAt.After(*summary.LastOrderAt) {
+			createdAt := order.CreatedAt
+			summary.LastOrderAt = &createdAt
+		}
+		if order.Status != entities.OrderStatusCancelled {
+			if _, ok := spent[order.Currency]; !ok {
+				currencies = append(currencies, order.Currency)
+			}
+			spent[order.Currency] = spent[order.Currency].Add(order.TotalPrice)
+		}
+	}
+	for _, currency := range currencies {
+		summary.TotalSpent = append(summary.TotalSpent, entities.CurrencyAmount{Currency: currency, Amount: spent[currency]})
+	}
+	return summary, nil
+}
+
+func (m *MockOrderRepository) ForceStatus(id string, status int, reason string, expectedVersion int, actorID string) (*entities.Order, error) {
+	order, _ := m.GetByID(id)
+	if order == nil {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	details, _ := json.Marshal(map[string]interface{}{"from": order.Status, "to": status, "reason": reason})
+	m.history = append(m.history, entities.OrderHistory{
+		ID: utils.CreateNewUUID().String(), OrderID: id, Event: entities.HistoryStatusForced, Details: details, ActorID: actorID, CreatedAt: time.Now(),
+	})
+	order.Status = status
+	order.Version++
+	return order, nil
+}
+
+func (m *MockOrderRepository) GetOrderHistory(orderID string) ([]entities.OrderHistory, error) {
+	found := false
+	for _, order := range append(append([]*entities.Order{}, m.orders...), m.deleted...) {
+		found = found || order.ID == orderID
+	}
+	if !found {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	history := []entities.OrderHistory{}
+	for _, entry := range m.history {
+		if entry.OrderID == orderID {
+			history = append(history, entry)
+		}
+	}
+	return history, nil
+}
+
+func (m *MockOrderRepository) DeleteOrder(id string, expectedVersion int, actorID string) error {
+	for i, order := range m.orders {
+		if order.ID != id {
+			continue
+		}
+		if order.Status != entities.OrderStatusCompleted && order.Status != entities.OrderStatusCancelled {
+			return apperrors.ErrOrderOpen
+		}
+		m.orders = append(m.orders[:i], m.orders[i+1:]...)
+		m.deleted = append(m.deleted, order)
+		return nil
+	}
+	return apperrors.ErrOrderNotFound
+}
+
+func (m *MockOrderRepository) ArchiveOrders(before time.Time, batchSize int) ([]string, error) {
+	var ids []string
+	var live []*entities.Order
+	now := time.Now()
+	for _, order := range m.orders {
+		if order.CreatedAt.Before(before) && (order.Status == entities.OrderStatusCompleted || order.Status == entities.OrderStatusCancelled) {
+			order.ArchivedAt = &now
+			m.archived = append(m.archived, order)
+			ids = append(ids, order.ID)
+			continue
+		}
+		live = append(live, order)
+	}
+	m.orders = live
+	// Deleted orders are archived too, but stay hidden
+	var deleted []*entities.Order
+	for _, order := range m.deleted {
+		if order.CreatedAt.Before(before) {
+			ids = append(ids, order.ID)
+			continue
+		}
+		deleted = append(deleted, order)
+	}
+	m.deleted = deleted
+	return ids, nil
+}
+
+func (m *MockOrderRepository) GetArchivedByID(id string) (*entities.Order, error) {
+	for _, order := range m.archived {
+		if order.ID == id {
+			return order, nil
+		}
+	}
+	return &entities.Order{}, nil
+}
+
+func (m *MockOrderRepository) RemoveOrderItem(orderID string, itemID string, expectedVersion int, actorID string) (*entities.Order, error) {
+	return nil, apperrors.ErrItemNotFound
+}
+
+func (m *MockOrderRepository) ForTenant(tenantID string) usecases.OrderRepository {
+	return m
 }
@@ -0,0 +1,259 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	promotionrepo "github.com/shayja/orders-service/internal/adapters/repositories/promotions"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/pkg/utils"
+	"github.com/shayja/orders-service/test/pgtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTenant = "acme"
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	pgtest.Stop()
+	os.Exit(code)
+}
+
+func newOrderRequest(userID string, details ...entities.OrderDetail) *entities.OrderRequest {
+	subtotal := entities.Money{}
+	for _, detail := range details {
+		subtotal = subtotal.Add(detail.UnitPrice.Mul(detail.Quantity))
+	}
+	return &entities.OrderRequest{
+		UserID:       userID,
+		TotalPrice:   subtotal,
+		Subtotal:     subtotal,
+		Status:       entities.OrderStatusPending,
+		Currency:     "USD",
+		OrderDetails: details,
+	}
+}
+
+func newDetail(quantity int, unitPrice string) entities.OrderDetail {
+	return entities.OrderDetail{
+		ProductID: utils.CreateNewUUID().String(),
+		Quantity:  quantity,
+		UnitPrice: entities.MustParseMoney(unitPrice),
+	}
+}
+
+func TestCreate_WithLineItems(t *testing.T) {
+	repo := &repositories.OrderRepository{Db: pgtest.Open(t), TenantID: testTenant}
+	userID := utils.CreateNewUUID().String()
+	first, second := newDetail(2, "50.00"), newDetail(1, "19.99")
+	second.DiscountAmount = entities.MustParseMoney("5.00")
+	second.TaxAmount = entities.MustParseMoney("2.55")
+
+	id, err := repo.Create(newOrderRequest(userID, first, second))
+	require.NoError(t, err)
+
+	order, err := repo.GetByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, id, order.ID)
+	assert.Equal(t, userID, order.UserID)
+	assert.Equal(t, entities.OrderStatusPending, order.Status)
+	assert.Equal(t, "USD", order.Currency)
+	assert.Equal(t, entities.MustParseMoney("119.99"), order.TotalPrice)
+	assert.Equal(t, 1, order.Version)
+
+	// The line items went through the order_detail_type array of orders_insert
+	details, err := repo.GetOrderDetails(id)
+	require.NoError(t, err)
+	require.Len(t, details, 2)
+	byProduct := map[string]entities.OrderDetail{}
+	for _, detail := range details {
+		assert.Equal(t, id, detail.OrderID)
+		byProduct[detail.ProductID] = detail
+	}
+	assert.Equal(t, 2, byProduct[first.ProductID].Quantity)
+	assert.Equal(t, entities.MustParseMoney("50.00"), byProduct[first.ProductID].UnitPrice)
+	assert.Equal(t, entities.MustParseMoney("100.00"), byProduct[first.ProductID].TotalPrice)
+	assert.True(t, byProduct[first.ProductID].DiscountAmount.IsZero())
+	assert.Equal(t, entities.MustParseMoney("19.99"), byProduct[second.ProductID].TotalPrice)
+	assert.Equal(t, entities.MustParseMoney("5.00"), byProduct[second.ProductID].DiscountAmount)
+	assert.Equal(t, entities.MustParseMoney("2.55"), byProduct[second.ProductID].TaxAmount)
+}
+
+func TestGetAllOrders_Pagination(t *testing.T) {
+	repo := &repositories.OrderRepository{Db: pgtest.Open(t), TenantID: testTenant}
+	userID := utils.CreateNewUUID().String()
+
+	total := repositories.PAGE_SIZE + 5
+	var ids []string
+	for i := 0; i < total; i++ {
+		id, err := repo.Create(newOrderRequest(userID, newDetail(1, "10.00")))
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	// The orders of other users and tenants are left out
+	_, err := repo.Create(newOrderRequest(utils.CreateNewUUID().String(), newDetail(1, "10.00")))
+	require.NoError(t, err)
+	other := repo.ForTenant("globex")
+	_, err = other.Create(newOrderRequest(userID, newDetail(1, "10.00")))
+	require.NoError(t, err)
+
+	first, err := repo.GetAllOrders(1, entities.OrderFilter{UserID: userID})
+	require.NoError(t, err)
+	assert.Len(t, first, repositories.PAGE_SIZE)
+	// Newest first
+	assert.Equal(t, ids[total-1], first[0].ID)
+
+	second, err := repo.GetAllOrders(2, entities.OrderFilter{UserID: userID})
+	require.NoError(t, err)
+	assert.Len(t, second, 5)
+	assert.Equal(t, ids[0], second[4].ID)
+
+	third, err := repo.GetAllOrders(3, entities.OrderFilter{UserID: userID})
+	require.NoError(t, err)
+	assert.Empty(t, third)
+
+	seen := map[string]bool{}
+	for _, order := range append(first, second...) {
+		assert.False(t, seen[order.ID], "order %s listed twice", order.ID)
+		seen[order.ID] = true
+	}
+	assert.Len(t, seen, total)
+
+	small, err := repo.GetAllOrders(2, entities.OrderFilter{UserID: userID, PageSize: 10})
+	require.NoError(t, err)
+	assert.Len(t, small, 10)
+	assert.Equal(t, ids[total-11], small[0].ID)
+}
+
+func TestGetAllOrders_StatusFilter(t *testing.T) {
+	repo := &repositories.OrderRepository{Db: pgtest.Open(t), TenantID: testTenant}
+	userID := utils.CreateNewUUID().String()
+
+	pending, err := repo.Create(newOrderRequest(userID, newDetail(1, "10.00")))
+	require.NoError(t, err)
+	completed, err := repo.Create(newOrderRequest(userID, newDetail(1, "10.00")))
+	require.NoError(t, err)
+	_, err = repo.UpdateStatus(completed, entities.OrderStatusCompleted, 0, "")
+	require.NoError(t, err)
+
+	orders, err := repo.GetAllOrders(1, entities.OrderFilter{UserID: userID, Status: entities.OrderStatusPending})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, pending, orders[0].ID)
+}
+
+func TestUpdateStatus(t *testing.T) {
+	repo := &repositories.OrderRepository{Db: pgtest.Open(t), TenantID: testTenant}
+	id, err := repo.Create(newOrderRequest(utils.CreateNewUUID().String(), newDetail(1, "10.00")))
+	require.NoError(t, err)
+
+	order, err := repo.UpdateStatus(id, entities.OrderStatusProcessing, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, entities.OrderStatusProcessing, order.Status)
+	// The orders_bump_version trigger bumped the version
+	assert.Equal(t, 2, order.Version)
+
+	// The client read version 1, the order changed since
+	_, err = repo.UpdateStatus(id, entities.OrderStatusCompleted, 1, "")
+	assert.ErrorIs(t, err, apperrors.ErrVersionMismatch)
+
+	order, err = repo.GetByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, entities.OrderStatusProcessing, order.Status)
+
+	history, err := repo.GetOrderHistory(id)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, entities.HistoryStatusChanged, history[0].Event)
+	assert.JSONEq(t, `{"from":1,"to":2}`, string(history[0].Details))
+}
+
+func TestUpdateStatusBulk_RecordsHistory(t *testing.T) {
+	repo := &repositories.OrderRepository{Db: pgtest.Open(t), TenantID: testTenant}
+	userID := utils.CreateNewUUID().String()
+	id, err := repo.Create(newOrderRequest(userID, newDetail(1, "10.00")))
+	require.NoError(t, err)
+
+	updates := []entities.StatusUpdate{{ID: id, Status: entities.OrderStatusCancelled}}
+	results, err := repo.UpdateStatusBulk(updates, true, userID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.BulkItemSuccess, results[0].Status)
+
+	history, err := repo.GetOrderHistory(id)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, entities.HistoryStatusChanged, history[0].Event)
+	assert.JSONEq(t, `{"from":1,"to":4}`, string(history[0].Details))
+	assert.Equal(t, userID, history[0].ActorID)
+}
+
+func TestNotFound(t *testing.T) {
+	repo := &repositories.OrderRepository{Db: pgtest.Open(t), TenantID: testTenant}
+	missing := utils.CreateNewUUID().String()
+
+	order, err := repo.GetByID(missing)
+	require.NoError(t, err)
+	assert.Empty(t, order.ID)
+
+	_, err = repo.GetOrderDetails(missing)
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotFound)
+
+	_, err = repo.UpdateStatus(missing, entities.OrderStatusCompleted, 0, "")
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotFound)
+
+	orders, err := repo.GetAllOrders(1, entities.OrderFilter{UserID: utils.CreateNewUUID().String()})
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+
+	// The orders of another tenant are not found either
+	id, err := repo.ForTenant("globex").Create(newOrderRequest(utils.CreateNewUUID().String(), newDetail(1, "10.00")))
+	require.NoError(t, err)
+	order, err = repo.GetByID(id)
+	require.NoError(t, err)
+	assert.Empty(t, order.ID)
+}
+
+func TestArchivedRedemptionsCount(t *testing.T) {
+	db := pgtest.Open(t)
+	repo := &repositories.OrderRepository{Db: db, TenantID: testTenant}
+	promotions := &promotionrepo.PromotionRepository{Db: db, TenantID: testTenant}
+	promotionID, err := promotions.Create(&entities.Promotion{Code: "ONCE", Type: entities.PromotionPercentage, Percent: 10, MaxRedemptionsPerUser: 1, Active: true})
+	require.NoError(t, err)
+	userID := utils.CreateNewUUID().String()
+
+	request := newOrderRequest(userID, newDetail(1, "10.00"))
+	request.PromotionID = promotionID
+	id, err := repo.Create(request)
+	require.NoError(t, err)
+	_, err = repo.UpdateStatus(id, entities.OrderStatusCompleted, 0, "")
+	require.NoError(t, err)
+	archived, err := repo.ArchiveOrders(time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{id}, archived)
+
+	// The redemption moved to the archive with its order, it still counts
+	_, err = repo.Create(request)
+	assert.ErrorIs(t, err, apperrors.ErrPromotionLimitReached)
+}
+
+func TestAddOrderItem_PromotionRedeemed(t *testing.T) {
+	db := pgtest.Open(t)
+	repo := &repositories.OrderRepository{Db: db, TenantID: testTenant}
+	promotions := &promotionrepo.PromotionRepository{Db: db, TenantID: testTenant}
+	promotionID, err := promotions.Create(&entities.Promotion{Code: "LOCKED", Type: entities.PromotionPercentage, Percent: 10, Active: true})
+	require.NoError(t, err)
+
+	request := newOrderRequest(utils.CreateNewUUID().String(), newDetail(1, "10.00"))
+	request.PromotionID = promotionID
+	id, err := repo.Create(request)
+	require.NoError(t, err)
+
+	// The discount was computed from the line items the order was created with
+	item := &entities.OrderItemRequest{ProductID: utils.CreateNewUUID().String(), Quantity: 1, UnitPrice: entities.MustParseMoney("5.00")}
+	_, err = repo.AddOrderItem(id, item, 0, "")
+	assert.ErrorIs(t, err, apperrors.ErrItemsLocked)
+}
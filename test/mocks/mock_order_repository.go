@@ -1,7 +1,10 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -10,8 +13,8 @@ type MockOrderRepository struct {
 }
 
 // Mock implementation for GetAllOrders
-func (m *MockOrderRepository) GetAllOrders(page int, userID string) ([]*entities.Order, error) {
-	args := m.Called(page, userID)
+func (m *MockOrderRepository) GetAllOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	args := m.Called(page, filter)
 	return args.Get(0).([]*entities.Order), args.Error(1)
 }
 
@@ -28,7 +31,101 @@ func (m *MockOrderRepository) Create(orderRequest *entities.OrderRequest) (strin
 }
 
 // Mock implementation for UpdateStatus
-func (m *MockOrderRepository) UpdateStatus(id string, status int) (*entities.Order, error) {
-	args := m.Called(id, status)
+func (m *MockOrderRepository) UpdateStatus(id string, status int, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(id, status, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+// Mock implementation for CreateBulk
+func (m *MockOrderRepository) CreateBulk(orders []*entities.OrderRequest, atomic bool) ([]entities.BulkItemResult, error) {
+	args := m.Called(orders, atomic)
+	return args.Get(0).([]entities.BulkItemResult), args.Error(1)
+}
+
+// Mock implementation for UpdateStatusBulk
+func (m *MockOrderRepository) UpdateStatusBulk(updates []entities.StatusUpdate, atomic bool, actorID string) ([]entities.BulkItemResult, error) {
+	args := m.Called(updates, atomic, actorID)
+	return args.Get(0).([]entities.BulkItemResult), args.Error(1)
+}
+
+// Mock implementation for ExportOrders
+func (m *MockOrderRepository) ExportOrders(filter entities.OrderFilter, includeItems bool, fn func(*entities.Order) error) error {
+	args := m.Called(filter, includeItems, fn)
+	return args.Error(0)
+}
+
+// Mock implementation for GetOrderDetails
+func (m *MockOrderRepository) GetOrderDetails(orderID string) ([]entities.OrderDetail, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]entities.OrderDetail), args.Error(1)
+}
+
+// Mock implementation for AddOrderItem
+func (m *MockOrderRepository) AddOrderItem(orderID string, item *entities.OrderItemRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(orderID, item, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+// Mock implementation for UpdateOrderItem
+func (m *MockOrderRepository) UpdateOrderItem(orderID string, itemID string, update *entities.OrderItemUpdate, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(orderID, itemID, update, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+// Mock implementation for RemoveOrderItem
+func (m *MockOrderRepository) RemoveOrderItem(orderID string, itemID string, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(orderID, itemID, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+// Mock implementation for CancelStalePending
+func (m *MockOrderRepository) CancelStalePending(before time.Time, batchSize int, reason string) ([]string, error) {
+	args := m.Called(before, batchSize, reason)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// Mock implementation for SearchOrders
+func (m *MockOrderRepository) SearchOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	args := m.Called(page, filter)
+	return args.Get(0).([]*entities.Order), args.Error(1)
+}
+
+// Mock implementation for GetOrderSummary
+func (m *MockOrderRepository) GetOrderSummary(filter entities.OrderFilter) (*entities.OrderSummary, error) {
+	args := m.Called(filter)
+	return args.Get(0).(*entities.OrderSummary), args.Error(1)
+}
+
+// Mock implementation for ForceStatus
+func (m *MockOrderRepository) ForceStatus(id string, status int, reason string, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(id, status, reason, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+// Mock implementation for GetOrderHistory
+func (m *MockOrderRepository) GetOrderHistory(orderID string) ([]entities.OrderHistory, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]entities.OrderHistory), args.Error(1)
+}
+
+// Mock implementation for DeleteOrder
+func (m *MockOrderRepository) DeleteOrder(id string, expectedVersion int, actorID string) error {
+	args := m.Called(id, expectedVersion, actorID)
+	return args.Error(0)
+}
+
+// Mock implementation for ArchiveOrders
+func (m *MockOrderRepository) ArchiveOrders(before time.Time, batchSize int) ([]string, error) {
+	args := m.Called(before, batchSize)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// Mock implementation for GetArchivedByID
+func (m *MockOrderRepository) GetArchivedByID(id string) (*entities.Order, error) {
+	args := m.Called(id)
 	return args.Get(0).(*entities.Order), args.Error(1)
 }
+
+func (m *MockOrderRepository) ForTenant(tenantID string) usecases.OrderRepository {
+	return m
+}
@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPrivacyRepository struct {
+	mock.Mock
+}
+
+// Mock implementation for ExportUserData
+func (m *MockPrivacyRepository) ExportUserData(userID string) ([]entities.UserDataOrder, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]entities.UserDataOrder), args.Error(1)
+}
+
+// Mock implementation for EraseUser
+func (m *MockPrivacyRepository) EraseUser(userID string, pseudonym string, entry *entities.ErasureRecord) ([]string, error) {
+	args := m.Called(userID, pseudonym, entry)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// Mock implementation for GetErasures
+func (m *MockPrivacyRepository) GetErasures(subjectHash string) ([]entities.ErasureRecord, error) {
+	args := m.Called(subjectHash)
+	return args.Get(0).([]entities.ErasureRecord), args.Error(1)
+}
+
+func (m *MockPrivacyRepository) ForTenant(tenantID string) usecases.PrivacyRepository {
+	return m
+}
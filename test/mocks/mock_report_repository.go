@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReportRepository struct {
+	mock.Mock
+}
+
+// Mock implementation for OrdersByPeriod
+func (m *MockReportRepository) OrdersByPeriod(filter entities.ReportFilter) ([]entities.OrdersReportRow, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]entities.OrdersReportRow), args.Error(1)
+}
+
+// Mock implementation for StatusCounts
+func (m *MockReportRepository) StatusCounts(filter entities.ReportFilter) ([]entities.StatusReportRow, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]entities.StatusReportRow), args.Error(1)
+}
+
+// Mock implementation for TopProducts
+func (m *MockReportRepository) TopProducts(filter entities.ReportFilter) ([]entities.ProductReportRow, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]entities.ProductReportRow), args.Error(1)
+}
+
+func (m *MockReportRepository) ForTenant(tenantID string) usecases.ReportRepository {
+	return m
+}
@@ -0,0 +1,231 @@
+// Package pgtest runs the repository tests against a throwaway Postgres server started from the local
+// Postgres binaries, with the migrations of the service applied. Nothing is downloaded: the tests are
+// skipped when no binaries are found, unless PGTEST_REQUIRED=1 makes them fail instead.
+package pgtest
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// The role the tests connect as. Like the service, it is not a superuser, so the row level security policies apply.
+const appRole = "appuser"
+
+// The database the migrations are applied to once, copied for every test.
+const templateDatabase = "orders_template"
+
+// server is a Postgres server running from a temporary directory.
+type server struct {
+	binDir string
+	dir    string
+	port   int
+}
+
+var (
+	startOnce sync.Once
+	started   *server
+	startErr  error
+	databases atomic.Int64
+)
+
+// Open returns a connection to a new database holding the schema of the service, as a role without
+// superuser rights. The server is started on the first call and serves the whole test binary, call
+// Stop from TestMain to stop it.
+// The test is skipped when no Postgres binaries are found: set POSTGRES_BIN_DIR to the directory
+// holding initdb and pg_ctl when they are not in the PATH or a usual install location.
+// With PGTEST_REQUIRED=1, as in CI, the test fails instead, so that the suite cannot pass without running.
+func Open(t *testing.T) *sql.DB {
+	t.Helper()
+	startOnce.Do(func() { started, startErr = start() })
+	if startErr != nil {
+		if os.Getenv("PGTEST_REQUIRED") == "1" {
+			t.Fatalf("postgres not available: %v", startErr)
+		}
+		t.Skipf("postgres not available: %v", startErr)
+	}
+
+	name := fmt.Sprintf("test_%d", databases.Add(1))
+	if err := started.createDatabase(name); err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	db, err := sql.Open("postgres", started.dsn(appRole, name))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// Stop stops the server started by Open, if any, and removes its files.
+func Stop() {
+	if started == nil {
+		return
+	}
+	exec.Command(filepath.Join(started.binDir, "pg_ctl"), "stop", "-D", filepath.Join(started.dir, "data"), "-m", "immediate", "-w").Run()
+	os.RemoveAll(started.dir)
+	started = nil
+}
+
+// start creates a cluster in a temporary directory, starts it on a free port and applies the migrations.
+func start() (*server, error) {
+	binDir, err := findBinaries()
+	if err != nil {
+		return nil, err
+	}
+	if os.Geteuid() == 0 {
+		return nil, fmt.Errorf("initdb cannot run as root")
+	}
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "pgtest")
+	if err != nil {
+		return nil, err
+	}
+	s := &server{binDir: binDir, dir: dir, port: port}
+
+	data := filepath.Join(dir, "data")
+	if err := s.run("initdb", "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-locale", "-N"); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	// fsync is off, the data does not outlive the tests
+	options := fmt.Sprintf("-F -p %d -h 127.0.0.1 -k %s", port, dir)
+	if err := s.run("pg_ctl", "start", "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-w", "-o", options); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	started = s
+	if err := s.prepare(); err != nil {
+		Stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// prepare creates the role of the service and the template database with the migrations applied, as that role.
+func (s *server) prepare() error {
+	admin, err := sql.Open("postgres", s.dsn("postgres", "postgres"))
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+	if _, err := admin.Exec(`CREATE ROLE ` + appRole + ` LOGIN`); err != nil {
+		return err
+	}
+	if _, err := admin.Exec(`CREATE DATABASE ` + templateDatabase + ` OWNER ` + appRole); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("postgres", s.dsn(appRole, templateDatabase))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	files, err := filepath.Glob(filepath.Join(migrationsDir(), "*.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		// Without bind parameters, a script of many statements runs in one round trip
+		if _, err := db.Exec(string(script)); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// createDatabase copies the template database.
+func (s *server) createDatabase(name string) error {
+	admin, err := sql.Open("postgres", s.dsn("postgres", "postgres"))
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+	// The sessions of the template may take a moment to end after their connections are closed
+	for attempt := 0; ; attempt++ {
+		_, err = admin.Exec(`CREATE DATABASE ` + name + ` TEMPLATE ` + templateDatabase + ` OWNER ` + appRole)
+		if err == nil || attempt == 50 || !strings.Contains(err.Error(), "being accessed by other users") {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (s *server) dsn(user string, database string) string {
+	return fmt.Sprintf("host=127.0.0.1 port=%d user=%s dbname=%s sslmode=disable", s.port, user, database)
+}
+
+// run runs a Postgres program, its output is returned with the error when it fails.
+func (s *server) run(program string, args ...string) error {
+	out, err := exec.Command(filepath.Join(s.binDir, program), args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", program, err, out)
+	}
+	return nil
+}
+
+// findBinaries returns the directory holding initdb and pg_ctl: POSTGRES_BIN_DIR, the PATH, or the
+// newest version in a usual install location.
+func findBinaries() (string, error) {
+	if dir := os.Getenv("POSTGRES_BIN_DIR"); dir != "" {
+		return dir, nil
+	}
+	if path, err := exec.LookPath("pg_ctl"); err == nil {
+		return filepath.Dir(path), nil
+	}
+	var candidates []string
+	for _, pattern := range []string{
+		"/usr/lib/postgresql/*/bin",
+		"/usr/pgsql-*/bin",
+		"/usr/local/pgsql/bin",
+		"/opt/homebrew/opt/postgresql*/bin",
+		"/usr/local/opt/postgresql*/bin",
+	} {
+		matches, _ := filepath.Glob(pattern)
+		candidates = append(candidates, matches...)
+	}
+	// Newest version first, e.g. /usr/lib/postgresql/16/bin before /usr/lib/postgresql/14/bin
+	sort.Sort(sort.Reverse(sort.StringSlice(candidates)))
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, "initdb")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no initdb and pg_ctl found, set POSTGRES_BIN_DIR")
+}
+
+// freePort returns a TCP port nothing listens on.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// migrationsDir returns the migrations directory of the repository.
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "migrations")
+}
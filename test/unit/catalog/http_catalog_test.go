@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/adapters/catalog"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const productID = "063d0ff7-e17e-4957-8d92-a988caeda8a1"
+
+func TestHTTPCatalog_GetProducts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path != "/products/"+productID {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"id": %q, "name": "Coffee mug", "price": 12.50, "currency": "usd", "active": true}`, productID)
+	}))
+	defer server.Close()
+
+	c := catalog.NewHTTPCatalog(server.URL+"/", time.Second)
+	products, err := c.GetProducts([]string{productID, "unknown"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, entities.MustParseMoney("12.50"), products[productID].Price)
+	assert.Equal(t, "USD", products[productID].Currency)
+
+	// Served from the cache, including the unknown product
+	_, err = c.GetProducts([]string{productID, "unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHTTPCatalog_CircuitBreaker(t *testing.T) {
+	var calls int32
+	healthy := atomic.Bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintf(w, `{"id": %q, "price": "1.00", "currency": "USD", "active": true}`, productID)
+	}))
+	defer server.Close()
+
+	c := catalog.NewHTTPCatalog(server.URL, time.Second)
+	c.FailureThreshold = 2
+	c.Cooldown = 50 * time.Millisecond
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GetProducts([]string{productID})
+		assert.ErrorIs(t, err, apperrors.ErrCatalogUnavailable)
+	}
+	// The third request failed fast
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// After the cooldown a probe closes the circuit again
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	products, err := c.GetProducts([]string{productID})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+}
+
+func TestHTTPCatalog_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	c := catalog.NewHTTPCatalog(server.URL, 20*time.Millisecond)
+	_, err := c.GetProducts([]string{productID})
+	assert.ErrorIs(t, err, apperrors.ErrCatalogUnavailable)
+}
+
+func TestLoadFileCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.json")
+	data := fmt.Sprintf(`[{"id": %q, "price": 3.99, "currency": "EUR", "active": true}]`, productID)
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	c, err := catalog.LoadFileCatalog(path)
+	assert.NoError(t, err)
+	products, err := c.GetProducts([]string{productID, "unknown"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, entities.MustParseMoney("3.99"), products[productID].Price)
+
+	_, err = catalog.LoadFileCatalog(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -17,10 +17,11 @@ func TestGetOrders_ValidRequest(t *testing.T) {
 	mockOrders := []*entities.Order{
 		{ID: "1", UserID: "user123", Status: 2},
 	}
-	mockRepo.On("GetAllOrders", 1, "user123").Return(mockOrders, nil)
+	filter := entities.OrderFilter{UserID: "user123"}
+	mockRepo.On("GetAllOrders", 1, filter).Return(mockOrders, nil)
 
 	// Call the usecase
-	orders, err := mockUsecase.GetOrders(1, "user123")
+	orders, err := mockUsecase.GetOrders(1, filter)
 
 	// Assertions
 	assert.NoError(t, err)
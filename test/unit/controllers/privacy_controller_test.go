@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/adapters/controllers"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/shayja/orders-service/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func privacyRouter(repo *mocks.MockPrivacyRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := &controllers.PrivacyController{PrivacyUsecase: &usecases.PrivacyUsecase{PrivacyRepo: repo}}
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", "123e4567-e89b-12d3-a456-426614174000")
+		c.Next()
+	})
+	r.GET("/users/:id/export", controller.ExportUserData)
+	r.POST("/users/:id/erasures", controller.EraseUser)
+	return r
+}
+
+func TestExportUserData_Bundle(t *testing.T) {
+	repo := new(mocks.MockPrivacyRepository)
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	repo.On("ExportUserData", userID).Return([]entities.UserDataOrder{{
+		Order:   entities.Order{ID: "6204037c-30e6-408b-8aaa-dd8219860b4b", UserID: userID, Currency: "USD"},
+		History: []entities.OrderHistory{},
+	}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/"+userID+"/export", nil)
+	w := httptest.NewRecorder()
+	privacyRouter(repo).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="user-`+userID+`.json"`, w.Header().Get("Content-Disposition"))
+	var bundle map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &bundle))
+	assert.Equal(t, userID, bundle["user_id"])
+	orders := bundle["orders"].([]interface{})
+	assert.Len(t, orders, 1)
+	// The fields of the order are inlined next to its history
+	assert.Equal(t, "6204037c-30e6-408b-8aaa-dd8219860b4b", orders[0].(map[string]interface{})["id"])
+	assert.Contains(t, orders[0], "history")
+}
+
+func TestEraseUser_Conflict(t *testing.T) {
+	repo := new(mocks.MockPrivacyRepository)
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	repo.On("EraseUser", userID, mock.Anything, mock.MatchedBy(func(entry *entities.ErasureRecord) bool {
+		return entry.ActorID == "123e4567-e89b-12d3-a456-426614174000"
+	})).Return([]string(nil), apperrors.ErrOrderOpen)
+	r := privacyRouter(repo)
+
+	// The reason is required
+	req, _ := http.NewRequest(http.MethodPost, "/users/"+userID+"/erasures", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, "/users/"+userID+"/erasures", bytes.NewBufferString(`{"reason": "Privacy request #1234"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
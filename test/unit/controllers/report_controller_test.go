@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/adapters/controllers"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/shayja/orders-service/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func reportRouter(repo *mocks.MockReportRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := &controllers.ReportController{ReportUsecase: &usecases.ReportUsecase{ReportRepo: repo}}
+	r := gin.New()
+	r.GET("/reports/orders", controller.GetOrdersReport)
+	r.GET("/reports/products", controller.GetProductReport)
+	return r
+}
+
+func TestGetOrdersReport_CSV(t *testing.T) {
+	repo := new(mocks.MockReportRepository)
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	// A date-only "to" includes the whole day
+	filter := entities.ReportFilter{From: from, To: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), Interval: entities.ReportMonth}
+	repo.On("OrdersByPeriod", filter).Return([]entities.OrdersReportRow{
+		{Period: from, Currency: "USD", Orders: 3, Revenue: entities.MustParseMoney("100"), AverageOrderValue: entities.MustParseMoney("33.33")},
+		{Period: from, Currency: "JPY", Orders: 1, Revenue: entities.MustParseMoney("1500"), AverageOrderValue: entities.MustParseMoney("1500")},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/reports/orders?interval=month&from=2024-07-01&to=2024-07-31&format=csv", nil)
+	w := httptest.NewRecorder()
+	reportRouter(repo).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, "period,currency,orders,revenue,average_order_value\n"+
+		"2024-07-01T00:00:00Z,USD,3,100.00,33.33\n"+
+		"2024-07-01T00:00:00Z,JPY,1,1500,1500\n", w.Body.String())
+}
+
+func TestGetProductReport_InvalidQuery(t *testing.T) {
+	repo := new(mocks.MockReportRepository)
+	r := reportRouter(repo)
+
+	for _, query := range []string{"limit=ten", "sort=margin", "format=xml", "from=yesterday", "from=2024-07-02&to=2024-07-01"} {
+		req, _ := http.NewRequest(http.MethodGet, "/reports/products?"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	repo.AssertNotCalled(t, "TopProducts", mock.Anything)
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/adapters/controllers"
+	"github.com/shayja/orders-service/internal/adapters/middleware"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/shayja/orders-service/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type validationResponse struct {
+	Status string                   `json:"status"`
+	Errors []controllers.FieldError `json:"errors"`
+}
+
+// postOrder posts body to the create order endpoint, whose repository must not be reached.
+func postOrder(t *testing.T, body string) (int, validationResponse) {
+	return sendBody(t, http.MethodPost, "/order", body)
+}
+
+// sendBody sends body to one of the order endpoints taking a JSON body, whose repository must not be reached.
+func sendBody(t *testing.T, method string, path string, body string) (int, validationResponse) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := new(mocks.MockOrderRepository)
+	controller := &controllers.OrderController{OrderUsecase: &usecases.OrderUsecase{OrderRepo: mockRepo}}
+	r := gin.New()
+	r.Use(middleware.BodyLimitMiddleware(512))
+	r.POST("/order", controller.Create)
+	r.POST("/order/bulk", controller.CreateBulk)
+	r.PUT("/order/status/bulk", controller.UpdateStatusBulk)
+
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res validationResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockRepo.AssertNotCalled(t, "CreateBulk", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateStatusBulk", mock.Anything, mock.Anything)
+	return w.Code, res
+}
+
+func TestCreate_FieldErrors(t *testing.T) {
+	code, res := postOrder(t, `{
+		"user_id": "not-a-uuid",
+		"currency": "US",
+		"order_details": [
+			{"product_id": "063d0ff7-e17e-4957-8d92-a988caeda8a1", "quantity": 0, "unit_price": 10},
+			{"product_id": "bad", "quantity": 1, "unit_price": -1}
+		]
+	}`)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "failed", res.Status)
+	assert.ElementsMatch(t, []controllers.FieldError{
+		{Field: "user_id", Code: controllers.CodeInvalidUUID, Message: "must be a UUID"},
+		{Field: "currency", Code: controllers.CodeInvalidValue, Message: "must be 3 characters long"},
+		{Field: "order_details[0].quantity", Code: controllers.CodeRequired, Message: "is required"},
+		{Field: "order_details[1].product_id", Code: controllers.CodeInvalidUUID, Message: "must be a UUID"},
+		{Field: "order_details[1].unit_price", Code: controllers.CodeTooSmall, Message: "must be at least 0"},
+	}, res.Errors)
+}
+
+func TestCreate_DuplicateProducts(t *testing.T) {
+	code, res := postOrder(t, `{
+		"user_id": "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
+		"order_details": [
+			{"product_id": "063d0ff7-e17e-4957-8d92-a988caeda8a1", "quantity": 1, "unit_price": 10},
+			{"product_id": "063d0ff7-e17e-4957-8d92-a988caeda8a1", "quantity": 2, "unit_price": 10}
+		]
+	}`)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []controllers.FieldError{
+		{Field: "order_details", Code: controllers.CodeDuplicate, Message: "must not repeat the same product_id"},
+	}, res.Errors)
+}
+
+func TestCreate_MissingLineItems(t *testing.T) {
+	code, res := postOrder(t, `{"user_id": "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "order_details": []}`)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []controllers.FieldError{
+		{Field: "order_details", Code: controllers.CodeTooSmall, Message: "must have a length of at least 1"},
+	}, res.Errors)
+}
+
+func TestCreateBulk_FieldErrors(t *testing.T) {
+	code, res := sendBody(t, http.MethodPost, "/order/bulk", `{
+		"orders": [
+			{"user_id": "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "order_details": [
+				{"product_id": "063d0ff7-e17e-4957-8d92-a988caeda8a1", "quantity": 1, "unit_price": 10}
+			]},
+			{"user_id": "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "order_details": [
+				{"product_id": "063d0ff7-e17e-4957-8d92-a988caeda8a1", "quantity": 1, "unit_price": 99999999999999}
+			]}
+		]
+	}`)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []controllers.FieldError{
+		{Field: "orders[1].order_details[0].unit_price", Code: controllers.CodeTooLarge, Message: "must be at most 1000000"},
+	}, res.Errors)
+}
+
+func TestUpdateStatusBulk_FieldErrors(t *testing.T) {
+	code, res := sendBody(t, http.MethodPut, "/order/status/bulk", `{
+		"updates": [
+			{"id": "6204037c-30e6-408b-8aaa-dd8219860b4b", "status": 2},
+			{"id": "bad", "status": 9}
+		]
+	}`)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.ElementsMatch(t, []controllers.FieldError{
+		{Field: "updates[1].id", Code: controllers.CodeInvalidUUID, Message: "must be a UUID"},
+		{Field: "updates[1].status", Code: controllers.CodeTooLarge, Message: "must be at most 4"},
+	}, res.Errors)
+}
+
+func TestCreate_MalformedBody(t *testing.T) {
+	code, res := postOrder(t, `{"user_id": "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "discount": 10}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []controllers.FieldError{{Field: "discount", Code: controllers.CodeUnknownField, Message: "is not a known field"}}, res.Errors)
+
+	code, res = postOrder(t, `{"user_id": "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "status": "pending"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []controllers.FieldError{{Field: "status", Code: controllers.CodeInvalidType, Message: "must be a number"}}, res.Errors)
+
+	code, res = postOrder(t, `{"user_id": `)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, controllers.CodeInvalidJSON, res.Errors[0].Code)
+
+	code, res = postOrder(t, `{"user_id": "`+strings.Repeat("a", 600)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+	assert.Equal(t, "failed", res.Status)
+}
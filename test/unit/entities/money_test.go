@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]int64{
+		"0":       0,
+		"12":      1200,
+		"12.5":    1250,
+		"12.50":   1250,
+		"12.500":  1250,
+		"-3.05":   -305,
+		".99":     99,
+		"1000000": 100000000,
+	}
+	for input, cents := range cases {
+		m, err := entities.ParseMoney(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, cents, m.Cents(), input)
+	}
+}
+
+func TestParseMoney_Invalid(t *testing.T) {
+	_, err := entities.ParseMoney("1.005")
+	assert.ErrorIs(t, err, entities.ErrMoneyScale)
+
+	for _, input := range []string{"", ".", "abc", "1e3", "1.2.3", "--1"} {
+		_, err := entities.ParseMoney(input)
+		assert.ErrorIs(t, err, entities.ErrMoneyFormat, input)
+	}
+}
+
+func TestMoney_JSONRoundTrip(t *testing.T) {
+	var detail entities.OrderDetail
+	err := json.Unmarshal([]byte(`{"unit_price": 0.1, "total_price": "0.30"}`), &detail)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), detail.UnitPrice.Cents())
+	assert.Equal(t, detail.UnitPrice.Mul(3), detail.TotalPrice)
+
+	out, err := json.Marshal(detail.UnitPrice)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.10", string(out))
+
+	err = json.Unmarshal([]byte(`{"unit_price": 10.999}`), &detail)
+	assert.ErrorIs(t, err, entities.ErrMoneyScale)
+}
+
+func TestMoney_SQLRoundTrip(t *testing.T) {
+	var m entities.Money
+	assert.NoError(t, m.Scan([]byte("99999999.99")))
+	assert.Equal(t, int64(9999999999), m.Cents())
+
+	value, err := m.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "99999999.99", value)
+
+	assert.NoError(t, m.Scan(nil))
+	assert.True(t, m.IsZero())
+}
+
+func TestMoney_MulFraction(t *testing.T) {
+	// 17% of 10.05 = 1.7085, rounded to 1.71
+	assert.Equal(t, int64(171), entities.MustParseMoney("10.05").MulFraction(17, 100).Cents())
+	// Halves are rounded away from zero
+	assert.Equal(t, int64(3), entities.MoneyFromCents(5).MulFraction(1, 2).Cents())
+	assert.Equal(t, int64(-3), entities.MoneyFromCents(-5).MulFraction(1, 2).Cents())
+	assert.Equal(t, int64(2), entities.MoneyFromCents(5).MulFraction(4, 10).Cents())
+}
+
+func TestMoney_Checked(t *testing.T) {
+	total, err := entities.MustParseMoney("10.05").MulChecked(3)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.MustParseMoney("30.15"), total)
+	share, err := entities.MustParseMoney("10.05").MulFractionChecked(17, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(171), share.Cents())
+
+	huge := entities.MoneyFromCents(math.MaxInt64 / 2)
+	_, err = huge.MulChecked(3)
+	assert.ErrorIs(t, err, entities.ErrMoneyRange)
+	_, err = huge.MulFractionChecked(3, 4)
+	assert.ErrorIs(t, err, entities.ErrMoneyRange)
+	_, err = entities.MoneyFromCents(math.MinInt64).MulChecked(-1)
+	assert.ErrorIs(t, err, entities.ErrMoneyRange)
+	_, err = entities.MustParseMoney("10.05").MulFractionChecked(17, 0)
+	assert.ErrorIs(t, err, entities.ErrMoneyDivision)
+}
+
+func TestMoney_StringFixed(t *testing.T) {
+	m := entities.MustParseMoney("-1234.5")
+	assert.Equal(t, "-1234.50", m.String())
+	assert.Equal(t, "-1234", m.StringFixed(0))
+}
@@ -0,0 +1,61 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/shayja/orders-service/internal/adapters/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+const secretKey = "test-secret"
+
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+	assert.NoError(t, err)
+	return token
+}
+
+// serve runs a request carrying token through the middleware and returns the status and tenant of the request.
+func serve(token string, defaultTenant string) (int, string) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var tenantID string
+	r.GET("/", middleware.AuthMiddleware(secretKey, defaultTenant), func(c *gin.Context) {
+		tenantID = c.GetString("tenantID")
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, tenantID
+}
+
+func TestAuthMiddleware_Tenant(t *testing.T) {
+	user := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+
+	code, tenantID := serve(signToken(t, jwt.MapClaims{"sub": user, "tenant_id": "acme"}), "default")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "acme", tenantID)
+
+	// Tokens without a tenant belong to the default tenant
+	code, tenantID = serve(signToken(t, jwt.MapClaims{"sub": user}), "default")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "default", tenantID)
+
+	// and are rejected when there is none
+	code, _ = serve(signToken(t, jwt.MapClaims{"sub": user}), "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// The tenant of every row cannot be claimed
+	code, _ = serve(signToken(t, jwt.MapClaims{"sub": user, "tenant_id": "*"}), "default")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = serve(signToken(t, jwt.MapClaims{"sub": user, "tenant_id": 42}), "default")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,139 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shayja/orders-service/internal/adapters/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore is a rate limit store that is unavailable.
+type failingStore struct{}
+
+func (failingStore) Take(key string, limit middleware.RateLimit) (middleware.RateLimitResult, error) {
+	return middleware.RateLimitResult{}, errors.New("store unavailable")
+}
+
+// rateLimitedRouter serves GET and POST / behind the rate limit, as the user set in the X-User header if any.
+func rateLimitedRouter(limit middleware.RateLimit, store middleware.RateLimitStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	setUser := func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set("userID", user)
+		}
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/", setUser, middleware.RateLimitMiddleware("orders", limit, store), ok)
+	r.POST("/", setUser, middleware.RateLimitMiddleware("orders", limit, store), ok)
+	return r
+}
+
+func request(r *gin.Engine, method string, user string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestParseRateLimit(t *testing.T) {
+	limit, err := middleware.ParseRateLimit("60/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, middleware.RateLimit{Requests: 60, Period: time.Minute}, limit)
+
+	limit, err = middleware.ParseRateLimit("")
+	assert.NoError(t, err)
+	assert.Zero(t, limit)
+
+	for _, value := range []string{"60", "0/1m", "-1/1m", "60/0s", "60/minute"} {
+		_, err := middleware.ParseRateLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &middleware.MemoryRateLimitStore{Now: func() time.Time { return now }}
+	limit := middleware.RateLimit{Requests: 2, Period: 10 * time.Second}
+
+	res, _ := store.Take("key", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+	assert.Equal(t, 5*time.Second, res.Reset)
+
+	res, _ = store.Take("key", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res, _ = store.Take("key", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 5*time.Second, res.RetryAfter)
+	assert.Equal(t, 10*time.Second, res.Reset)
+
+	// Other keys have their own bucket
+	res, _ = store.Take("other", limit)
+	assert.True(t, res.Allowed)
+
+	// A token is earned every 5 seconds
+	now = now.Add(5 * time.Second)
+	res, _ = store.Take("key", limit)
+	assert.True(t, res.Allowed)
+	res, _ = store.Take("key", limit)
+	assert.False(t, res.Allowed)
+
+	// Buckets never hold more than the limit
+	now = now.Add(time.Hour)
+	res, _ = store.Take("key", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	r := rateLimitedRouter(middleware.RateLimit{Requests: 2, Period: time.Minute}, &middleware.MemoryRateLimitStore{})
+
+	w := request(r, http.MethodPost, "user-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusOK, request(r, http.MethodPost, "user-1").Code)
+	w = request(r, http.MethodPost, "user-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	// Each user and each route has its own bucket
+	assert.Equal(t, http.StatusOK, request(r, http.MethodPost, "user-2").Code)
+	assert.Equal(t, http.StatusOK, request(r, http.MethodGet, "user-1").Code)
+
+	// Requests without a user are limited by client IP
+	assert.Equal(t, http.StatusOK, request(r, http.MethodPost, "").Code)
+	assert.Equal(t, http.StatusOK, request(r, http.MethodPost, "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(r, http.MethodPost, "").Code)
+}
+
+func TestRateLimitMiddleware_Disabled(t *testing.T) {
+	r := rateLimitedRouter(middleware.RateLimit{}, failingStore{})
+
+	w := request(r, http.MethodPost, "user-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitMiddleware_StoreFailure(t *testing.T) {
+	r := rateLimitedRouter(middleware.RateLimit{Requests: 1, Period: time.Minute}, failingStore{})
+
+	// Requests are let through when the limits cannot be checked
+	assert.Equal(t, http.StatusOK, request(r, http.MethodPost, "user-1").Code)
+	assert.Equal(t, http.StatusOK, request(r, http.MethodPost, "user-1").Code)
+}
@@ -0,0 +1,143 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchOrders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	productID := "063d0ff7-e17e-4957-8d92-a988caeda8a1"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM orders o WHERE o.deleted_at IS NULL AND o.status = \\$1 AND EXISTS \\(SELECT 1 FROM order_details od WHERE od.order_id = o.id AND od.product_id = \\$2\\) "+
+		"AND o.id::text LIKE \\$3 ORDER BY o.created_at DESC, o.id OFFSET \\$4 LIMIT \\$5").
+		WithArgs(entities.OrderStatusPending, productID, "6204%", 50, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+			"subtotal", "discount_total", "tax_total", "shipping_total"}).
+			AddRow("6204037c-30e6-408b-8aaa-dd8219860b4b", "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "150.00", 1, time.Now(), time.Now(), 1, "USD", "150.00", "0.00", "0.00", "0.00"))
+	mock.ExpectCommit()
+
+	orders, err := repo.SearchOrders(2, entities.OrderFilter{Status: entities.OrderStatusPending, ProductID: productID, IDPrefix: "6204", PageSize: 50})
+
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", orders[0].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForceStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	actorID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(entities.OrderStatusCompleted, 4))
+	mock.ExpectExec("UPDATE orders SET status = \\$2 WHERE id = \\$1").
+		WithArgs(orderID, entities.OrderStatusProcessing).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_history").
+		WithArgs(orderID, entities.HistoryStatusForced, []byte(`{"from":3,"reason":"Shipment lost","to":2}`), actorID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT \\* FROM get_order\\(\\$1\\)").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+			"subtotal", "discount_total", "tax_total", "shipping_total"}).
+			AddRow(orderID, actorID, "150.00", 2, time.Now(), time.Now(), 5, "USD", "150.00", "0.00", "0.00", "0.00"))
+	mock.ExpectCommit()
+
+	order, err := repo.ForceStatus(orderID, entities.OrderStatusProcessing, "Shipment lost", 4, actorID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.OrderStatusProcessing, order.Status)
+	assert.Equal(t, 5, order.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForceStatus_SameStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(entities.OrderStatusCancelled, 2))
+	mock.ExpectRollback()
+
+	_, err = repo.ForceStatus(orderID, entities.OrderStatusCancelled, "Duplicate order", 0, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	// Soft deleted orders are found too
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM orders WHERE id = \\$1\\)").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT id, order_id, event, details, actor_id, created_at FROM order_history\\s+WHERE order_id = \\$1 ORDER BY created_at, id").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "event", "details", "actor_id", "created_at"}).
+			AddRow("2f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2", orderID, entities.HistoryAutoCancelled, []byte(`{"reason":"not paid"}`), nil, time.Now()).
+			AddRow("3f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2", orderID, entities.HistoryStatusForced, []byte(`{"from":4,"to":1}`), "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", time.Now()))
+	mock.ExpectCommit()
+
+	history, err := repo.GetOrderHistory(orderID)
+
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Empty(t, history[0].ActorID)
+	assert.JSONEq(t, `{"reason":"not paid"}`, string(history[0].Details))
+	assert.Equal(t, "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", history[1].ActorID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOrderHistory_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM orders WHERE id = \\$1\\)").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+
+	_, err = repo.GetOrderHistory(orderID)
+
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,223 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	actorID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(entities.OrderStatusCompleted, 3))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM order_sagas WHERE order_id = \\$1 AND state = 'order_created'\\)").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("UPDATE orders SET deleted_at = CURRENT_TIMESTAMP WHERE id = \\$1").
+		WithArgs(orderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_history").
+		WithArgs(orderID, entities.HistoryDeleted, []byte(`{"status":3}`), actorID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.DeleteOrder(orderID, 3, actorID)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteOrder_Open(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(entities.OrderStatusProcessing, 2))
+	mock.ExpectRollback()
+
+	err = repo.DeleteOrder(orderID, 0, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrOrderOpen)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteOrder_SagaOpen(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	// The stock of the order is not confirmed yet
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(entities.OrderStatusCompleted, 2))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM order_sagas").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err = repo.DeleteOrder(orderID, 0, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrOrderOpen)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestArchiveOrders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	before := time.Now().AddDate(-1, 0, 0)
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+		WithArgs(repositories.ARCHIVE_LOCK_KEY).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT o.id FROM orders o\\s+WHERE o.created_at < \\$1 AND \\(o.status IN \\(\\$2, \\$3\\) OR o.deleted_at IS NOT NULL\\).*FOR UPDATE SKIP LOCKED").
+		WithArgs(before, entities.OrderStatusCompleted, entities.OrderStatusCancelled, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("order-1"))
+	mock.ExpectExec("INSERT INTO orders_archive SELECT o.\\*, CURRENT_TIMESTAMP FROM orders o WHERE o.id = ANY\\(\\$1\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, table := range []string{"order_details", "order_history", "order_fulfilment", "order_addresses", "payments",
+		"payment_events", "returns", "return_items", "promotion_redemptions"} {
+		mock.ExpectExec("INSERT INTO " + table + "_archive SELECT \\* FROM " + table + " WHERE ").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("DELETE FROM order_history WHERE order_id = ANY\\(\\$1\\)").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM orders WHERE id = ANY\\(\\$1\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").
+		WithArgs(repositories.ARCHIVE_LOCK_KEY).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ids, err := repo.ArchiveOrders(before, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-1"}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestArchiveOrders_Locked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	// Another replica holds the lock
+	mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+		WithArgs(repositories.ARCHIVE_LOCK_KEY).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	_, err = repo.ArchiveOrders(time.Now(), 100)
+
+	assert.ErrorIs(t, err, apperrors.ErrJobRunning)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllOrders_IncludeArchived(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	archivedAt := time.Now()
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM orders o WHERE o.deleted_at IS NULL AND o.user_id = \\$1 AND o.status = \\$2\\s+UNION ALL.*"+
+		"FROM orders_archive a WHERE a.deleted_at IS NULL AND a.user_id = \\$3 AND a.status = \\$4\\s+\\) AS all_orders\\s+"+
+		"ORDER BY created_at DESC, id OFFSET \\$5 LIMIT \\$6").
+		WithArgs(userID, entities.OrderStatusCompleted, userID, entities.OrderStatusCompleted, 0, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+			"subtotal", "discount_total", "tax_total", "shipping_total", "archived_at"}).
+			AddRow("6204037c-30e6-408b-8aaa-dd8219860b4b", userID, "150.00", 3, time.Now(), time.Now(), 4, "USD", "150.00", "0.00", "0.00", "0.00", nil).
+			AddRow("7204037c-30e6-408b-8aaa-dd8219860b4b", userID, "80.00", 3, time.Now(), time.Now(), 3, "USD", "80.00", "0.00", "0.00", "0.00", archivedAt))
+	mock.ExpectCommit()
+
+	orders, err := repo.GetAllOrders(1, entities.OrderFilter{UserID: userID, Status: entities.OrderStatusCompleted, IncludeArchived: true})
+
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+	assert.Nil(t, orders[0].ArchivedAt)
+	assert.NotNil(t, orders[1].ArchivedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchivedByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM orders_archive WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+			"subtotal", "discount_total", "tax_total", "shipping_total", "archived_at"}).
+			AddRow(orderID, "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "150.00", 3, time.Now(), time.Now(), 4, "USD", "150.00", "0.00", "0.00", "0.00", time.Now()))
+	mock.ExpectCommit()
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM order_details_archive WHERE order_id = \\$1 ORDER BY created_at, id").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "unit_price", "total_price", "discount_amount", "tax_amount", "created_at", "updated_at"}).
+			AddRow("2f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2", orderID, "063d0ff7-e17e-4957-8d92-a988caeda8a1", 3, "50.00", "150.00", "0.00", "0.00", time.Now(), time.Now()))
+	mock.ExpectCommit()
+
+	order, err := repo.GetArchivedByID(orderID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, orderID, order.ID)
+	assert.NotNil(t, order.ArchivedAt)
+	assert.Len(t, order.OrderDetails, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchivedByID_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM orders_archive WHERE id = \\$1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	order, err := repo.GetArchivedByID("6204037c-30e6-408b-8aaa-dd8219860b4b")
+
+	assert.NoError(t, err)
+	assert.Empty(t, order.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,134 @@
+package repositories_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/cache"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderCache_GetByID(t *testing.T) {
+	repo := new(mocks.MockOrderRepository)
+	cached := cache.NewOrderRepository(repo, &cache.MemoryBackend{}, time.Minute)
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	repo.On("GetByID", orderID).Return(&entities.Order{ID: orderID, Status: entities.OrderStatusPending, Version: 1}, nil).Once()
+
+	order, err := cached.GetByID(orderID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, order.Version)
+
+	// Callers get a copy, changing it does not change the cached order
+	order.Status = entities.OrderStatusCancelled
+	order, err = cached.GetByID(orderID)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.OrderStatusPending, order.Status)
+
+	assert.Equal(t, int64(1), cached.Metrics.Hits.Value())
+	assert.Equal(t, int64(1), cached.Metrics.Misses.Value())
+	assert.JSONEq(t, `{"hits": 1, "misses": 1}`, cached.Metrics.String())
+	repo.AssertExpectations(t)
+}
+
+func TestOrderCache_InvalidatedByWrites(t *testing.T) {
+	repo := new(mocks.MockOrderRepository)
+	cached := cache.NewOrderRepository(repo, &cache.MemoryBackend{}, time.Minute)
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	repo.On("GetByID", orderID).Return(&entities.Order{ID: orderID, Status: entities.OrderStatusPending, Version: 1}, nil).Once()
+	repo.On("UpdateStatus", orderID, entities.OrderStatusProcessing, 1, "").Return(&entities.Order{ID: orderID, Status: entities.OrderStatusProcessing, Version: 2}, nil)
+	repo.On("GetByID", orderID).Return(&entities.Order{ID: orderID, Status: entities.OrderStatusProcessing, Version: 2}, nil).Once()
+
+	_, err := cached.GetByID(orderID)
+	assert.NoError(t, err)
+	_, err = cached.UpdateStatus(orderID, entities.OrderStatusProcessing, 1, "")
+	assert.NoError(t, err)
+
+	order, err := cached.GetByID(orderID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, order.Version)
+	assert.Equal(t, int64(2), cached.Metrics.Misses.Value())
+	repo.AssertExpectations(t)
+}
+
+func TestOrderCache_NotFoundAndErrorsNotCached(t *testing.T) {
+	repo := new(mocks.MockOrderRepository)
+	cached := cache.NewOrderRepository(repo, &cache.MemoryBackend{}, time.Minute)
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	repo.On("GetByID", orderID).Return(&entities.Order{}, nil).Once()
+	repo.On("GetByID", orderID).Return((*entities.Order)(nil), errors.New("connection refused")).Once()
+	repo.On("GetByID", orderID).Return(&entities.Order{ID: orderID}, nil).Once()
+
+	order, err := cached.GetByID(orderID)
+	assert.NoError(t, err)
+	assert.Empty(t, order.ID)
+	_, err = cached.GetByID(orderID)
+	assert.Error(t, err)
+	order, err = cached.GetByID(orderID)
+	assert.NoError(t, err)
+	assert.Equal(t, orderID, order.ID)
+	repo.AssertExpectations(t)
+}
+
+func TestOrderCache_TenantScope(t *testing.T) {
+	repo := new(mocks.MockOrderRepository)
+	cached := cache.NewOrderRepository(repo, &cache.MemoryBackend{}, time.Minute)
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	repo.On("GetByID", orderID).Return(&entities.Order{ID: orderID}, nil).Twice()
+
+	_, err := cached.ForTenant("acme").GetByID(orderID)
+	assert.NoError(t, err)
+	// Read in the scope of another tenant, the order is looked up again
+	_, err = cached.ForTenant("globex").GetByID(orderID)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestOrderCache_Singleflight(t *testing.T) {
+	repo := new(mocks.MockOrderRepository)
+	cached := cache.NewOrderRepository(repo, &cache.MemoryBackend{}, time.Minute)
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	repo.On("GetByID", orderID).Return(&entities.Order{ID: orderID}, nil).After(50 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order, err := cached.GetByID(orderID)
+			assert.NoError(t, err)
+			assert.Equal(t, orderID, order.ID)
+		}()
+	}
+	wg.Wait()
+
+	repo.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestMemoryBackend_EvictionAndExpiry(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	backend := &cache.MemoryBackend{Capacity: 2, Now: func() time.Time { return now }}
+	entry := func(id string) cache.Entry { return cache.Entry{Order: &entities.Order{ID: id}} }
+
+	backend.Set("a", entry("a"), time.Minute)
+	backend.Set("b", entry("b"), time.Minute)
+	// a is now the most recently used, so c evicts b
+	_, ok := backend.Get("a")
+	assert.True(t, ok)
+	backend.Set("c", entry("c"), 10*time.Second)
+	_, ok = backend.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, backend.Len())
+
+	now = now.Add(10 * time.Second)
+	_, ok = backend.Get("c")
+	assert.False(t, ok)
+	_, ok = backend.Get("a")
+	assert.True(t, ok)
+
+	backend.Delete("a")
+	assert.Equal(t, 0, backend.Len())
+}
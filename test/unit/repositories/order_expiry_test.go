@@ -0,0 +1,68 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelStalePending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	before := time.Now().Add(-time.Hour)
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+		WithArgs(repositories.AUTO_CANCEL_LOCK_KEY).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+
+	// A full batch, then the last one
+	expectTenantTx(mock)
+	mock.ExpectQuery("WITH stale AS \\(.*FOR UPDATE SKIP LOCKED\\s*\\)\\s*UPDATE orders SET status = \\$4").
+		WithArgs(entities.OrderStatusPending, before, 2, entities.OrderStatusCancelled).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("order-1").AddRow("order-2"))
+	mock.ExpectExec("INSERT INTO order_history \\(order_id, event, details\\) SELECT id, \\$2, \\$3 FROM unnest\\(\\$1::uuid\\[\\]\\)").
+		WithArgs(sqlmock.AnyArg(), entities.HistoryAutoCancelled, []byte(`{"reason":"not paid"}`)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	expectTenantTx(mock)
+	mock.ExpectQuery("WITH stale AS").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("order-3"))
+	mock.ExpectExec("INSERT INTO order_history").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").
+		WithArgs(repositories.AUTO_CANCEL_LOCK_KEY).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ids, err := repo.CancelStalePending(before, 2, "not paid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-1", "order-2", "order-3"}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelStalePending_Locked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	// Another replica holds the lock
+	mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	_, err = repo.CancelStalePending(time.Now(), 100, "not paid")
+
+	assert.ErrorIs(t, err, apperrors.ErrJobRunning)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
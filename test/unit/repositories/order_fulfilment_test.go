@@ -0,0 +1,131 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate_WritesDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	orderRequest := &entities.OrderRequest{
+		UserID:         "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
+		Status:         1,
+		DeliveryMethod: entities.DeliveryStandard,
+		ShippingAddress: &entities.Address{
+			Name: "Jane Doe", Line1: "1 Main Street", City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US",
+		},
+		OrderDetails: []entities.OrderDetail{
+			{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 1, UnitPrice: entities.MustParseMoney("50.00")},
+		},
+	}
+
+	expectTenantTx(mock)
+	mock.ExpectExec("CALL orders_insert").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_fulfilment \\(order_id, delivery_method\\) VALUES \\(\\$1, \\$2\\)").
+		WithArgs(sqlmock.AnyArg(), entities.DeliveryStandard).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_addresses").
+		WithArgs(sqlmock.AnyArg(), entities.AddressShipping, "Jane Doe", "1 Main Street", nil, "Springfield", "IL", "62701", "US", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, err = repo.Create(orderRequest)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFulfilment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.FulfilmentRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT f.delivery_method, .* FROM order_fulfilment f\\s+LEFT JOIN order_addresses a ON a.order_id = f.order_id\\s+WHERE f.order_id = \\$1").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_method", "tracking_number", "carrier",
+			"kind", "name", "line1", "line2", "city", "region", "postal_code", "country", "phone"}).
+			AddRow("express", "1Z999", "UPS", "shipping", "Jane Doe", "1 Main Street", nil, "Berlin", nil, "10115", "DE", nil).
+			AddRow("express", "1Z999", "UPS", "billing", "ACME GmbH", "2 Side Street", "Floor 3", "Berlin", nil, "10117", "DE", "+49 30 1234567"))
+	mock.ExpectCommit()
+
+	fulfilment, err := repo.GetFulfilment(orderID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.DeliveryExpress, fulfilment.DeliveryMethod)
+	assert.Equal(t, "UPS", fulfilment.Carrier)
+	assert.Equal(t, "10115", fulfilment.ShippingAddress.PostalCode)
+	assert.Equal(t, "Floor 3", fulfilment.BillingAddress.Line2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetFulfilment_PendingOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.FulfilmentRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(entities.OrderStatusPending, 1))
+	mock.ExpectRollback()
+
+	_, err = repo.SetFulfilment(orderID, &entities.FulfilmentRequest{TrackingNumber: "1Z999", Carrier: "UPS"}, 0, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotFulfillable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetFulfilment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.FulfilmentRepository{Db: db, TenantID: testTenant}
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(entities.OrderStatusProcessing, 2))
+	mock.ExpectExec("INSERT INTO order_fulfilment \\(order_id, tracking_number, carrier\\) VALUES \\(\\$1, \\$2, \\$3\\)\\s+ON CONFLICT \\(order_id\\) DO UPDATE").
+		WithArgs(orderID, "1Z999", "UPS").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE orders SET updated_at = CURRENT_TIMESTAMP WHERE id = \\$1").
+		WithArgs(orderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_history").
+		WithArgs(orderID, entities.HistoryFulfilmentSet, sqlmock.AnyArg(), "451fa817-41f4-40cf-8dc2-c9f22aa98a4f").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT \\* FROM get_order\\(\\$1\\)").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+			"subtotal", "discount_total", "tax_total", "shipping_total"}).
+			AddRow(orderID, "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "150.00", 2, time.Now(), time.Now(), 3, "USD", "150.00", "0.00", "0.00", "0.00"))
+	mock.ExpectCommit()
+
+	order, err := repo.SetFulfilment(orderID, &entities.FulfilmentRequest{TrackingNumber: "1Z999", Carrier: "UPS"}, 2, "451fa817-41f4-40cf-8dc2-c9f22aa98a4f")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, order.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
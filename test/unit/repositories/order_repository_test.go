@@ -1,12 +1,14 @@
 package repositories_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
 	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,7 +17,7 @@ func TestGetAllOrders(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := repositories.OrderRepository{Db: db}
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
 
 	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
 	page := 1
@@ -23,21 +25,24 @@ func TestGetAllOrders(t *testing.T) {
 		{
 			ID:         "6204037c-30e6-408b-8aaa-dd8219860b4b",
 			UserID:     userID,
-			TotalPrice: 100.0,
+			TotalPrice: entities.MustParseMoney("100.00"),
 			Status:     1,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at"}).
-		AddRow(expectedOrders[0].ID, userID, expectedOrders[0].TotalPrice, expectedOrders[0].Status, expectedOrders[0].CreatedAt, expectedOrders[0].UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+		"subtotal", "discount_total", "tax_total", "shipping_total"}).
+		AddRow(expectedOrders[0].ID, userID, expectedOrders[0].TotalPrice.String(), expectedOrders[0].Status, expectedOrders[0].CreatedAt, expectedOrders[0].UpdatedAt, 1, "EUR", "100.00", "0.00", "0.00", "0.00")
 
-	mock.ExpectQuery("SELECT \\* FROM get_user_orders\\(\\$1, \\$2, \\$3\\)").
-		WithArgs(userID, 0, repositories.PAGE_SIZE).
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT \\* FROM get_user_orders\\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7\\)").
+		WithArgs(userID, 0, repositories.PAGE_SIZE, nil, "EUR", nil, nil).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
-	orders, err := repo.GetAllOrders(page, userID)
+	orders, err := repo.GetAllOrders(page, entities.OrderFilter{UserID: userID, Currency: "EUR"})
 
 	assert.NoError(t, err)
 	assert.Len(t, orders, len(expectedOrders))
@@ -50,24 +55,27 @@ func TestGetByID(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := repositories.OrderRepository{Db: db}
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
 
 	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
 	expectedOrder := &entities.Order{
 		ID:         orderID,
 		UserID:     "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
-		TotalPrice: 150.0,
+		TotalPrice: entities.MustParseMoney("150.00"),
 		Status:     2,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at"}).
-		AddRow(expectedOrder.ID, expectedOrder.UserID, expectedOrder.TotalPrice, expectedOrder.Status, expectedOrder.CreatedAt, expectedOrder.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+		"subtotal", "discount_total", "tax_total", "shipping_total"}).
+		AddRow(expectedOrder.ID, expectedOrder.UserID, expectedOrder.TotalPrice.String(), expectedOrder.Status, expectedOrder.CreatedAt, expectedOrder.UpdatedAt, 1, "EUR", "100.00", "0.00", "0.00", "0.00")
 
+	expectTenantTx(mock)
 	mock.ExpectQuery("SELECT \\* FROM get_order\\(\\$1\\)").
 		WithArgs(orderID).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
 	order, err := repo.GetByID(orderID)
 
@@ -81,21 +89,24 @@ func TestCreate(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := repositories.OrderRepository{Db: db}
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
 
 	orderRequest := &entities.OrderRequest{
 		UserID:      "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
-		TotalPrice:  200.0,
+		TotalPrice:  entities.MustParseMoney("200.00"),
 		Status:      1,
 		OrderDetails: []entities.OrderDetail{
-			{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 2, UnitPrice: 50.0, TotalPrice: 100.0},
+			{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 2, UnitPrice: entities.MustParseMoney("50.00"), TotalPrice: entities.MustParseMoney("100.00")},
 		},
 	}
 	//newID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
 
-	mock.ExpectExec("CALL orders_insert\\(\\$1, \\$2, \\$3, \\$4::order_detail_type\\[\\], \\$5\\)").
-		WithArgs(orderRequest.UserID, orderRequest.TotalPrice, orderRequest.Status, sqlmock.AnyArg(), sqlmock.AnyArg()).
+	expectTenantTx(mock)
+	mock.ExpectExec("CALL orders_insert\\(\\$1, \\$2, \\$3, \\$4::order_detail_type\\[\\], \\$5, \\$6, \\$7, \\$8, \\$9, \\$10\\)").
+		WithArgs(orderRequest.UserID, orderRequest.TotalPrice, orderRequest.Status, sqlmock.AnyArg(), sqlmock.AnyArg(), orderRequest.Currency,
+			orderRequest.Subtotal, orderRequest.DiscountTotal, orderRequest.TaxTotal, orderRequest.ShippingTotal).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	id, err := repo.Create(orderRequest)
 
@@ -104,38 +115,466 @@ func TestCreate(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestCreate_RedeemsPromotion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	promotionID := "3f1c2a7e-8d4b-4e0f-9a55-1b2c3d4e5f60"
+	orderRequest := &entities.OrderRequest{
+		UserID:      "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
+		Status:      1,
+		Currency:    "USD",
+		PromotionID: promotionID,
+	}
+
+	expectTenantTx(mock)
+	mock.ExpectExec("CALL orders_insert").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT max_redemptions, max_redemptions_per_user, redemptions FROM promotions\\s+WHERE id = \\$1 AND active.*FOR UPDATE").
+		WithArgs(promotionID).
+		WillReturnRows(sqlmock.NewRows([]string{"max_redemptions", "max_redemptions_per_user", "redemptions"}).AddRow(100, 1, 10))
+	mock.ExpectQuery("SELECT \\(SELECT COUNT\\(\\*\\) FROM promotion_redemptions WHERE promotion_id = \\$1 AND user_id = \\$2\\)\\s+"+
+		"\\+ \\(SELECT COUNT\\(\\*\\) FROM promotion_redemptions_archive WHERE promotion_id = \\$1 AND user_id = \\$2\\)").
+		WithArgs(promotionID, orderRequest.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("UPDATE promotions SET redemptions = redemptions \\+ 1 WHERE id = \\$1").
+		WithArgs(promotionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO promotion_redemptions \\(promotion_id, user_id, order_id\\)").
+		WithArgs(promotionID, orderRequest.UserID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	id, err := repo.Create(orderRequest)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_PromotionLimitReached(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	promotionID := "3f1c2a7e-8d4b-4e0f-9a55-1b2c3d4e5f60"
+	orderRequest := &entities.OrderRequest{UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", Status: 1, PromotionID: promotionID}
+
+	expectTenantTx(mock)
+	mock.ExpectExec("CALL orders_insert").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT max_redemptions, max_redemptions_per_user, redemptions FROM promotions").
+		WithArgs(promotionID).
+		WillReturnRows(sqlmock.NewRows([]string{"max_redemptions", "max_redemptions_per_user", "redemptions"}).AddRow(10, 0, 10))
+	// The order insert is rolled back together with the failed redemption
+	mock.ExpectRollback()
+
+	id, err := repo.Create(orderRequest)
+
+	assert.ErrorIs(t, err, apperrors.ErrPromotionLimitReached)
+	assert.Empty(t, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_ArchivedRedemptionsCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	promotionID := "3f1c2a7e-8d4b-4e0f-9a55-1b2c3d4e5f60"
+	orderRequest := &entities.OrderRequest{UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", Status: 1, PromotionID: promotionID}
+
+	expectTenantTx(mock)
+	mock.ExpectExec("CALL orders_insert").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT max_redemptions, max_redemptions_per_user, redemptions FROM promotions").
+		WithArgs(promotionID).
+		WillReturnRows(sqlmock.NewRows([]string{"max_redemptions", "max_redemptions_per_user", "redemptions"}).AddRow(0, 1, 1))
+	// The only redemption of the user belongs to an archived order
+	mock.ExpectQuery("FROM promotion_redemptions_archive WHERE promotion_id = \\$1 AND user_id = \\$2").
+		WithArgs(promotionID, orderRequest.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	id, err := repo.Create(orderRequest)
+
+	assert.ErrorIs(t, err, apperrors.ErrPromotionLimitReached)
+	assert.Empty(t, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_AttachesSaga(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	sagaID := "8a0d5c1e-2f3b-4c6d-9e7f-0a1b2c3d4e5f"
+	orderRequest := &entities.OrderRequest{UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", Status: 1, Currency: "USD", SagaID: sagaID}
+
+	// The reservation was released in the meantime, the order must not be stored
+	expectTenantTx(mock)
+	mock.ExpectExec("CALL orders_insert").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE order_sagas SET order_id = \\$1, state = 'order_created'.*WHERE id = \\$2 AND state = 'reserved'").
+		WithArgs(sqlmock.AnyArg(), sagaID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	id, err := repo.Create(orderRequest)
+
+	assert.Error(t, err)
+	assert.Empty(t, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUpdateStatus(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := repositories.OrderRepository{Db: db}
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
 
 	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
 	newStatus := 3
 	expectedOrder := &entities.Order{
 		ID:         orderID,
 		UserID:     "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
-		TotalPrice: 150.0,
+		TotalPrice: entities.MustParseMoney("150.00"),
 		Status:     newStatus,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
 
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(2, 1))
 	mock.ExpectExec("CALL orders_update_status\\(\\$1, \\$2\\)").
 		WithArgs(orderID, newStatus).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_history \\(order_id, event, details, actor_id\\)").
+		WithArgs(orderID, entities.HistoryStatusChanged, []byte(`{"from":2,"to":3}`), expectedOrder.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at"}).
-		AddRow(expectedOrder.ID, expectedOrder.UserID, expectedOrder.TotalPrice, expectedOrder.Status, expectedOrder.CreatedAt, expectedOrder.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+		"subtotal", "discount_total", "tax_total", "shipping_total"}).
+		AddRow(expectedOrder.ID, expectedOrder.UserID, expectedOrder.TotalPrice.String(), expectedOrder.Status, expectedOrder.CreatedAt, expectedOrder.UpdatedAt, 2, "USD", "150.00", "0.00", "0.00", "0.00")
 
+	expectTenantTx(mock)
 	mock.ExpectQuery("SELECT \\* FROM get_order\\(\\$1\\)").
 		WithArgs(orderID).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
-	order, err := repo.UpdateStatus(orderID, newStatus)
+	order, err := repo.UpdateStatus(orderID, newStatus, 1, expectedOrder.UserID)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedOrder.Status, order.Status)
+	assert.Equal(t, 2, order.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStatus_VersionMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(1, 2))
+	mock.ExpectRollback()
+
+	order, err := repo.UpdateStatus(orderID, 3, 1, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrVersionMismatch)
+	assert.Nil(t, order)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateBulk_Atomic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	orders := []*entities.OrderRequest{
+		{
+			UserID:     "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
+			TotalPrice: entities.MustParseMoney("100.00"),
+			Status:     1,
+			OrderDetails: []entities.OrderDetail{
+				{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 2, UnitPrice: entities.MustParseMoney("50.00")},
+			},
+		},
+		{
+			UserID:     "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
+			TotalPrice: entities.MustParseMoney("20.00"),
+			Status:     1,
+			OrderDetails: []entities.OrderDetail{
+				{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 1, UnitPrice: entities.MustParseMoney("20.00")},
+			},
+		},
+	}
+
+	expectTenantTx(mock)
+	mock.ExpectExec("INSERT INTO orders \\(id, user_id, total_price, status, currency, subtotal, discount_total, tax_total, shipping_total\\) VALUES \\(\\$1, .*, \\$9\\), \\(\\$10, .*, \\$18\\)$").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO order_details \\(order_id, product_id, quantity, unit_price, discount_amount, tax_amount\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6\\), \\(\\$7, \\$8, \\$9, \\$10, \\$11, \\$12\\)").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	results, err := repo.CreateBulk(orders, true)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	for _, res := range results {
+		assert.Equal(t, entities.BulkItemSuccess, res.Status)
+		assert.NotEmpty(t, res.ID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateBulk_BestEffortFallback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	orders := []*entities.OrderRequest{
+		{UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", TotalPrice: entities.MustParseMoney("10.00"), Status: 1},
+		{UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f", TotalPrice: entities.MustParseMoney("20.00"), Status: 1},
+	}
+
+	expectTenantTx(mock)
+	mock.ExpectExec("SAVEPOINT bulk_batch").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO orders").WillReturnError(errors.New("batch failed"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_batch").WillReturnResult(sqlmock.NewResult(0, 0))
+	// First order succeeds
+	mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	// Second order fails
+	mock.ExpectExec("SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO orders").WillReturnError(errors.New("bad row"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	results, err := repo.CreateBulk(orders, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.BulkItemSuccess, results[0].Status)
+	assert.Equal(t, entities.BulkItemFailed, results[1].Status)
+	assert.Equal(t, "bad row", results[1].Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStatusBulk_RecordsHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	actorID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	updates := []entities.StatusUpdate{
+		{ID: "6204037c-30e6-408b-8aaa-dd8219860b4b", Status: 2},
+		{ID: "7204037c-30e6-408b-8aaa-dd8219860b4b", Status: 3},
+	}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("WITH v\\(id, status\\) AS \\(VALUES .*\\),\\s+prev AS \\(SELECT o.id, o.status FROM orders o JOIN v ON v.id = o.id\\s+WHERE o.deleted_at IS NULL ORDER BY o.id FOR UPDATE OF o\\)\\s+UPDATE orders AS o SET status = v.status").
+		WithArgs(updates[0].ID, updates[0].Status, updates[1].ID, updates[1].Status).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(updates[0].ID, 1))
+	mock.ExpectExec("INSERT INTO order_history \\(order_id, event, details, actor_id\\)").
+		WithArgs(updates[0].ID, entities.HistoryStatusChanged, []byte(`{"from":1,"to":2}`), actorID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	results, err := repo.UpdateStatusBulk(updates, false, actorID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.BulkItemSuccess, results[0].Status)
+	assert.Equal(t, entities.BulkItemFailed, results[1].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStatusBulk_AtomicMissingOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	updates := []entities.StatusUpdate{
+		{ID: "6204037c-30e6-408b-8aaa-dd8219860b4b", Status: 2},
+		{ID: "7204037c-30e6-408b-8aaa-dd8219860b4b", Status: 3},
+	}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("UPDATE orders AS o SET status = v.status").
+		WithArgs(updates[0].ID, updates[0].Status, updates[1].ID, updates[1].Status).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(updates[0].ID, 1))
+	mock.ExpectRollback()
+
+	results, err := repo.UpdateStatusBulk(updates, true, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotFound)
+	assert.Nil(t, results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportOrders_WithItems(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	now := time.Now()
+
+	columns := []string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+		"subtotal", "discount_total", "tax_total", "shipping_total",
+		"id", "product_id", "quantity", "unit_price", "total_price", "discount_amount", "tax_amount", "created_at", "updated_at"}
+	rows := sqlmock.NewRows(columns).
+		AddRow(orderID, userID, "150.00", 1, now, now, 1, "USD", "150.00", "0.00", "0.00", "0.00", "a1", "063d0ff7-e17e-4957-8d92-a988caeda8a1", 1, "50.00", "50.00", "0.00", "0.00", now, now).
+		AddRow(orderID, userID, "150.00", 1, now, now, 1, "USD", "150.00", "0.00", "0.00", "0.00", "a2", "163d0ff7-e17e-4957-8d92-a988caeda8a1", 2, "50.00", "100.00", "0.00", "0.00", now, now)
+
+	expectTenantTx(mock)
+	mock.ExpectExec("DECLARE export_cursor NO SCROLL CURSOR FOR SELECT .* FROM orders o LEFT JOIN order_details d ON d.order_id = o.id WHERE o.deleted_at IS NULL AND o.user_id = \\$1 AND o.status = \\$2").
+		WithArgs(userID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FETCH 500 FROM export_cursor").WillReturnRows(rows)
+	mock.ExpectExec("CLOSE export_cursor").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var exported []*entities.Order
+	err = repo.ExportOrders(entities.OrderFilter{UserID: userID, Status: 1}, true, func(order *entities.Order) error {
+		exported = append(exported, order)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, exported, 1)
+	assert.Len(t, exported[0].OrderDetails, 2)
+	assert.Equal(t, 2, exported[0].OrderDetails[1].Quantity)
+	assert.Equal(t, entities.MustParseMoney("100.00"), exported[0].OrderDetails[1].TotalPrice)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddOrderItem(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	item := &entities.OrderItemRequest{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 2, UnitPrice: entities.MustParseMoney("25.00"), TaxAmount: entities.MustParseMoney("10.00")}
+	now := time.Now()
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(1, 3))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM order_sagas WHERE order_id = \\$1 AND state = 'order_created'\\)\\s+OR EXISTS \\(SELECT 1 FROM promotion_redemptions WHERE order_id = \\$1\\)").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("INSERT INTO order_details \\(order_id, product_id, quantity, unit_price, discount_amount, tax_amount\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6\\) RETURNING id").
+		WithArgs(orderID, item.ProductID, item.Quantity, item.UnitPrice, item.DiscountAmount, item.TaxAmount).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"))
+	mock.ExpectExec("UPDATE orders AS o\\s+SET subtotal = t.subtotal, discount_total = t.discount_total, tax_total = t.tax_total,\\s+total_price = t.subtotal - t.discount_total \\+ t.tax_total \\+ o.shipping_total").
+		WithArgs(orderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_history \\(order_id, event, details, actor_id\\)").
+		WithArgs(orderID, entities.HistoryItemAdded, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT \\* FROM get_order\\(\\$1\\)").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+			"subtotal", "discount_total", "tax_total", "shipping_total"}).
+			AddRow(orderID, userID, "160.00", 1, now, now, 4, "USD", "150.00", "0.00", "10.00", "0.00"))
+	mock.ExpectCommit()
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT id, order_id, product_id, quantity, unit_price, total_price, discount_amount, tax_amount, created_at, updated_at\\s+FROM order_details WHERE order_id = \\$1").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "unit_price", "total_price", "discount_amount", "tax_amount", "created_at", "updated_at"}).
+			AddRow("9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", orderID, item.ProductID, 2, "25.00", "50.00", "0.00", "10.00", now, now))
+	mock.ExpectCommit()
+
+	order, err := repo.AddOrderItem(orderID, item, 3, userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, order.Version)
+	assert.Equal(t, entities.MustParseMoney("10.00"), order.TaxTotal)
+	assert.Len(t, order.OrderDetails, 1)
+	assert.Equal(t, entities.MustParseMoney("10.00"), order.OrderDetails[0].TaxAmount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddOrderItem_StockReserved(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	item := &entities.OrderItemRequest{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 1, UnitPrice: entities.MustParseMoney("25.00")}
+
+	// The reservation holds the stock of the items the order was created with
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(1, 3))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM order_sagas WHERE order_id = \\$1 AND state = 'order_created'\\)\\s+OR EXISTS \\(SELECT 1 FROM promotion_redemptions WHERE order_id = \\$1\\)").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	order, err := repo.AddOrderItem(orderID, item, 3, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrItemsLocked)
+	assert.Nil(t, order)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddOrderItem_VersionMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	item := &entities.OrderItemRequest{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 1, UnitPrice: entities.MustParseMoney("25.00")}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, version FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(1, 5))
+	mock.ExpectRollback()
+
+	order, err := repo.AddOrderItem(orderID, item, 4, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrVersionMismatch)
+	assert.Nil(t, order)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
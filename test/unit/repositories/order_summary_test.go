@@ -0,0 +1,66 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrderSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT o.currency, o.status, COUNT\\(\\*\\), SUM\\(o.total_price\\), MAX\\(o.created_at\\)\\s+FROM orders o WHERE o.deleted_at IS NULL AND o.user_id = \\$1 AND o.created_at >= \\$2\\s+"+
+		"GROUP BY o.currency, o.status").
+		WithArgs(userID, from).
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "status", "count", "sum", "max"}).
+			AddRow("EUR", entities.OrderStatusCompleted, 2, "80.00", last.AddDate(0, -1, 0)).
+			AddRow("USD", entities.OrderStatusPending, 1, "20.00", last).
+			AddRow("USD", entities.OrderStatusCompleted, 3, "150.00", last.AddDate(0, -2, 0)).
+			AddRow("USD", entities.OrderStatusCancelled, 1, "99.00", last.AddDate(0, -3, 0)))
+	mock.ExpectCommit()
+
+	summary, err := repo.GetOrderSummary(entities.OrderFilter{UserID: userID, From: &from})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, summary.Orders)
+	assert.Equal(t, last, *summary.LastOrderAt)
+	assert.Equal(t, []entities.CurrencyAmount{
+		{Currency: "EUR", Amount: entities.MustParseMoney("80.00")},
+		{Currency: "USD", Amount: entities.MustParseMoney("170.00")},
+	}, summary.TotalSpent)
+	assert.Equal(t, entities.OrderStatusCounts{Pending: 1, Completed: 5, Cancelled: 1}, summary.ByStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOrderSummary_NoOrders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db, TenantID: testTenant}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM orders o WHERE o.deleted_at IS NULL AND o.user_id = \\$1").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "status", "count", "sum", "max"}))
+	mock.ExpectCommit()
+
+	summary, err := repo.GetOrderSummary(entities.OrderFilter{UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summary.Orders)
+	assert.Nil(t, summary.LastOrderAt)
+	assert.Empty(t, summary.TotalSpent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
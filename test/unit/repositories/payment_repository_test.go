@@ -0,0 +1,108 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/payments"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCapturedPayment() *entities.Payment {
+	return &entities.Payment{
+		ID:               "2c9a6f4e-1b7d-4e3a-8f5c-6d0e9b1a2c3d",
+		OrderID:          "6204037c-30e6-408b-8aaa-dd8219860b4b",
+		Provider:         "fake",
+		Status:           entities.PaymentCaptured,
+		AuthorizedAmount: entities.MustParseMoney("150.00"),
+		CapturedAmount:   entities.MustParseMoney("150.00"),
+	}
+}
+
+func TestPaymentUpdate_CaptureMovesOrderToProcessing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PaymentRepository{Db: db, TenantID: testTenant}
+	payment := newCapturedPayment()
+
+	expectTenantTx(mock)
+	mock.ExpectExec("UPDATE payments SET status = \\$1, .* WHERE id = \\$7 AND status = \\$8").
+		WithArgs(entities.PaymentCaptured, payment.AuthorizedAmount, payment.CapturedAmount, payment.RefundedAmount, payment.FailedAmount,
+			nil, payment.ID, entities.PaymentAuthorized).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE orders SET status = \\$1 WHERE id = \\$2 AND status = \\$3 AND deleted_at IS NULL").
+		WithArgs(entities.OrderStatusProcessing, payment.OrderID, entities.OrderStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_history").
+		WithArgs(payment.OrderID, entities.HistoryPaymentCaptured, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Update(payment, entities.PaymentAuthorized)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentUpdate_StateChanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PaymentRepository{Db: db, TenantID: testTenant}
+
+	expectTenantTx(mock)
+	mock.ExpectExec("UPDATE payments").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.Update(newCapturedPayment(), entities.PaymentAuthorized)
+
+	assert.ErrorIs(t, err, apperrors.ErrPaymentState)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentApplyEvent_Duplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PaymentRepository{Db: db, TenantID: testTenant}
+	payment := newCapturedPayment()
+
+	expectTenantTx(mock)
+	mock.ExpectExec("INSERT INTO payment_events \\(provider, event_id, payment_id\\) VALUES \\(\\$1, \\$2, \\$3\\) ON CONFLICT DO NOTHING").
+		WithArgs("fake", "evt_1", payment.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	applied, err := repo.ApplyEvent(payment, entities.PaymentAuthorized, "evt_1")
+
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentCreate_OrderAlreadyPaid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PaymentRepository{Db: db, TenantID: testTenant}
+	payment := newCapturedPayment()
+
+	// A concurrent checkout stored its authorization first
+	expectTenantTx(mock)
+	mock.ExpectQuery("INSERT INTO payments").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "payments_order_id_open_key"})
+	mock.ExpectRollback()
+
+	_, err = repo.Create(payment)
+
+	assert.ErrorIs(t, err, apperrors.ErrPaymentExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
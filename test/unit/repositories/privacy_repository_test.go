@@ -0,0 +1,147 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/privacy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportUserData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PrivacyRepository{Db: db, TenantID: testTenant}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	live := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	archived := "7204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM orders WHERE user_id = \\$1\\s+UNION ALL.*FROM orders_archive WHERE user_id = \\$1\\s+ORDER BY created_at, id").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+			"subtotal", "discount_total", "tax_total", "shipping_total", "deleted_at", "archived_at"}).
+			AddRow(archived, userID, "80.00", 3, time.Now(), time.Now(), 3, "USD", "80.00", "0.00", "0.00", "0.00", nil, time.Now()).
+			AddRow(live, userID, "150.00", 4, time.Now(), time.Now(), 2, "USD", "150.00", "0.00", "0.00", "0.00", time.Now(), nil))
+	mock.ExpectQuery("FROM order_details d JOIN orders o ON o.id = d.order_id WHERE o.user_id = \\$1\\s+UNION ALL.*FROM order_details_archive d").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "unit_price", "total_price", "discount_amount", "tax_amount", "created_at", "updated_at"}).
+			AddRow("2f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2", archived, "063d0ff7-e17e-4957-8d92-a988caeda8a1", 1, "80.00", "80.00", "0.00", "0.00", time.Now(), time.Now()))
+	mock.ExpectQuery("FROM order_addresses a JOIN orders o ON o.id = a.order_id WHERE o.user_id = \\$1\\s+UNION ALL.*FROM order_addresses_archive a").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "kind", "name", "line1", "line2", "city", "region", "postal_code", "country", "phone"}).
+			AddRow(live, entities.AddressShipping, "Jane Doe", "1 Main Street", nil, "Springfield", "IL", "62701", "US", nil))
+	mock.ExpectQuery("FROM order_history h JOIN orders o ON o.id = h.order_id WHERE o.user_id = \\$1\\s+UNION ALL.*FROM order_history_archive h").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "event", "details", "actor_id", "created_at"}).
+			AddRow("3f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2", live, entities.HistoryDeleted, []byte(`{"status":4}`), nil, time.Now()))
+	mock.ExpectCommit()
+
+	orders, err := repo.ExportUserData(userID)
+
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+	assert.NotNil(t, orders[0].ArchivedAt)
+	assert.Len(t, orders[0].OrderDetails, 1)
+	assert.Empty(t, orders[0].History)
+	assert.NotNil(t, orders[1].DeletedAt)
+	assert.Equal(t, "Jane Doe", orders[1].ShippingAddress.Name)
+	assert.Len(t, orders[1].History, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEraseUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PrivacyRepository{Db: db, TenantID: testTenant}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	pseudonym := "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+	actorID := "123e4567-e89b-12d3-a456-426614174000"
+	hash := entities.ErasureSubjectHash(userID)
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM orders WHERE user_id = \\$1 AND status IN \\(\\$2, \\$3\\) AND deleted_at IS NULL\\)").
+		WithArgs(userID, entities.OrderStatusPending, entities.OrderStatusProcessing).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	for _, suffix := range []string{"", "_archive"} {
+		mock.ExpectExec("UPDATE order_addresses"+suffix+"\\s+SET name = \\$2, line1 = \\$2, line2 = NULL, city = \\$2, region = NULL, postal_code = NULL, phone = NULL\\s+"+
+			"WHERE order_id IN \\(SELECT id FROM orders"+suffix+" WHERE user_id = \\$1\\)").
+			WithArgs(userID, repositories.ERASED).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("UPDATE returns" + suffix + " SET reason = NULL").
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE order_history"+suffix+" SET actor_id = \\$2 WHERE actor_id = \\$1").
+			WithArgs(userID, pseudonym).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectExec("UPDATE promotion_redemptions"+suffix+" SET user_id = \\$2 WHERE user_id = \\$1").
+			WithArgs(userID, pseudonym).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("UPDATE orders"+suffix+" SET user_id = \\$2 WHERE user_id = \\$1 RETURNING id").
+			WithArgs(userID, pseudonym).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("order-1" + suffix).AddRow("order-2" + suffix).AddRow("order-3" + suffix))
+	}
+	mock.ExpectQuery("INSERT INTO erasure_audit_log \\(subject_hash, orders, addresses, reason, actor_id\\)").
+		WithArgs(hash, 6, 4, "Privacy request #1234", actorID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("2f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2", time.Now()))
+	mock.ExpectCommit()
+
+	entry := &entities.ErasureRecord{SubjectHash: hash, Reason: "Privacy request #1234", ActorID: actorID}
+	orderIDs, err := repo.EraseUser(userID, pseudonym, entry)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-1", "order-2", "order-3", "order-1_archive", "order-2_archive", "order-3_archive"}, orderIDs)
+	assert.Equal(t, 6, entry.Orders)
+	assert.Equal(t, 4, entry.Addresses)
+	assert.Equal(t, "2f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2", entry.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEraseUser_OpenOrders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PrivacyRepository{Db: db, TenantID: testTenant}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM orders WHERE user_id = \\$1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err = repo.EraseUser("451fa817-41f4-40cf-8dc2-c9f22aa98a4f", "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", &entities.ErasureRecord{Reason: "Privacy request"})
+
+	assert.ErrorIs(t, err, apperrors.ErrOrderOpen)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetErasures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PrivacyRepository{Db: db, TenantID: testTenant}
+	hash := entities.ErasureSubjectHash("451fa817-41f4-40cf-8dc2-c9f22aa98a4f")
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT id, subject_hash, orders, addresses, reason, actor_id, created_at FROM erasure_audit_log\\s+WHERE subject_hash = \\$1 ORDER BY created_at, id").
+		WithArgs(hash).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subject_hash", "orders", "addresses", "reason", "actor_id", "created_at"}).
+			AddRow("2f7c1a51-8b1e-4bd4-9a36-1c6a3cc8a5a2", hash, 6, 4, "Privacy request #1234", nil, time.Now()))
+	mock.ExpectCommit()
+
+	erasures, err := repo.GetErasures(hash)
+
+	assert.NoError(t, err)
+	assert.Len(t, erasures, 1)
+	assert.Equal(t, 6, erasures[0].Orders)
+	assert.Empty(t, erasures[0].ActorID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,107 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/promotions"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var promotionRowColumns = []string{"id", "code", "type", "percent", "amount_off", "buy_quantity", "get_quantity", "product_id", "currency",
+	"min_order_value", "starts_at", "ends_at", "max_redemptions", "max_redemptions_per_user", "redemptions", "active", "created_at", "updated_at"}
+
+func TestPromotionGetByCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PromotionRepository{Db: db, TenantID: testTenant}
+	now := time.Now()
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT id, code, type, .* FROM promotions WHERE code = \\$1").
+		WithArgs("SUMMER25").
+		WillReturnRows(sqlmock.NewRows(promotionRowColumns).
+			AddRow("3f1c2a7e-8d4b-4e0f-9a55-1b2c3d4e5f60", "SUMMER25", "percentage", 25, "0.00", 0, 0, nil, "USD", "50.00", nil, now, 100, 1, 7, true, now, now))
+	mock.ExpectCommit()
+
+	promotion, err := repo.GetByCode("SUMMER25")
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.PromotionPercentage, promotion.Type)
+	assert.Equal(t, "", promotion.ProductID)
+	assert.Equal(t, "USD", promotion.Currency)
+	assert.Nil(t, promotion.StartsAt)
+	assert.NotNil(t, promotion.EndsAt)
+	assert.Equal(t, entities.MustParseMoney("50.00"), promotion.MinOrderValue)
+	assert.Equal(t, 7, promotion.Redemptions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPromotionGetByCode_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PromotionRepository{Db: db, TenantID: testTenant}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM promotions WHERE code = \\$1").
+		WithArgs("NOPE").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	promotion, err := repo.GetByCode("NOPE")
+
+	assert.ErrorIs(t, err, apperrors.ErrPromotionNotFound)
+	assert.Nil(t, promotion)
+}
+
+func TestPromotionCreate_CodeTaken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PromotionRepository{Db: db, TenantID: testTenant}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("INSERT INTO promotions").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	_, err = repo.Create(&entities.Promotion{Code: "SUMMER25", Type: entities.PromotionPercentage, Percent: 25})
+
+	assert.ErrorIs(t, err, apperrors.ErrPromotionCodeTaken)
+}
+
+func TestPromotionDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.PromotionRepository{Db: db, TenantID: testTenant}
+	id := "3f1c2a7e-8d4b-4e0f-9a55-1b2c3d4e5f60"
+
+	// Redeemed promotions are protected by the foreign key of promotion_redemptions
+	expectTenantTx(mock)
+	mock.ExpectExec("DELETE FROM promotions WHERE id = \\$1").
+		WithArgs(id).
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+	assert.ErrorIs(t, repo.Delete(id), apperrors.ErrPromotionInUse)
+
+	expectTenantTx(mock)
+	mock.ExpectExec("DELETE FROM promotions WHERE id = \\$1").
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.ErrorIs(t, repo.Delete(id), apperrors.ErrPromotionNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,122 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	"github.com/shayja/orders-service/internal/adapters/repositories/replicas"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPingDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+func TestRouter_RoundRobinAndHealth(t *testing.T) {
+	primary, _ := newPingDB(t)
+	first, firstMock := newPingDB(t)
+	second, secondMock := newPingDB(t)
+	router := replicas.NewRouter(primary, []*sql.DB{first, second}, 0)
+
+	picked := map[*sql.DB]int{}
+	for i := 0; i < 4; i++ {
+		picked[router.Reader("")]++
+	}
+	assert.Equal(t, map[*sql.DB]int{first: 2, second: 2}, picked)
+
+	// The first replica is down
+	firstMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	secondMock.ExpectPing()
+	router.CheckHealth(context.Background(), time.Second)
+	for i := 0; i < 3; i++ {
+		assert.Same(t, second, router.Reader(""))
+	}
+
+	// No replica is healthy, the primary serves the reads
+	firstMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	secondMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	router.CheckHealth(context.Background(), time.Second)
+	assert.Same(t, primary, router.Reader(""))
+
+	// Back in the rotation once it answers again
+	firstMock.ExpectPing()
+	secondMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	router.CheckHealth(context.Background(), time.Second)
+	assert.Same(t, first, router.Reader(""))
+
+	assert.NoError(t, firstMock.ExpectationsWereMet())
+	assert.NoError(t, secondMock.ExpectationsWereMet())
+}
+
+func TestRouter_Stickiness(t *testing.T) {
+	primary, _ := newPingDB(t)
+	replica, _ := newPingDB(t)
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	router := replicas.NewRouter(primary, []*sql.DB{replica}, 5*time.Second)
+	router.Now = func() time.Time { return now }
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+
+	router.Wrote(userID)
+	assert.Same(t, primary, router.Reader(userID))
+	// Other users and reads without a user keep using the replicas
+	assert.Same(t, replica, router.Reader("b7d2b1f4-2c43-4f7e-9a57-7c7d2b5f6a10"))
+	assert.Same(t, replica, router.Reader(""))
+
+	now = now.Add(5 * time.Second)
+	assert.Same(t, replica, router.Reader(userID))
+}
+
+func TestGetAllOrders_ReadYourWrites(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer replica.Close()
+
+	router := replicas.NewRouter(primary, []*sql.DB{replica}, time.Minute)
+	repo := repositories.OrderRepository{Db: primary, TenantID: testTenant, Router: router}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	columns := []string{"id", "user_id", "total_price", "status", "created_at", "updated_at", "version", "currency",
+		"subtotal", "discount_total", "tax_total", "shipping_total"}
+
+	// The listing goes to the replica
+	expectTenantTx(replicaMock)
+	replicaMock.ExpectQuery("SELECT \\* FROM get_user_orders").
+		WithArgs(userID, 0, repositories.PAGE_SIZE, nil, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows(columns))
+	replicaMock.ExpectCommit()
+
+	orders, err := repo.GetAllOrders(1, entities.OrderFilter{UserID: userID})
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+
+	// The order is written to the primary, and the next listing of the user reads it there
+	expectTenantTx(primaryMock)
+	primaryMock.ExpectExec("CALL orders_insert").WillReturnResult(sqlmock.NewResult(1, 1))
+	primaryMock.ExpectCommit()
+	expectTenantTx(primaryMock)
+	primaryMock.ExpectQuery("SELECT \\* FROM get_user_orders").
+		WithArgs(userID, 0, repositories.PAGE_SIZE, nil, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("6204037c-30e6-408b-8aaa-dd8219860b4b", userID, "100.00", 1, time.Now(), time.Now(), 1, "USD", "100.00", "0.00", "0.00", "0.00"))
+	primaryMock.ExpectCommit()
+
+	_, err = repo.Create(&entities.OrderRequest{UserID: userID, TotalPrice: entities.MustParseMoney("100.00"), Status: 1, Currency: "USD"})
+	assert.NoError(t, err)
+	orders, err = repo.GetAllOrders(1, entities.OrderFilter{UserID: userID})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
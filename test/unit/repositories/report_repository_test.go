@@ -0,0 +1,88 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/reports"
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrdersByPeriod(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.ReportRepository{Db: db, TenantID: testTenant}
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT date_trunc\\(\\$1, created_at\\) AS period, currency, COUNT\\(\\*\\), SUM\\(total_price\\), ROUND\\(AVG\\(total_price\\), 2\\)\\s+FROM orders\\s+"+
+		"WHERE created_at >= \\$2 AND created_at < \\$3 AND status <> \\$4 AND deleted_at IS NULL\\s+GROUP BY period, currency").
+		WithArgs(entities.ReportWeek, from, to, entities.OrderStatusCancelled).
+		WillReturnRows(sqlmock.NewRows([]string{"period", "currency", "count", "sum", "round"}).
+			AddRow(from, "EUR", 2, "90.00", "45.00").
+			AddRow(from, "USD", 3, "100.00", "33.33"))
+	mock.ExpectCommit()
+
+	report, err := repo.OrdersByPeriod(entities.ReportFilter{From: from, To: to, Interval: entities.ReportWeek})
+
+	assert.NoError(t, err)
+	assert.Len(t, report, 2)
+	assert.Equal(t, 3, report[1].Orders)
+	assert.Equal(t, entities.MustParseMoney("100.00"), report[1].Revenue)
+	assert.Equal(t, entities.MustParseMoney("33.33"), report[1].AverageOrderValue)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatusCounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.ReportRepository{Db: db, TenantID: testTenant}
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status, COUNT\\(\\*\\) FROM orders\\s+WHERE created_at >= \\$1 AND created_at < \\$2 AND deleted_at IS NULL\\s+GROUP BY status").
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow(1, 5).AddRow(4, 2))
+	mock.ExpectCommit()
+
+	report, err := repo.StatusCounts(entities.ReportFilter{From: from, To: to})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.StatusReportRow{{Status: 1, Orders: 5}, {Status: 4, Orders: 2}}, report)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTopProducts_ByRevenue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.ReportRepository{Db: db, TenantID: testTenant}
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("FROM order_details d\\s+JOIN orders o ON o.id = d.order_id\\s+WHERE o.created_at >= \\$1 AND o.created_at < \\$2 AND o.status <> \\$3 AND o.deleted_at IS NULL\\s+"+
+		"GROUP BY d.product_id, o.currency\\s+ORDER BY SUM\\(d.total_price - d.discount_amount\\) DESC, d.product_id\\s+LIMIT \\$4").
+		WithArgs(from, to, entities.OrderStatusCancelled, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "currency", "sum", "sum", "count"}).
+			AddRow("063d0ff7-e17e-4957-8d92-a988caeda8a1", "USD", 12, "600.00", 7))
+	mock.ExpectCommit()
+
+	report, err := repo.TopProducts(entities.ReportFilter{From: from, To: to, SortBy: entities.ReportByRevenue, Limit: 5})
+
+	assert.NoError(t, err)
+	assert.Len(t, report, 1)
+	assert.Equal(t, 12, report[0].Quantity)
+	assert.Equal(t, entities.MustParseMoney("600.00"), report[0].Revenue)
+	assert.Equal(t, 7, report[0].Orders)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,133 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/returns"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newReturn() *entities.Return {
+	return &entities.Return{
+		OrderID:      "6204037c-30e6-408b-8aaa-dd8219860b4b",
+		Status:       entities.ReturnRequested,
+		RefundAmount: entities.MustParseMoney("11.31"),
+		Items: []entities.ReturnItem{
+			{OrderDetailID: "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", Quantity: 1, RefundAmount: entities.MustParseMoney("11.31")},
+		},
+	}
+}
+
+func TestReturnCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.ReturnRepository{Db: db, TenantID: testTenant}
+	ret := newReturn()
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status FROM orders WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(ret.OrderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entities.OrderStatusCompleted))
+	mock.ExpectQuery("SELECT d.quantity, .* FROM order_details d WHERE d.id = \\$1 AND d.order_id = \\$2").
+		WithArgs(ret.Items[0].OrderDetailID, ret.OrderID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "returned"}).AddRow(3, 1))
+	mock.ExpectQuery("INSERT INTO returns \\(order_id, status, reason, refund_amount\\)").
+		WithArgs(ret.OrderID, entities.ReturnRequested, "", ret.RefundAmount).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("5d2b8e1a-3c4f-4a6b-9d7e-8f0a1b2c3d4e"))
+	mock.ExpectExec("INSERT INTO return_items").
+		WithArgs("5d2b8e1a-3c4f-4a6b-9d7e-8f0a1b2c3d4e", ret.Items[0].OrderDetailID, 1, ret.Items[0].RefundAmount).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE orders SET updated_at = CURRENT_TIMESTAMP WHERE id = \\$1").
+		WithArgs(ret.OrderID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO order_history").
+		WithArgs(ret.OrderID, entities.HistoryReturnRequested, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	id, err := repo.Create(ret, "451fa817-41f4-40cf-8dc2-c9f22aa98a4f")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "5d2b8e1a-3c4f-4a6b-9d7e-8f0a1b2c3d4e", id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReturnCreate_QuantityExceeded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.ReturnRepository{Db: db, TenantID: testTenant}
+	ret := newReturn()
+
+	// A concurrent return took the last unit
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT status FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entities.OrderStatusCompleted))
+	mock.ExpectQuery("SELECT d.quantity").
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "returned"}).AddRow(3, 3))
+	mock.ExpectRollback()
+
+	_, err = repo.Create(ret, "")
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReturnGetByOrderID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.ReturnRepository{Db: db, TenantID: testTenant}
+	now := time.Now()
+	columns := []string{"id", "order_id", "status", "reason", "refund_amount", "created_at", "updated_at", "order_detail_id", "quantity", "item_refund_amount"}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM orders WHERE id = \\$1 AND deleted_at IS NULL\\)").
+		WithArgs("6204037c-30e6-408b-8aaa-dd8219860b4b").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectCommit()
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT r.id, .* FROM returns r\\s+JOIN return_items ri ON ri.return_id = r.id\\s+WHERE r.order_id = \\$1").
+		WithArgs("6204037c-30e6-408b-8aaa-dd8219860b4b").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("r1", "6204037c-30e6-408b-8aaa-dd8219860b4b", "refunded", nil, "16.31", now, now, "d1", 1, "11.31").
+			AddRow("r1", "6204037c-30e6-408b-8aaa-dd8219860b4b", "refunded", nil, "16.31", now, now, "d2", 1, "5.00").
+			AddRow("r2", "6204037c-30e6-408b-8aaa-dd8219860b4b", "requested", "Broken", "11.31", now, now, "d1", 1, "11.31"))
+	mock.ExpectCommit()
+
+	returns, err := repo.GetByOrderID("6204037c-30e6-408b-8aaa-dd8219860b4b")
+
+	assert.NoError(t, err)
+	assert.Len(t, returns, 2)
+	assert.Len(t, returns[0].Items, 2)
+	assert.Equal(t, entities.MustParseMoney("5.00"), returns[0].Items[1].RefundAmount)
+	assert.Equal(t, "Broken", returns[1].Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReturnGetByOrderID_DeletedOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.ReturnRepository{Db: db, TenantID: testTenant}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM orders WHERE id = \\$1 AND deleted_at IS NULL\\)").
+		WithArgs("6204037c-30e6-408b-8aaa-dd8219860b4b").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectCommit()
+
+	_, err = repo.GetByOrderID("6204037c-30e6-408b-8aaa-dd8219860b4b")
+
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
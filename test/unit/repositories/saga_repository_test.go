@@ -0,0 +1,86 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/sagas"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var sagaRowColumns = []string{"id", "order_id", "state", "items", "error", "created_at", "updated_at"}
+
+func TestSagaCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.SagaRepository{Db: db, TenantID: testTenant}
+	saga := &entities.OrderSaga{
+		State: entities.SagaStarted,
+		Items: []entities.StockItem{{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 2}},
+	}
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("INSERT INTO order_sagas \\(state, items\\) VALUES \\(\\$1, \\$2\\) RETURNING id").
+		WithArgs(entities.SagaStarted, []byte(`[{"product_id":"063d0ff7-e17e-4957-8d92-a988caeda8a1","quantity":2}]`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("8a0d5c1e-2f3b-4c6d-9e7f-0a1b2c3d4e5f"))
+	mock.ExpectCommit()
+
+	id, err := repo.Create(saga)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "8a0d5c1e-2f3b-4c6d-9e7f-0a1b2c3d4e5f", id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSagaGetResumable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.SagaRepository{Db: db, TenantID: testTenant}
+	before := time.Now().Add(-time.Minute)
+	now := time.Now()
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT s.id, .* FROM order_sagas s\\s+LEFT JOIN orders o ON o.id = s.order_id").
+		WithArgs(before, entities.OrderStatusCompleted, entities.OrderStatusCancelled, 10).
+		WillReturnRows(sqlmock.NewRows(sagaRowColumns).
+			AddRow("8a0d5c1e-2f3b-4c6d-9e7f-0a1b2c3d4e5f", nil, "reserved", []byte(`[{"product_id":"p1","quantity":1}]`), nil, now, now).
+			AddRow("9a0d5c1e-2f3b-4c6d-9e7f-0a1b2c3d4e5f", "6204037c-30e6-408b-8aaa-dd8219860b4b", "order_created", []byte(`[]`), "timeout", now, now))
+	mock.ExpectCommit()
+
+	sagas, err := repo.GetResumable(before, 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, sagas, 2)
+	assert.Equal(t, entities.SagaReserved, sagas[0].State)
+	assert.Equal(t, "", sagas[0].OrderID)
+	assert.Equal(t, []entities.StockItem{{ProductID: "p1", Quantity: 1}}, sagas[0].Items)
+	assert.Equal(t, "6204037c-30e6-408b-8aaa-dd8219860b4b", sagas[1].OrderID)
+	assert.Equal(t, "timeout", sagas[1].Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSagaUpdateState_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.SagaRepository{Db: db, TenantID: testTenant}
+
+	expectTenantTx(mock)
+	mock.ExpectExec("UPDATE order_sagas SET state = \\$1, error = \\$2").
+		WithArgs(entities.SagaCompensated, "out of stock", "8a0d5c1e-2f3b-4c6d-9e7f-0a1b2c3d4e5f").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = repo.UpdateState("8a0d5c1e-2f3b-4c6d-9e7f-0a1b2c3d4e5f", entities.SagaCompensated, "out of stock")
+
+	assert.ErrorIs(t, err, apperrors.ErrSagaNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,89 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	repositories "github.com/shayja/orders-service/internal/adapters/repositories/orders"
+	"github.com/shayja/orders-service/internal/adapters/repositories/tenancy"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// The tenant the repositories under test are scoped to.
+const testTenant = "acme"
+
+// expectTenantTx expects the start of a transaction scoped to testTenant.
+func expectTenantTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET LOCAL app.tenant_id = 'acme'")).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestTenancy_RequiresTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.OrderRepository{Db: db}
+
+	_, err = repo.GetByID("order-id")
+	assert.ErrorIs(t, err, apperrors.ErrTenantRequired)
+	_, err = repo.Create(&entities.OrderRequest{UserID: "user-id"})
+	assert.ErrorIs(t, err, apperrors.ErrTenantRequired)
+	_, err = repo.CancelStalePending(time.Now(), 10, "expired")
+	assert.ErrorIs(t, err, apperrors.ErrTenantRequired)
+	// Nothing reached the database
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTenancy_ForTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := (&repositories.OrderRepository{Db: db}).ForTenant("globex")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET LOCAL app.tenant_id = 'globex'")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT \\* FROM get_order").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	order, err := repo.GetByID("order-id")
+	assert.NoError(t, err)
+	assert.Empty(t, order.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTenancy_QueryRowRollsBackWithoutRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectTenantTx(mock)
+	mock.ExpectQuery("SELECT id FROM payments").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	var id string
+	err = tenancy.Scope(db, testTenant).QueryRow("SELECT id FROM payments WHERE id = $1", "payment-id").Scan(&id)
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTenancy_QuotesTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SET LOCAL app.tenant_id = 'a''b'`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM promotions").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, err = tenancy.Scope(db, "a'b").Exec("DELETE FROM promotions WHERE id = $1", "promotion-id")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,35 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/adapters/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_RunsJobsUntilCancelled(t *testing.T) {
+	var failing, panicking atomic.Int32
+	s := &scheduler.Scheduler{}
+	s.Add(scheduler.Job{Name: "failing", Interval: time.Millisecond, Run: func(ctx context.Context) error {
+		failing.Add(1)
+		return errors.New("boom")
+	}})
+	s.Add(scheduler.Job{Name: "panicking", Interval: time.Millisecond, Run: func(ctx context.Context) error {
+		panicking.Add(1)
+		panic("boom")
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	assert.Eventually(t, func() bool { return failing.Load() >= 3 && panicking.Load() >= 3 }, time.Second, time.Millisecond)
+
+	cancel()
+	s.Wait()
+	runs := failing.Load()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, runs, failing.Load())
+}
@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shayja/orders-service/internal/adapters/catalog"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type unavailableCatalog struct{}
+
+func (unavailableCatalog) GetProducts(ids []string) (map[string]*entities.Product, error) {
+	return nil, fmt.Errorf("%w: connection refused", apperrors.ErrCatalogUnavailable)
+}
+
+func TestOrderUsecase_Create_Catalog(t *testing.T) {
+	productCatalog := catalog.NewMemoryCatalog(
+		&entities.Product{ID: productA, Price: entities.MustParseMoney("10.00"), Currency: "usd", Active: true},
+		&entities.Product{ID: productB, Price: entities.MustParseMoney("5.00"), Currency: "USD", Active: false},
+	)
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, Catalog: productCatalog}
+
+	// Current price
+	req := newOrderRequest()
+	orderRepositoryMock.On("Create", req).Return("new-id", nil)
+	id, err := orderUsecase.Create(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "new-id", id)
+
+	// Stale price
+	_, err = orderUsecase.Create(newOrderRequest(withUnitPrice("9.00")))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "unit price of product "+productA+" is 10.00")
+
+	// Inactive product
+	req = newOrderRequest(withUnitPrice("5.00"))
+	req.OrderDetails[0].ProductID = productB
+	_, err = orderUsecase.Create(req)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	// Unknown product
+	req = newOrderRequest()
+	req.OrderDetails[0].ProductID = "263d0ff7-e17e-4957-8d92-a988caeda8a1"
+	_, err = orderUsecase.Create(req)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	// Priced in another currency
+	req = newOrderRequest()
+	req.Currency = "EUR"
+	_, err = orderUsecase.Create(req)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	orderRepositoryMock.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestOrderUsecase_Create_CatalogUnavailable(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, Catalog: unavailableCatalog{}}
+
+	_, err := orderUsecase.Create(newOrderRequest())
+	assert.ErrorIs(t, err, apperrors.ErrCatalogUnavailable)
+	assert.NotErrorIs(t, err, apperrors.ErrInvalidRequest)
+	orderRepositoryMock.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestOrderUsecase_CreateBulk_Catalog(t *testing.T) {
+	productCatalog := catalog.NewMemoryCatalog(&entities.Product{ID: productA, Price: entities.MustParseMoney("10.00"), Currency: "USD", Active: true})
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, Catalog: productCatalog}
+
+	req := &entities.BulkOrderRequest{Orders: []entities.OrderRequest{*newOrderRequest(withUnitPrice("9.00")), *newOrderRequest()}}
+	orderRepositoryMock.On("CreateBulk", mock.MatchedBy(func(orders []*entities.OrderRequest) bool {
+		return len(orders) == 1
+	}), false).Return([]entities.BulkItemResult{{Index: 0, ID: "new-id", Status: entities.BulkItemSuccess}}, nil)
+
+	res, err := orderUsecase.CreateBulk(req)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.BulkItemFailed, res[0].Status)
+	assert.Contains(t, res[0].Error, "unit price")
+	assert.Equal(t, "new-id", res[1].ID)
+	orderRepositoryMock.AssertExpectations(t)
+}
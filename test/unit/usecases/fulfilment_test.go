@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type FulfilmentRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *FulfilmentRepositoryMock) GetFulfilment(orderID string) (*entities.Fulfilment, error) {
+	args := m.Called(orderID)
+	return args.Get(0).(*entities.Fulfilment), args.Error(1)
+}
+
+func (m *FulfilmentRepositoryMock) SetFulfilment(orderID string, req *entities.FulfilmentRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(orderID, req, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+func (m *FulfilmentRepositoryMock) ForTenant(tenantID string) usecases.FulfilmentRepository {
+	return m
+}
+
+func TestOrderUsecase_Create_ShippingAddress(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderRepositoryMock.On("Create", mock.Anything).Return("order-id", nil)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	orderRequest := newOrderRequest(withShippingAddress(&entities.Address{
+		Name: " Jane Doe ", Line1: "1 Main Street", City: "London", PostalCode: "sw1a 1aa", Country: "gb",
+	}))
+	_, err := orderUsecase.Create(orderRequest)
+
+	assert.NoError(t, err)
+	// Normalized and defaulted to standard delivery
+	assert.Equal(t, entities.DeliveryStandard, orderRequest.DeliveryMethod)
+	assert.Equal(t, "Jane Doe", orderRequest.ShippingAddress.Name)
+	assert.Equal(t, "GB", orderRequest.ShippingAddress.Country)
+	assert.Equal(t, "SW1A 1AA", orderRequest.ShippingAddress.PostalCode)
+}
+
+func TestOrderUsecase_Create_InvalidDelivery(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		address *entities.Address
+	}{
+		{"express without address", entities.DeliveryExpress, nil},
+		{"unknown method", "drone", nil},
+		{"pickup with address", entities.DeliveryPickup, &entities.Address{Name: "Jane", Line1: "1 Main St", City: "Tel Aviv", PostalCode: "6100000", Country: "IL"}},
+		{"unknown country", "", &entities.Address{Name: "Jane", Line1: "1 Main St", City: "Atlantis", Country: "XX"}},
+		{"postal code of another country", "", &entities.Address{Name: "Jane", Line1: "1 Main St", City: "Berlin", PostalCode: "SW1A 1AA", Country: "DE"}},
+		{"missing region", "", &entities.Address{Name: "Jane", Line1: "1 Main St", City: "Springfield", PostalCode: "62701", Country: "US"}},
+		{"missing city", "", &entities.Address{Name: "Jane", Line1: "1 Main St", PostalCode: "10115", Country: "DE"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderRepositoryMock := new(OrderRepositoryMock)
+			orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+			orderRequest := newOrderRequest(withShippingAddress(tt.address))
+			orderRequest.DeliveryMethod = tt.method
+			_, err := orderUsecase.Create(orderRequest)
+
+			assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+			orderRepositoryMock.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestOrderUsecase_Create_CountryWithoutPostalCodes(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderRepositoryMock.On("Create", mock.Anything).Return("order-id", nil)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	orderRequest := newOrderRequest(withShippingAddress(&entities.Address{Name: "Jane", Line1: "1 Queen's Road", City: "Hong Kong", PostalCode: "000000", Country: "HK"}))
+	_, err := orderUsecase.Create(orderRequest)
+
+	assert.NoError(t, err)
+	assert.Empty(t, orderRequest.ShippingAddress.PostalCode)
+}
+
+func TestOrderUsecase_GetByID_Fulfilment(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderRepositoryMock.On("GetByID", "order-id").Return(&entities.Order{ID: "order-id"}, nil)
+	fulfilmentRepositoryMock := new(FulfilmentRepositoryMock)
+	address := &entities.Address{Name: "Jane", Line1: "1 Main St", City: "Berlin", PostalCode: "10115", Country: "DE"}
+	fulfilmentRepositoryMock.On("GetFulfilment", "order-id").Return(&entities.Fulfilment{
+		DeliveryMethod: entities.DeliveryExpress, ShippingAddress: address, TrackingNumber: "1Z999", Carrier: "UPS",
+	}, nil)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, FulfilmentRepo: fulfilmentRepositoryMock}
+
+	order, err := orderUsecase.GetByID("order-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.DeliveryExpress, order.DeliveryMethod)
+	assert.Equal(t, address, order.ShippingAddress)
+	assert.Nil(t, order.BillingAddress)
+	assert.Equal(t, "1Z999", order.TrackingNumber)
+	assert.Equal(t, "UPS", order.Carrier)
+}
+
+func TestOrderUsecase_SetFulfilment(t *testing.T) {
+	fulfilmentRepositoryMock := new(FulfilmentRepositoryMock)
+	fulfilmentRepositoryMock.On("SetFulfilment", "order-id", &entities.FulfilmentRequest{TrackingNumber: "1Z999", Carrier: "UPS"}, 3, "admin-id").
+		Return(&entities.Order{ID: "order-id", Version: 4}, nil)
+	fulfilmentRepositoryMock.On("GetFulfilment", "order-id").Return(&entities.Fulfilment{TrackingNumber: "1Z999", Carrier: "UPS"}, nil)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: new(OrderRepositoryMock), FulfilmentRepo: fulfilmentRepositoryMock}
+
+	order, err := orderUsecase.SetFulfilment("order-id", &entities.FulfilmentRequest{TrackingNumber: " 1Z999 ", Carrier: "UPS"}, 3, "admin-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1Z999", order.TrackingNumber)
+	assert.Equal(t, 4, order.Version)
+
+	_, err = orderUsecase.SetFulfilment("order-id", &entities.FulfilmentRequest{TrackingNumber: "  ", Carrier: "UPS"}, 0, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+}
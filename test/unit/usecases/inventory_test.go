@@ -0,0 +1,207 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/adapters/inventory"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// sagaStore is an in-memory SagaRepository.
+type sagaStore struct {
+	mu    sync.Mutex
+	sagas map[string]*entities.OrderSaga
+	seq   int
+}
+
+func newSagaStore() *sagaStore {
+	return &sagaStore{sagas: make(map[string]*entities.OrderSaga)}
+}
+
+func (s *sagaStore) Create(saga *entities.OrderSaga) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	stored := *saga
+	stored.ID = fmt.Sprintf("saga-%d", s.seq)
+	s.sagas[stored.ID] = &stored
+	return stored.ID, nil
+}
+
+func (s *sagaStore) GetByID(id string) (*entities.OrderSaga, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saga, ok := s.sagas[id]
+	if !ok {
+		return nil, apperrors.ErrSagaNotFound
+	}
+	copied := *saga
+	return &copied, nil
+}
+
+func (s *sagaStore) GetByOrderID(orderID string) (*entities.OrderSaga, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, saga := range s.sagas {
+		if saga.OrderID == orderID {
+			copied := *saga
+			return &copied, nil
+		}
+	}
+	return nil, apperrors.ErrSagaNotFound
+}
+
+func (s *sagaStore) GetResumable(before time.Time, limit int) ([]*entities.OrderSaga, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sagas []*entities.OrderSaga
+	for _, saga := range s.sagas {
+		if !saga.State.IsFinal() {
+			copied := *saga
+			sagas = append(sagas, &copied)
+		}
+	}
+	return sagas, nil
+}
+
+func (s *sagaStore) UpdateState(id string, state entities.SagaState, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saga, ok := s.sagas[id]
+	if !ok {
+		return apperrors.ErrSagaNotFound
+	}
+	saga.State, saga.Error = state, errMsg
+	return nil
+}
+
+// attach does what the order repository does in the transaction that stores the order.
+func (s *sagaStore) attach(sagaID string, orderID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sagas[sagaID].OrderID = orderID
+	s.sagas[sagaID].State = entities.SagaOrderCreated
+}
+
+func (s *sagaStore) ForTenant(tenantID string) usecases.SagaRepository {
+	return s
+}
+
+func newInventoryUsecase(stock int) (*usecases.OrderUsecase, *OrderRepositoryMock, *inventory.MemoryInventory, *sagaStore) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	inv := inventory.NewMemoryInventory(map[string]int{productA: stock})
+	sagas := newSagaStore()
+	return &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, Inventory: inv, SagaRepo: sagas}, orderRepositoryMock, inv, sagas
+}
+
+func TestOrderUsecase_Create_ReservesStock(t *testing.T) {
+	orderUsecase, orderRepositoryMock, inv, sagas := newInventoryUsecase(5)
+
+	req := newOrderRequest(withQuantity(3))
+	orderRepositoryMock.On("Create", req).Run(func(args mock.Arguments) {
+		sagas.attach(args.Get(0).(*entities.OrderRequest).SagaID, "order-id")
+	}).Return("order-id", nil)
+
+	id, err := orderUsecase.Create(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "order-id", id)
+	assert.Equal(t, 2, inv.Available(productA))
+
+	saga, err := sagas.GetByOrderID("order-id")
+	assert.NoError(t, err)
+	assert.Equal(t, entities.SagaOrderCreated, saga.State)
+	assert.Equal(t, req.SagaID, saga.ID)
+
+	// Completing the order confirms the reservation, the stock stays taken
+	orderRepositoryMock.On("UpdateStatus", "order-id", entities.OrderStatusCompleted, 0, "").Return(&entities.Order{ID: "order-id"}, nil)
+	_, err = orderUsecase.UpdateStatus("order-id", entities.OrderStatusCompleted, 0, "")
+	assert.NoError(t, err)
+	saga, _ = sagas.GetByID(saga.ID)
+	assert.Equal(t, entities.SagaConfirmed, saga.State)
+	assert.Equal(t, 2, inv.Available(productA))
+}
+
+func TestOrderUsecase_Create_OutOfStock(t *testing.T) {
+	orderUsecase, orderRepositoryMock, inv, sagas := newInventoryUsecase(2)
+
+	_, err := orderUsecase.Create(newOrderRequest(withQuantity(3)))
+	assert.ErrorIs(t, err, apperrors.ErrOutOfStock)
+	assert.Equal(t, 2, inv.Available(productA))
+	saga, _ := sagas.GetByID("saga-1")
+	assert.Equal(t, entities.SagaCompensated, saga.State)
+	orderRepositoryMock.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestOrderUsecase_Create_ReleasesStockOnFailure(t *testing.T) {
+	orderUsecase, orderRepositoryMock, inv, sagas := newInventoryUsecase(5)
+
+	req := newOrderRequest(withQuantity(3))
+	orderRepositoryMock.On("Create", req).Return("", errors.New("connection reset"))
+
+	_, err := orderUsecase.Create(req)
+	assert.Error(t, err)
+	assert.Equal(t, 5, inv.Available(productA))
+	saga, _ := sagas.GetByID(req.SagaID)
+	assert.Equal(t, entities.SagaCompensated, saga.State)
+	assert.Equal(t, "connection reset", saga.Error)
+}
+
+func TestOrderUsecase_UpdateStatus_CancelReleasesStock(t *testing.T) {
+	orderUsecase, orderRepositoryMock, inv, sagas := newInventoryUsecase(5)
+
+	req := newOrderRequest(withQuantity(3))
+	orderRepositoryMock.On("Create", req).Run(func(args mock.Arguments) {
+		sagas.attach(args.Get(0).(*entities.OrderRequest).SagaID, "order-id")
+	}).Return("order-id", nil)
+	_, err := orderUsecase.Create(req)
+	assert.NoError(t, err)
+
+	orderRepositoryMock.On("UpdateStatusBulk", mock.Anything, false, "").Return([]entities.BulkItemResult{{ID: "order-id", Status: entities.BulkItemSuccess}}, nil)
+	_, err = orderUsecase.UpdateStatusBulk(&entities.BulkStatusRequest{Updates: []entities.StatusUpdate{
+		{ID: "6204037c-30e6-408b-8aaa-dd8219860b4b", Status: entities.OrderStatusCancelled},
+	}}, "")
+	assert.NoError(t, err)
+	// The bulk update was for another order
+	assert.Equal(t, 2, inv.Available(productA))
+
+	orderRepositoryMock.On("UpdateStatus", "order-id", entities.OrderStatusCancelled, 0, "").Return(&entities.Order{ID: "order-id"}, nil)
+	_, err = orderUsecase.UpdateStatus("order-id", entities.OrderStatusCancelled, 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, inv.Available(productA))
+	saga, _ := sagas.GetByOrderID("order-id")
+	assert.Equal(t, entities.SagaReleased, saga.State)
+}
+
+func TestOrderUsecase_ResumeSagas(t *testing.T) {
+	orderUsecase, orderRepositoryMock, inv, sagas := newInventoryUsecase(5)
+
+	// Crashed after reserving the stock of a first order, before storing it
+	interruptedID, _ := sagas.Create(&entities.OrderSaga{State: entities.SagaStarted})
+	assert.NoError(t, inv.Reserve(interruptedID, []entities.StockItem{{ProductID: productA, Quantity: 1}}))
+	assert.NoError(t, sagas.UpdateState(interruptedID, entities.SagaReserved, ""))
+
+	// Crashed after cancelling a second order, before releasing its stock
+	cancelledID, _ := sagas.Create(&entities.OrderSaga{State: entities.SagaStarted})
+	assert.NoError(t, inv.Reserve(cancelledID, []entities.StockItem{{ProductID: productA, Quantity: 2}}))
+	sagas.attach(cancelledID, "order-id")
+	orderRepositoryMock.On("GetByID", "order-id").Return(&entities.Order{ID: "order-id", Status: entities.OrderStatusCancelled}, nil)
+
+	assert.Equal(t, 2, inv.Available(productA))
+	resumed, err := orderUsecase.ResumeSagas(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resumed)
+	assert.Equal(t, 5, inv.Available(productA))
+
+	saga, _ := sagas.GetByID(interruptedID)
+	assert.Equal(t, entities.SagaCompensated, saga.State)
+	saga, _ = sagas.GetByID(cancelledID)
+	assert.Equal(t, entities.SagaReleased, saga.State)
+}
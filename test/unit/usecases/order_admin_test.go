@@ -0,0 +1,66 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderUsecase_SearchOrders(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, PageSize: 50}
+
+	filter := entities.OrderFilter{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1"}
+	expected := []*entities.Order{{ID: "order-1"}}
+	orderRepositoryMock.On("SearchOrders", 1, entities.OrderFilter{ProductID: filter.ProductID, PageSize: 50}).Return(expected, nil)
+
+	orders, err := orderUsecase.SearchOrders(1, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, orders)
+
+	_, err = orderUsecase.SearchOrders(1, entities.OrderFilter{Currency: "XXX"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+}
+
+func TestOrderUsecase_ForceStatus(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{
+		OrderRepo:         orderRepositoryMock,
+		StatusTransitions: entities.StatusTransitions{entities.OrderStatusPending: {entities.OrderStatusProcessing}},
+	}
+
+	_, err := orderUsecase.ForceStatus("order-1", entities.OrderStatusCancelled, "  ", 0, "admin-1")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	_, err = orderUsecase.ForceStatus("order-1", 9, "Stuck order", 0, "admin-1")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	// The status rules are bypassed, the order is never loaded to check them
+	expected := &entities.Order{ID: "order-1", Status: entities.OrderStatusCancelled}
+	orderRepositoryMock.On("ForceStatus", "order-1", entities.OrderStatusCancelled, "Stuck order", 3, "admin-1").Return(expected, nil)
+
+	order, err := orderUsecase.ForceStatus("order-1", entities.OrderStatusCancelled, " Stuck order ", 3, "admin-1")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, order)
+	orderRepositoryMock.AssertNotCalled(t, "GetByID", "order-1")
+}
+
+func TestOrderUsecase_GetOrderHistory(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	history := []entities.OrderHistory{{ID: "entry-1", OrderID: "order-1", Event: entities.HistoryStatusForced}}
+	orderRepositoryMock.On("GetOrderHistory", "order-1").Return(history, nil)
+	orderRepositoryMock.On("GetOrderHistory", "missing").Return([]entities.OrderHistory(nil), apperrors.ErrOrderNotFound)
+
+	res, err := orderUsecase.GetOrderHistory("order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, history, res)
+
+	_, err = orderUsecase.GetOrderHistory("missing")
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotFound)
+	// Soft deleted orders have a history, the order is not loaded
+	orderRepositoryMock.AssertNotCalled(t, "GetByID", "order-1")
+}
@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrderUsecase_DeleteOrder(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	orderRepositoryMock.On("DeleteOrder", "order-1", 2, "admin-1").Return(nil)
+	orderRepositoryMock.On("DeleteOrder", "order-2", 0, "admin-1").Return(apperrors.ErrOrderOpen)
+
+	assert.NoError(t, orderUsecase.DeleteOrder("order-1", 2, "admin-1"))
+	assert.ErrorIs(t, orderUsecase.DeleteOrder("order-2", 0, "admin-1"), apperrors.ErrOrderOpen)
+}
+
+func TestOrderUsecase_ArchiveOrders(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	// Disabled without a retention period
+	_, err := orderUsecase.ArchiveOrders()
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	orderUsecase.ArchiveAfter = 90 * 24 * time.Hour
+	orderRepositoryMock.On("ArchiveOrders", mock.MatchedBy(func(before time.Time) bool {
+		age := time.Since(before)
+		return age >= 90*24*time.Hour && age < 91*24*time.Hour
+	}), mock.Anything).Return([]string{"order-1", "order-2"}, nil).Once()
+
+	n, err := orderUsecase.ArchiveOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	orderRepositoryMock.On("ArchiveOrders", mock.Anything, mock.Anything).Return([]string(nil), apperrors.ErrJobRunning)
+	_, err = orderUsecase.ArchiveOrders()
+	assert.ErrorIs(t, err, apperrors.ErrJobRunning)
+}
+
+func TestOrderUsecase_GetArchivedByID(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	archivedAt := time.Now()
+	expected := &entities.Order{ID: "order-1", ArchivedAt: &archivedAt}
+	orderRepositoryMock.On("GetArchivedByID", "order-1").Return(expected, nil)
+
+	order, err := orderUsecase.GetArchivedByID("order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, order)
+}
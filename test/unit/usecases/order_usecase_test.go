@@ -3,8 +3,10 @@ package usecases
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
 	"github.com/shayja/orders-service/internal/usecases"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -14,8 +16,8 @@ type OrderRepositoryMock struct {
 	mock.Mock
 }
 
-func (m *OrderRepositoryMock) GetAllOrders(page int, userID string) ([]*entities.Order, error) {
-	args := m.Called(page, userID)
+func (m *OrderRepositoryMock) GetAllOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	args := m.Called(page, filter)
 	return args.Get(0).([]*entities.Order), args.Error(1)
 }
 
@@ -29,22 +31,153 @@ func (m *OrderRepositoryMock) Create(orderRequest *entities.OrderRequest) (strin
 	return args.String(0), args.Error(1)
 }
 
-func (m *OrderRepositoryMock) UpdateStatus(id string, status int) (*entities.Order, error) {
-	args := m.Called(id, status)
+func (m *OrderRepositoryMock) UpdateStatus(id string, status int, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(id, status, expectedVersion, actorID)
 	return args.Get(0).(*entities.Order), args.Error(1)
 }
 
+func (m *OrderRepositoryMock) CreateBulk(orders []*entities.OrderRequest, atomic bool) ([]entities.BulkItemResult, error) {
+	args := m.Called(orders, atomic)
+	return args.Get(0).([]entities.BulkItemResult), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) UpdateStatusBulk(updates []entities.StatusUpdate, atomic bool, actorID string) ([]entities.BulkItemResult, error) {
+	args := m.Called(updates, atomic, actorID)
+	return args.Get(0).([]entities.BulkItemResult), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) ExportOrders(filter entities.OrderFilter, includeItems bool, fn func(*entities.Order) error) error {
+	args := m.Called(filter, includeItems, fn)
+	return args.Error(0)
+}
+
+func (m *OrderRepositoryMock) GetOrderDetails(orderID string) ([]entities.OrderDetail, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]entities.OrderDetail), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) AddOrderItem(orderID string, item *entities.OrderItemRequest, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(orderID, item, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) UpdateOrderItem(orderID string, itemID string, update *entities.OrderItemUpdate, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(orderID, itemID, update, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) RemoveOrderItem(orderID string, itemID string, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(orderID, itemID, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) CancelStalePending(before time.Time, batchSize int, reason string) ([]string, error) {
+	args := m.Called(before, batchSize, reason)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) SearchOrders(page int, filter entities.OrderFilter) ([]*entities.Order, error) {
+	args := m.Called(page, filter)
+	return args.Get(0).([]*entities.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) GetOrderSummary(filter entities.OrderFilter) (*entities.OrderSummary, error) {
+	args := m.Called(filter)
+	return args.Get(0).(*entities.OrderSummary), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) ForceStatus(id string, status int, reason string, expectedVersion int, actorID string) (*entities.Order, error) {
+	args := m.Called(id, status, reason, expectedVersion, actorID)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) GetOrderHistory(orderID string) ([]entities.OrderHistory, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]entities.OrderHistory), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) DeleteOrder(id string, expectedVersion int, actorID string) error {
+	args := m.Called(id, expectedVersion, actorID)
+	return args.Error(0)
+}
+
+func (m *OrderRepositoryMock) ArchiveOrders(before time.Time, batchSize int) ([]string, error) {
+	args := m.Called(before, batchSize)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) GetArchivedByID(id string) (*entities.Order, error) {
+	args := m.Called(id)
+	return args.Get(0).(*entities.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) ForTenant(tenantID string) usecases.OrderRepository {
+	return m
+}
+
+const (
+	productA = "063d0ff7-e17e-4957-8d92-a988caeda8a1"
+	productB = "163d0ff7-e17e-4957-8d92-a988caeda8a1"
+)
+
+// orderOption changes the order request built by newOrderRequest.
+type orderOption func(*entities.OrderRequest)
+
+// newOrderRequest returns a pending USD order of one unit of productA at 10.00, changed by opts.
+func newOrderRequest(opts ...orderOption) *entities.OrderRequest {
+	req := &entities.OrderRequest{
+		UserID:   "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
+		Status:   entities.OrderStatusPending,
+		Currency: "USD",
+		OrderDetails: []entities.OrderDetail{
+			{ProductID: productA, Quantity: 1, UnitPrice: entities.MustParseMoney("10.00")},
+		},
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req
+}
+
+// withQuantity sets the quantity of the first line.
+func withQuantity(quantity int) orderOption {
+	return func(req *entities.OrderRequest) { req.OrderDetails[0].Quantity = quantity }
+}
+
+// withUnitPrice sets the unit price of the first line.
+func withUnitPrice(unitPrice string) orderOption {
+	return func(req *entities.OrderRequest) { req.OrderDetails[0].UnitPrice = entities.MustParseMoney(unitPrice) }
+}
+
+// withLine adds a line to the order.
+func withLine(productID string, quantity int, unitPrice string) orderOption {
+	return func(req *entities.OrderRequest) {
+		req.OrderDetails = append(req.OrderDetails, entities.OrderDetail{ProductID: productID, Quantity: quantity, UnitPrice: entities.MustParseMoney(unitPrice)})
+	}
+}
+
+// withCoupon sets the coupon code of the order.
+func withCoupon(code string) orderOption {
+	return func(req *entities.OrderRequest) { req.CouponCode = code }
+}
+
+// withShippingAddress sets the shipping address of the order.
+func withShippingAddress(address *entities.Address) orderOption {
+	return func(req *entities.OrderRequest) { req.ShippingAddress = address }
+}
+
 func TestOrderUsecase_GetOrders(t *testing.T) {
 	orderRepositoryMock := new(OrderRepositoryMock)
 	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
 
 	expectedOrders := []*entities.Order{}
-	orderRepositoryMock.On("GetAllOrders", 1, "test-user-id").Return(expectedOrders, nil)
+	filter := entities.OrderFilter{UserID: "test-user-id"}
+	orderRepositoryMock.On("GetAllOrders", 1, filter).Return(expectedOrders, nil)
 
-	orders, err := orderUsecase.GetOrders(1, "test-user-id")
+	orders, err := orderUsecase.GetOrders(1, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedOrders, orders)
-	orderRepositoryMock.AssertCalled(t, "GetAllOrders", 1, "test-user-id")
+	orderRepositoryMock.AssertCalled(t, "GetAllOrders", 1, filter)
 }
 
 func TestOrderUsecase_GetOrders_Error(t *testing.T) {
@@ -52,10 +185,209 @@ func TestOrderUsecase_GetOrders_Error(t *testing.T) {
 	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
 
 	// Mock the behavior: return nil orders and an error
-	orderRepositoryMock.On("GetAllOrders", 1, "test-user-id").Return(([]*entities.Order)(nil), errors.New("db error"))
+	filter := entities.OrderFilter{UserID: "test-user-id"}
+	orderRepositoryMock.On("GetAllOrders", 1, filter).Return(([]*entities.Order)(nil), errors.New("db error"))
 
-	orders, err := orderUsecase.GetOrders(1, "test-user-id")
+	orders, err := orderUsecase.GetOrders(1, filter)
 	assert.Error(t, err)           // Expecting an error
 	assert.Nil(t, orders)          // Expecting orders to be nil
-	orderRepositoryMock.AssertCalled(t, "GetAllOrders", 1, "test-user-id")
+	orderRepositoryMock.AssertCalled(t, "GetAllOrders", 1, filter)
+}
+
+func TestOrderUsecase_CreateBulk_LimitExceeded(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, BulkLimit: 1}
+
+	req := &entities.BulkOrderRequest{Orders: make([]entities.OrderRequest, 2)}
+
+	res, err := orderUsecase.CreateBulk(req)
+	assert.ErrorIs(t, err, apperrors.ErrBulkLimitExceeded)
+	assert.Nil(t, res)
+	orderRepositoryMock.AssertNotCalled(t, "CreateBulk", mock.Anything, mock.Anything)
+}
+
+func TestOrderUsecase_CreateBulk_BestEffort(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, BulkLimit: 10}
+
+	validOrder := entities.OrderRequest{
+		UserID: "451fa817-41f4-40cf-8dc2-c9f22aa98a4f",
+		Status: 1,
+		OrderDetails: []entities.OrderDetail{
+			{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 1, UnitPrice: entities.MustParseMoney("10.00")},
+		},
+	}
+	invalidOrder := entities.OrderRequest{UserID: "not-a-uuid", Status: 1}
+	req := &entities.BulkOrderRequest{Orders: []entities.OrderRequest{invalidOrder, validOrder}}
+
+	orderRepositoryMock.On("CreateBulk", mock.MatchedBy(func(orders []*entities.OrderRequest) bool {
+		return len(orders) == 1 && orders[0].UserID == validOrder.UserID
+	}), false).Return([]entities.BulkItemResult{{Index: 0, ID: "new-id", Status: entities.BulkItemSuccess}}, nil)
+
+	res, err := orderUsecase.CreateBulk(req)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, entities.BulkItemFailed, res[0].Status)
+	assert.Equal(t, 1, res[1].Index)
+	assert.Equal(t, "new-id", res[1].ID)
+	orderRepositoryMock.AssertExpectations(t)
+}
+
+func TestOrderUsecase_UpdateStatusBulk_AtomicInvalid(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	req := &entities.BulkStatusRequest{
+		Atomic: true,
+		Updates: []entities.StatusUpdate{
+			{ID: "6204037c-30e6-408b-8aaa-dd8219860b4b", Status: 9},
+		},
+	}
+
+	res, err := orderUsecase.UpdateStatusBulk(req, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	assert.Nil(t, res)
+	orderRepositoryMock.AssertNotCalled(t, "UpdateStatusBulk", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderUsecase_ExportOrders_InvalidRange(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := entities.OrderFilter{UserID: "test-user-id", From: &from, To: &to}
+
+	err := orderUsecase.ExportOrders(filter, false, func(*entities.Order) error { return nil })
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	orderRepositoryMock.AssertNotCalled(t, "ExportOrders", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderUsecase_UpdateItem_NothingToUpdate(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	res, err := orderUsecase.UpdateItem("order-id", "item-id", &entities.OrderItemUpdate{}, 0, "test-user-id")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	assert.Nil(t, res)
+	orderRepositoryMock.AssertNotCalled(t, "UpdateOrderItem", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderUsecase_AddItem(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	item := &entities.OrderItemRequest{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 1, UnitPrice: entities.MustParseMoney("10.00")}
+	expectedOrder := &entities.Order{ID: "order-id", Version: 3}
+	orderRepositoryMock.On("GetByID", "order-id").Return(&entities.Order{ID: "order-id", Currency: "USD", Version: 2}, nil)
+	orderRepositoryMock.On("AddOrderItem", "order-id", item, 2, "test-user-id").Return(expectedOrder, nil)
+
+	order, err := orderUsecase.AddItem("order-id", item, 2, "test-user-id")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, order.Version)
+	orderRepositoryMock.AssertExpectations(t)
+}
+
+func TestOrderUsecase_UpdateStatus_InvalidStatus(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	res, err := orderUsecase.UpdateStatus("order-id", 7, 0, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	assert.Nil(t, res)
+	orderRepositoryMock.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderUsecase_Create_Currency(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, AllowedCurrencies: []string{"USD", "EUR", "JPY"}}
+
+	// Defaults to the first allowed currency
+	req := &entities.OrderRequest{TotalPrice: entities.MustParseMoney("10.50")}
+	orderRepositoryMock.On("Create", req).Return("new-id", nil)
+	_, err := orderUsecase.Create(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", req.Currency)
+
+	// Not in the allow-list
+	_, err = orderUsecase.Create(&entities.OrderRequest{Currency: "ILS"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	// JPY has no minor units
+	_, err = orderUsecase.Create(&entities.OrderRequest{Currency: "jpy", ShippingTotal: entities.MustParseMoney("10.50")})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	// Line items must share the order currency
+	_, err = orderUsecase.Create(&entities.OrderRequest{
+		Currency:     "EUR",
+		OrderDetails: []entities.OrderDetail{{Currency: "USD", Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	orderRepositoryMock.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestOrderUsecase_Create_Pricing(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, Pricing: usecases.FlatTaxCalculator{TaxRate: 1700}}
+
+	req := &entities.OrderRequest{
+		Currency:      "USD",
+		TotalPrice:    entities.MustParseMoney("1.00"),
+		ShippingTotal: entities.MustParseMoney("5.00"),
+		OrderDetails: []entities.OrderDetail{
+			// Client-sent discounts are ignored
+			{ProductID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 2, UnitPrice: entities.MustParseMoney("10.00"), DiscountAmount: entities.MustParseMoney("20.00")},
+		},
+	}
+	orderRepositoryMock.On("Create", req).Return("new-id", nil)
+
+	_, err := orderUsecase.Create(req)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.MustParseMoney("20.00"), req.Subtotal)
+	assert.True(t, req.DiscountTotal.IsZero())
+	assert.Equal(t, entities.MustParseMoney("3.40"), req.TaxTotal)
+	assert.Equal(t, entities.MustParseMoney("28.40"), req.TotalPrice)
+	assert.Equal(t, entities.MustParseMoney("3.40"), req.OrderDetails[0].TaxAmount)
+}
+
+func TestOrderUsecase_CancelStaleOrders(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	// Disabled without a TTL
+	_, err := orderUsecase.CancelStaleOrders()
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	orderUsecase.PendingTTL = 24 * time.Hour
+	orderRepositoryMock.On("CancelStalePending", mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 24*time.Hour && time.Since(before) < 25*time.Hour
+	}), mock.Anything, "not paid within 24h0m0s").Return([]string{"order-1", "order-2"}, nil).Once()
+
+	n, err := orderUsecase.CancelStaleOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	orderRepositoryMock.On("CancelStalePending", mock.Anything, mock.Anything, mock.Anything).Return([]string(nil), apperrors.ErrJobRunning)
+	_, err = orderUsecase.CancelStaleOrders()
+	assert.ErrorIs(t, err, apperrors.ErrJobRunning)
+}
+
+func TestOrderUsecase_GetSummary(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	summary := &entities.OrderSummary{Orders: 2}
+	// Only the user and date range narrow down the summary
+	orderRepositoryMock.On("GetOrderSummary", entities.OrderFilter{UserID: "user-1", From: &from}).Return(summary, nil)
+
+	res, err := orderUsecase.GetSummary(entities.OrderFilter{UserID: "user-1", From: &from, Status: entities.OrderStatusPending, PageSize: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, summary, res)
+
+	_, err = orderUsecase.GetSummary(entities.OrderFilter{})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	_, err = orderUsecase.GetSummary(entities.OrderFilter{UserID: "user-1", From: &from, To: &from})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
 }
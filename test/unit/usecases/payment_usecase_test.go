@@ -0,0 +1,171 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/adapters/payments"
+	"github.com/shayja/orders-service/internal/adapters/repositories/cache"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type PaymentRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PaymentRepositoryMock) Create(payment *entities.Payment) (string, error) {
+	args := m.Called(payment)
+	return args.String(0), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) GetByID(id string) (*entities.Payment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*entities.Payment), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) GetByOrderID(orderID string) ([]*entities.Payment, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]*entities.Payment), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) GetByProviderRef(provider string, providerRef string) (*entities.Payment, error) {
+	args := m.Called(provider, providerRef)
+	return args.Get(0).(*entities.Payment), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) Update(payment *entities.Payment, from string) error {
+	args := m.Called(payment, from)
+	return args.Error(0)
+}
+
+func (m *PaymentRepositoryMock) ApplyEvent(payment *entities.Payment, from string, eventID string) (bool, error) {
+	args := m.Called(payment, from, eventID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) ForTenant(tenantID string) usecases.PaymentRepository {
+	return m
+}
+
+const paymentOrderID = "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+func newPaymentUsecase(gateway *payments.FakeGateway) (*usecases.PaymentUsecase, *PaymentRepositoryMock, *OrderRepositoryMock) {
+	paymentRepositoryMock := new(PaymentRepositoryMock)
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderRepositoryMock.On("GetByID", paymentOrderID).Return(&entities.Order{
+		ID: paymentOrderID, Status: entities.OrderStatusPending, Currency: "USD", TotalPrice: entities.MustParseMoney("150.00"),
+	}, nil)
+	return &usecases.PaymentUsecase{PaymentRepo: paymentRepositoryMock, Gateway: gateway, OrderRepo: orderRepositoryMock}, paymentRepositoryMock, orderRepositoryMock
+}
+
+func TestPaymentUsecase_StartAndCapture(t *testing.T) {
+	paymentUsecase, paymentRepositoryMock, _ := newPaymentUsecase(&payments.FakeGateway{})
+
+	paymentRepositoryMock.On("GetByOrderID", paymentOrderID).Return([]*entities.Payment{
+		{Status: entities.PaymentFailed},
+	}, nil)
+	paymentRepositoryMock.On("Create", mock.AnythingOfType("*entities.Payment")).Return("payment-id", nil)
+
+	payment, err := paymentUsecase.StartPayment(paymentOrderID)
+	assert.NoError(t, err)
+	assert.Equal(t, "payment-id", payment.ID)
+	assert.Equal(t, entities.PaymentAuthorized, payment.Status)
+	assert.Equal(t, entities.MustParseMoney("150.00"), payment.AuthorizedAmount)
+	assert.Equal(t, "fake", payment.Provider)
+	assert.NotEmpty(t, payment.ProviderRef)
+
+	paymentRepositoryMock.On("GetByID", "payment-id").Return(payment, nil)
+	paymentRepositoryMock.On("Update", payment, entities.PaymentAuthorized).Return(nil)
+
+	captured, err := paymentUsecase.Capture(paymentOrderID, "payment-id")
+	assert.NoError(t, err)
+	assert.Equal(t, entities.PaymentCaptured, captured.Status)
+	assert.Equal(t, entities.MustParseMoney("150.00"), captured.CapturedAmount)
+
+	// Captured payments cannot be captured again
+	_, err = paymentUsecase.Capture(paymentOrderID, "payment-id")
+	assert.ErrorIs(t, err, apperrors.ErrPaymentState)
+	paymentRepositoryMock.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestPaymentUsecase_CaptureInvalidatesCachedOrder(t *testing.T) {
+	gateway := &payments.FakeGateway{}
+	paymentUsecase, paymentRepositoryMock, orderRepositoryMock := newPaymentUsecase(gateway)
+	cached := cache.NewOrderRepository(orderRepositoryMock, &cache.MemoryBackend{}, time.Minute)
+	paymentUsecase.OrderRepo = cached
+
+	ref, err := gateway.Authorize(paymentOrderID, entities.MustParseMoney("150.00"), "USD")
+	assert.NoError(t, err)
+	payment := &entities.Payment{ID: "payment-id", OrderID: paymentOrderID, Status: entities.PaymentAuthorized, ProviderRef: ref,
+		AuthorizedAmount: entities.MustParseMoney("150.00")}
+	paymentRepositoryMock.On("GetByID", "payment-id").Return(payment, nil)
+	paymentRepositoryMock.On("Update", payment, entities.PaymentAuthorized).Return(nil)
+
+	_, err = cached.GetByID(paymentOrderID)
+	assert.NoError(t, err)
+	_, err = paymentUsecase.Capture(paymentOrderID, "payment-id")
+	assert.NoError(t, err)
+
+	// The capture moved the order to processing, the next lookup reads it again
+	_, err = cached.GetByID(paymentOrderID)
+	assert.NoError(t, err)
+	orderRepositoryMock.AssertNumberOfCalls(t, "GetByID", 2)
+}
+
+func TestPaymentUsecase_StartPayment_Declined(t *testing.T) {
+	paymentUsecase, paymentRepositoryMock, _ := newPaymentUsecase(&payments.FakeGateway{DeclineAbove: entities.MustParseMoney("100.00")})
+
+	paymentRepositoryMock.On("GetByOrderID", paymentOrderID).Return([]*entities.Payment{}, nil)
+	paymentRepositoryMock.On("Create", mock.MatchedBy(func(p *entities.Payment) bool {
+		return p.Status == entities.PaymentFailed && p.FailedAmount == entities.MustParseMoney("150.00")
+	})).Return("payment-id", nil)
+
+	payment, err := paymentUsecase.StartPayment(paymentOrderID)
+	assert.ErrorIs(t, err, apperrors.ErrPaymentDeclined)
+	assert.Equal(t, "payment-id", payment.ID)
+	paymentRepositoryMock.AssertExpectations(t)
+}
+
+func TestPaymentUsecase_StartPayment_AlreadyPaid(t *testing.T) {
+	paymentUsecase, paymentRepositoryMock, _ := newPaymentUsecase(&payments.FakeGateway{})
+
+	paymentRepositoryMock.On("GetByOrderID", paymentOrderID).Return([]*entities.Payment{{Status: entities.PaymentCaptured}}, nil)
+
+	_, err := paymentUsecase.StartPayment(paymentOrderID)
+	assert.ErrorIs(t, err, apperrors.ErrPaymentExists)
+	paymentRepositoryMock.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestPaymentUsecase_HandleWebhook(t *testing.T) {
+	gateway := &payments.FakeGateway{Secret: "webhook-secret"}
+	paymentUsecase, paymentRepositoryMock, _ := newPaymentUsecase(gateway)
+
+	payment := &entities.Payment{ID: "payment-id", OrderID: paymentOrderID, Provider: "fake", ProviderRef: "fake_1",
+		Status: entities.PaymentAuthorized, AuthorizedAmount: entities.MustParseMoney("150.00")}
+	paymentRepositoryMock.On("GetByProviderRef", "fake", "fake_1").Return(payment, nil)
+	paymentRepositoryMock.On("ApplyEvent", payment, entities.PaymentAuthorized, "evt_1").Return(true, nil)
+
+	payload := []byte(`{"id": "evt_1", "type": "payment.captured", "provider_ref": "fake_1", "amount": 150.00}`)
+
+	// Unsigned or tampered payloads are rejected
+	err := paymentUsecase.HandleWebhook(payload, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSignature)
+	err = paymentUsecase.HandleWebhook([]byte(`{"id": "evt_1", "type": "payment.refunded", "provider_ref": "fake_1"}`), gateway.Sign(payload))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSignature)
+
+	err = paymentUsecase.HandleWebhook(payload, gateway.Sign(payload))
+	assert.NoError(t, err)
+	assert.Equal(t, entities.PaymentCaptured, payment.Status)
+	assert.Equal(t, entities.MustParseMoney("150.00"), payment.CapturedAmount)
+
+	// A late authorization does not undo the capture
+	late := []byte(`{"id": "evt_0", "type": "payment.authorized", "provider_ref": "fake_1", "amount": 150.00}`)
+	err = paymentUsecase.HandleWebhook(late, gateway.Sign(late))
+	assert.NoError(t, err)
+	assert.Equal(t, entities.PaymentCaptured, payment.Status)
+	paymentRepositoryMock.AssertNumberOfCalls(t, "ApplyEvent", 1)
+}
@@ -0,0 +1,76 @@
+package usecases
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shayja/orders-service/internal/entities"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatTaxCalculator_Price(t *testing.T) {
+	calculator := usecases.FlatTaxCalculator{TaxRate: 1750}
+	req := &entities.OrderRequest{
+		Currency:      "USD",
+		ShippingTotal: entities.MustParseMoney("4.99"),
+		OrderDetails: []entities.OrderDetail{
+			{Quantity: 3, UnitPrice: entities.MustParseMoney("9.99"), DiscountAmount: entities.MustParseMoney("2.97")},
+			{Quantity: 1, UnitPrice: entities.MustParseMoney("0.10")},
+		},
+	}
+
+	assert.NoError(t, calculator.Price(req))
+	// 29.97 - 2.97 = 27.00 taxed at 17.5% = 4.725, rounded half away from zero
+	assert.Equal(t, entities.MustParseMoney("29.97"), req.OrderDetails[0].TotalPrice)
+	assert.Equal(t, entities.MustParseMoney("4.73"), req.OrderDetails[0].TaxAmount)
+	// 0.10 taxed at 17.5% = 0.0175
+	assert.Equal(t, entities.MustParseMoney("0.02"), req.OrderDetails[1].TaxAmount)
+
+	assert.Equal(t, entities.MustParseMoney("30.07"), req.Subtotal)
+	assert.Equal(t, entities.MustParseMoney("2.97"), req.DiscountTotal)
+	assert.Equal(t, entities.MustParseMoney("4.75"), req.TaxTotal)
+	assert.Equal(t, entities.MustParseMoney("36.84"), req.TotalPrice)
+}
+
+func TestFlatTaxCalculator_Price_CurrencyRounding(t *testing.T) {
+	calculator := usecases.FlatTaxCalculator{TaxRate: 1000}
+	req := &entities.OrderRequest{
+		Currency:     "JPY",
+		OrderDetails: []entities.OrderDetail{{Quantity: 1, UnitPrice: entities.MustParseMoney("1255")}},
+	}
+
+	assert.NoError(t, calculator.Price(req))
+	// 125.5 yen is rounded to whole yen
+	assert.Equal(t, entities.MustParseMoney("126"), req.TaxTotal)
+	assert.Equal(t, entities.MustParseMoney("1381"), req.TotalPrice)
+}
+
+func TestFlatTaxCalculator_Price_DiscountCappedAtLineTotal(t *testing.T) {
+	calculator := usecases.FlatTaxCalculator{TaxRate: 1700}
+	req := &entities.OrderRequest{
+		Currency:     "USD",
+		OrderDetails: []entities.OrderDetail{{Quantity: 1, UnitPrice: entities.MustParseMoney("5.00"), DiscountAmount: entities.MustParseMoney("8.00")}},
+	}
+
+	assert.NoError(t, calculator.Price(req))
+	assert.Equal(t, entities.MustParseMoney("5.00"), req.DiscountTotal)
+	assert.True(t, req.TaxTotal.IsZero())
+	assert.True(t, req.TotalPrice.IsZero())
+}
+
+func TestFlatTaxCalculator_Price_NegativeShipping(t *testing.T) {
+	calculator := usecases.FlatTaxCalculator{}
+	err := calculator.Price(&entities.OrderRequest{Currency: "USD", ShippingTotal: entities.MustParseMoney("-1.00")})
+	assert.Error(t, err)
+}
+
+func TestFlatTaxCalculator_Price_Overflow(t *testing.T) {
+	calculator := usecases.FlatTaxCalculator{TaxRate: 1700}
+	req := &entities.OrderRequest{
+		Currency:     "USD",
+		OrderDetails: []entities.OrderDetail{{Quantity: 10000, UnitPrice: entities.MoneyFromCents(math.MaxInt64 / 1000)}},
+	}
+
+	assert.ErrorIs(t, calculator.Price(req), entities.ErrMoneyRange)
+}
@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/adapters/repositories/cache"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/shayja/orders-service/pkg/utils"
+	"github.com/shayja/orders-service/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPrivacyUsecase_ExportUserData(t *testing.T) {
+	repo := new(mocks.MockPrivacyRepository)
+	now := time.Date(2024, 7, 31, 12, 0, 0, 0, time.UTC)
+	uc := &usecases.PrivacyUsecase{PrivacyRepo: repo, Now: func() time.Time { return now }}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+
+	orders := []entities.UserDataOrder{{Order: entities.Order{ID: "order-1", UserID: userID}}}
+	repo.On("ExportUserData", userID).Return(orders, nil)
+
+	bundle, err := uc.ExportUserData(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, &entities.UserDataExport{UserID: userID, GeneratedAt: now, Orders: orders}, bundle)
+
+	_, err = uc.ExportUserData("not-a-uuid")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+}
+
+func TestPrivacyUsecase_EraseUser(t *testing.T) {
+	repo := new(mocks.MockPrivacyRepository)
+	uc := &usecases.PrivacyUsecase{PrivacyRepo: repo}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+
+	_, err := uc.EraseUser(userID, "   ", "admin-1")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	_, err = uc.EraseUser("not-a-uuid", "Privacy request", "admin-1")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	// The user is recorded by its hash, and the orders get a fresh pseudonym
+	repo.On("EraseUser", userID, mock.MatchedBy(func(pseudonym string) bool {
+		return utils.IsValidUUID(pseudonym) && pseudonym != userID
+	}), mock.MatchedBy(func(entry *entities.ErasureRecord) bool {
+		return entry.SubjectHash == entities.ErasureSubjectHash(userID) && entry.Reason == "Privacy request #1234" && entry.ActorID == "admin-1"
+	})).Return([]string{"order-1"}, nil).Once()
+
+	entry, err := uc.EraseUser(userID, " Privacy request #1234 ", "admin-1")
+	assert.NoError(t, err)
+	assert.NotContains(t, entry.SubjectHash, userID)
+
+	repo.On("EraseUser", userID, mock.Anything, mock.Anything).Return([]string(nil), apperrors.ErrOrderOpen)
+	_, err = uc.EraseUser(userID, "Privacy request", "admin-1")
+	assert.ErrorIs(t, err, apperrors.ErrOrderOpen)
+}
+
+func TestPrivacyUsecase_EraseUserInvalidatesCachedOrders(t *testing.T) {
+	repo := new(mocks.MockPrivacyRepository)
+	orderRepo := new(mocks.MockOrderRepository)
+	cached := cache.NewOrderRepository(orderRepo, &cache.MemoryBackend{}, time.Minute)
+	uc := &usecases.PrivacyUsecase{PrivacyRepo: repo, OrderRepo: cached}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+	orderID := "6204037c-30e6-408b-8aaa-dd8219860b4b"
+
+	orderRepo.On("GetByID", orderID).Return(&entities.Order{ID: orderID, UserID: userID}, nil).Once()
+	repo.On("EraseUser", userID, mock.Anything, mock.Anything).Return([]string{orderID}, nil)
+	orderRepo.On("GetByID", orderID).Return(&entities.Order{ID: orderID, UserID: "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"}, nil).Once()
+
+	_, err := cached.GetByID(orderID)
+	assert.NoError(t, err)
+	_, err = uc.EraseUser(userID, "Privacy request", "admin-1")
+	assert.NoError(t, err)
+
+	// The cached order still had the user ID, the next lookup reads the pseudonym
+	order, err := cached.GetByID(orderID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, userID, order.UserID)
+	assert.Equal(t, int64(2), cached.Metrics.Misses.Value())
+	orderRepo.AssertExpectations(t)
+}
+
+func TestPrivacyUsecase_GetErasures(t *testing.T) {
+	repo := new(mocks.MockPrivacyRepository)
+	uc := &usecases.PrivacyUsecase{PrivacyRepo: repo}
+	userID := "451fa817-41f4-40cf-8dc2-c9f22aa98a4f"
+
+	// IDs are hashed case-insensitively
+	expected := []entities.ErasureRecord{{ID: "entry-1", Orders: 2}}
+	repo.On("GetErasures", entities.ErasureSubjectHash(userID)).Return(expected, nil)
+
+	erasures, err := uc.GetErasures("451FA817-41F4-40CF-8DC2-C9F22AA98A4F")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, erasures)
+}
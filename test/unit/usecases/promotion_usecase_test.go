@@ -0,0 +1,169 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type PromotionRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PromotionRepositoryMock) GetAll(page int) ([]*entities.Promotion, error) {
+	args := m.Called(page)
+	return args.Get(0).([]*entities.Promotion), args.Error(1)
+}
+
+func (m *PromotionRepositoryMock) GetByID(id string) (*entities.Promotion, error) {
+	args := m.Called(id)
+	return args.Get(0).(*entities.Promotion), args.Error(1)
+}
+
+func (m *PromotionRepositoryMock) GetByCode(code string) (*entities.Promotion, error) {
+	args := m.Called(code)
+	return args.Get(0).(*entities.Promotion), args.Error(1)
+}
+
+func (m *PromotionRepositoryMock) Create(promotion *entities.Promotion) (string, error) {
+	args := m.Called(promotion)
+	return args.String(0), args.Error(1)
+}
+
+func (m *PromotionRepositoryMock) Update(promotion *entities.Promotion) (*entities.Promotion, error) {
+	args := m.Called(promotion)
+	return args.Get(0).(*entities.Promotion), args.Error(1)
+}
+
+func (m *PromotionRepositoryMock) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *PromotionRepositoryMock) ForTenant(tenantID string) usecases.PromotionRepository {
+	return m
+}
+
+func TestOrderUsecase_Create_Coupon(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion entities.Promotion
+		discounts []string
+	}{
+		{
+			name:      "percentage",
+			promotion: entities.Promotion{Type: entities.PromotionPercentage, Percent: 15},
+			discounts: []string{"4.50", "3.00"},
+		},
+		{
+			name:      "fixed amount spread over the lines",
+			promotion: entities.Promotion{Type: entities.PromotionFixedAmount, AmountOff: entities.MustParseMoney("10.00"), Currency: "USD"},
+			discounts: []string{"6.00", "4.00"},
+		},
+		{
+			name:      "buy two get one for a single product",
+			promotion: entities.Promotion{Type: entities.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, ProductID: productA},
+			discounts: []string{"10.00", "0.00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promotionRepositoryMock := new(PromotionRepositoryMock)
+			orderRepositoryMock := new(OrderRepositoryMock)
+			orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, PromotionRepo: promotionRepositoryMock}
+
+			promotion := tt.promotion
+			promotion.ID, promotion.Code, promotion.Active = "promo-id", "SAVE", true
+			promotionRepositoryMock.On("GetByCode", "SAVE").Return(&promotion, nil)
+
+			req := newOrderRequest(withCoupon(" save "), withQuantity(3), withLine(productB, 1, "20.00"))
+			orderRepositoryMock.On("Create", req).Return("new-id", nil)
+
+			_, err := orderUsecase.Create(req)
+			assert.NoError(t, err)
+			assert.Equal(t, "promo-id", req.PromotionID)
+			for i, discount := range tt.discounts {
+				assert.Equal(t, entities.MustParseMoney(discount), req.OrderDetails[i].DiscountAmount)
+			}
+			discountTotal := entities.MustParseMoney(tt.discounts[0]).Add(entities.MustParseMoney(tt.discounts[1]))
+			assert.Equal(t, discountTotal, req.DiscountTotal)
+			assert.Equal(t, entities.MustParseMoney("50.00").Sub(discountTotal), req.TotalPrice)
+		})
+	}
+}
+
+func TestOrderUsecase_Create_CouponNotApplicable(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name      string
+		promotion entities.Promotion
+	}{
+		{name: "inactive", promotion: entities.Promotion{Type: entities.PromotionPercentage, Percent: 10}},
+		{name: "expired", promotion: entities.Promotion{Type: entities.PromotionPercentage, Percent: 10, Active: true, EndsAt: &past}},
+		{name: "other currency", promotion: entities.Promotion{Type: entities.PromotionFixedAmount, AmountOff: entities.MustParseMoney("5.00"), Currency: "EUR", Active: true}},
+		{name: "below minimum", promotion: entities.Promotion{Type: entities.PromotionPercentage, Percent: 10, MinOrderValue: entities.MustParseMoney("100.00"), Currency: "USD", Active: true}},
+		{name: "not enough items", promotion: entities.Promotion{Type: entities.PromotionBuyXGetY, BuyQuantity: 3, GetQuantity: 1, Active: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promotionRepositoryMock := new(PromotionRepositoryMock)
+			orderRepositoryMock := new(OrderRepositoryMock)
+			orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, PromotionRepo: promotionRepositoryMock}
+
+			promotion := tt.promotion
+			promotion.ID, promotion.Code = "promo-id", "SAVE"
+			promotionRepositoryMock.On("GetByCode", "SAVE").Return(&promotion, nil)
+
+			_, err := orderUsecase.Create(newOrderRequest(withCoupon("SAVE"), withQuantity(3), withLine(productB, 1, "20.00")))
+			assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+			orderRepositoryMock.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestOrderUsecase_Create_UnknownCoupon(t *testing.T) {
+	promotionRepositoryMock := new(PromotionRepositoryMock)
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, PromotionRepo: promotionRepositoryMock}
+
+	promotionRepositoryMock.On("GetByCode", "NOPE").Return((*entities.Promotion)(nil), apperrors.ErrPromotionNotFound)
+
+	_, err := orderUsecase.Create(newOrderRequest(withCoupon("nope"), withQuantity(3), withLine(productB, 1, "20.00")))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	orderRepositoryMock.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestPromotionUsecase_Create_Validation(t *testing.T) {
+	promotionRepositoryMock := new(PromotionRepositoryMock)
+	promotionUsecase := &usecases.PromotionUsecase{PromotionRepo: promotionRepositoryMock}
+
+	invalid := []entities.Promotion{
+		{Code: "X", Type: entities.PromotionPercentage, Percent: 10},
+		{Code: "SAVE10", Type: entities.PromotionPercentage, Percent: 120},
+		{Code: "SAVE10", Type: entities.PromotionFixedAmount, AmountOff: entities.MustParseMoney("10.00")},
+		{Code: "SAVE10", Type: entities.PromotionBuyXGetY, BuyQuantity: 2},
+		{Code: "SAVE10", Type: "free_lunch"},
+	}
+	for _, promotion := range invalid {
+		_, err := promotionUsecase.Create(&promotion)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidRequest, promotion)
+	}
+	promotionRepositoryMock.AssertNotCalled(t, "Create", mock.Anything)
+
+	promotion := &entities.Promotion{Code: "save10", Type: entities.PromotionPercentage, Percent: 10, Currency: "usd", AmountOff: entities.MustParseMoney("3.00")}
+	promotionRepositoryMock.On("Create", promotion).Return("promo-id", nil)
+	id, err := promotionUsecase.Create(promotion)
+	assert.NoError(t, err)
+	assert.Equal(t, "promo-id", id)
+	assert.Equal(t, "SAVE10", promotion.Code)
+	assert.Equal(t, "USD", promotion.Currency)
+	// Settings of other promotion types are cleared
+	assert.True(t, promotion.AmountOff.IsZero())
+}
@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ReportRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *ReportRepositoryMock) OrdersByPeriod(filter entities.ReportFilter) ([]entities.OrdersReportRow, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]entities.OrdersReportRow), args.Error(1)
+}
+
+func (m *ReportRepositoryMock) StatusCounts(filter entities.ReportFilter) ([]entities.StatusReportRow, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]entities.StatusReportRow), args.Error(1)
+}
+
+func (m *ReportRepositoryMock) TopProducts(filter entities.ReportFilter) ([]entities.ProductReportRow, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]entities.ProductReportRow), args.Error(1)
+}
+
+func (m *ReportRepositoryMock) ForTenant(tenantID string) usecases.ReportRepository {
+	return m
+}
+
+func TestReportUsecase_OrdersByPeriod_Defaults(t *testing.T) {
+	repo := new(ReportRepositoryMock)
+	now := time.Date(2024, 7, 31, 12, 0, 0, 0, time.UTC)
+	uc := &usecases.ReportUsecase{ReportRepo: repo, Now: func() time.Time { return now }}
+
+	// The last 30 days, per day
+	expected := entities.ReportFilter{From: now.AddDate(0, 0, -30), To: now, Interval: entities.ReportDay}
+	repo.On("OrdersByPeriod", expected).Return([]entities.OrdersReportRow{}, nil)
+
+	_, err := uc.OrdersByPeriod(entities.ReportFilter{})
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+
+	_, err = uc.OrdersByPeriod(entities.ReportFilter{Interval: "year"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+}
+
+func TestReportUsecase_DateRange(t *testing.T) {
+	repo := new(ReportRepositoryMock)
+	uc := &usecases.ReportUsecase{ReportRepo: repo}
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := uc.StatusCounts(entities.ReportFilter{From: from, To: from})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	_, err = uc.StatusCounts(entities.ReportFilter{From: from, To: from.AddDate(3, 0, 0)})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	repo.AssertNotCalled(t, "StatusCounts", mock.Anything)
+}
+
+func TestReportUsecase_TopProducts(t *testing.T) {
+	repo := new(ReportRepositoryMock)
+	uc := &usecases.ReportUsecase{ReportRepo: repo}
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	expected := entities.ReportFilter{From: from, To: to, Limit: 10, SortBy: entities.ReportByQuantity}
+	repo.On("TopProducts", expected).Return([]entities.ProductReportRow{{ProductID: "product-1", Quantity: 3}}, nil)
+
+	report, err := uc.TopProducts(entities.ReportFilter{From: from, To: to})
+	assert.NoError(t, err)
+	assert.Len(t, report, 1)
+
+	_, err = uc.TopProducts(entities.ReportFilter{From: from, To: to, SortBy: "margin"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	_, err = uc.TopProducts(entities.ReportFilter{From: from, To: to, Limit: 101})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+}
@@ -0,0 +1,186 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/shayja/orders-service/internal/adapters/payments"
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ReturnRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *ReturnRepositoryMock) Create(ret *entities.Return, actorID string) (string, error) {
+	args := m.Called(ret, actorID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *ReturnRepositoryMock) GetByID(id string) (*entities.Return, error) {
+	args := m.Called(id)
+	return args.Get(0).(*entities.Return), args.Error(1)
+}
+
+func (m *ReturnRepositoryMock) GetByOrderID(orderID string) ([]entities.Return, error) {
+	args := m.Called(orderID)
+	return args.Get(0).([]entities.Return), args.Error(1)
+}
+
+func (m *ReturnRepositoryMock) UpdateStatus(id string, from string, to string, actorID string) error {
+	args := m.Called(id, from, to, actorID)
+	return args.Error(0)
+}
+
+func (m *ReturnRepositoryMock) ForTenant(tenantID string) usecases.ReturnRepository {
+	return m
+}
+
+const (
+	returnOrderID = "6204037c-30e6-408b-8aaa-dd8219860b4b"
+	lineA         = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+	lineB         = "ab1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+)
+
+func newReturnUsecase(status int, previous []entities.Return) (*usecases.ReturnUsecase, *ReturnRepositoryMock) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderRepositoryMock.On("GetByID", returnOrderID).Return(&entities.Order{ID: returnOrderID, Status: status, Currency: "USD"}, nil)
+	orderRepositoryMock.On("GetOrderDetails", returnOrderID).Return([]entities.OrderDetail{
+		// 3 x 10.00, 1.00 off, 17% tax: 33.93 paid
+		{ID: lineA, Quantity: 3, UnitPrice: entities.MustParseMoney("10.00"), DiscountAmount: entities.MustParseMoney("1.00"), TaxAmount: entities.MustParseMoney("4.93")},
+		{ID: lineB, Quantity: 1, UnitPrice: entities.MustParseMoney("5.00")},
+	}, nil)
+	returnRepositoryMock := new(ReturnRepositoryMock)
+	returnRepositoryMock.On("GetByOrderID", returnOrderID).Return(previous, nil)
+	return &usecases.ReturnUsecase{ReturnRepo: returnRepositoryMock, OrderRepo: orderRepositoryMock}, returnRepositoryMock
+}
+
+func TestReturnUsecase_RequestReturn(t *testing.T) {
+	returnUsecase, returnRepositoryMock := newReturnUsecase(entities.OrderStatusCompleted, []entities.Return{})
+	returnRepositoryMock.On("Create", mock.Anything, "actor-id").Return("return-id", nil)
+
+	ret, err := returnUsecase.RequestReturn(returnOrderID, &entities.ReturnRequest{
+		Reason: "Wrong size",
+		Items:  []entities.ReturnItemRequest{{OrderDetailID: lineA, Quantity: 1}},
+	}, "actor-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "return-id", ret.ID)
+	assert.Equal(t, entities.ReturnRequested, ret.Status)
+	// A third of 33.93
+	assert.Equal(t, entities.MustParseMoney("11.31"), ret.RefundAmount)
+}
+
+func TestReturnUsecase_RequestReturn_LastUnitsTakeTheRemainder(t *testing.T) {
+	previous := []entities.Return{{Items: []entities.ReturnItem{{OrderDetailID: lineA, Quantity: 1, RefundAmount: entities.MustParseMoney("11.31")}}}}
+	returnUsecase, returnRepositoryMock := newReturnUsecase(entities.OrderStatusCompleted, previous)
+	returnRepositoryMock.On("Create", mock.Anything, "").Return("return-id", nil)
+
+	ret, err := returnUsecase.RequestReturn(returnOrderID, &entities.ReturnRequest{
+		Items: []entities.ReturnItemRequest{{OrderDetailID: lineA, Quantity: 2}, {OrderDetailID: lineB, Quantity: 1}},
+	}, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, entities.MustParseMoney("22.62"), ret.Items[0].RefundAmount)
+	assert.Equal(t, entities.MustParseMoney("5.00"), ret.Items[1].RefundAmount)
+	assert.Equal(t, entities.MustParseMoney("27.62"), ret.RefundAmount)
+}
+
+func TestReturnUsecase_RequestReturn_Invalid(t *testing.T) {
+	previous := []entities.Return{{Items: []entities.ReturnItem{{OrderDetailID: lineA, Quantity: 2}}}}
+	returnUsecase, returnRepositoryMock := newReturnUsecase(entities.OrderStatusCompleted, previous)
+
+	// Only one unit is left to return
+	_, err := returnUsecase.RequestReturn(returnOrderID, &entities.ReturnRequest{
+		Items: []entities.ReturnItemRequest{{OrderDetailID: lineA, Quantity: 2}},
+	}, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	// Not a line item of the order
+	_, err = returnUsecase.RequestReturn(returnOrderID, &entities.ReturnRequest{
+		Items: []entities.ReturnItemRequest{{OrderDetailID: "063d0ff7-e17e-4957-8d92-a988caeda8a1", Quantity: 1}},
+	}, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	returnRepositoryMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// Only completed orders can be returned
+	returnUsecase, _ = newReturnUsecase(entities.OrderStatusProcessing, nil)
+	_, err = returnUsecase.RequestReturn(returnOrderID, &entities.ReturnRequest{
+		Items: []entities.ReturnItemRequest{{OrderDetailID: lineB, Quantity: 1}},
+	}, "")
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotCompleted)
+}
+
+func TestReturnUsecase_AdvanceReturn_Refund(t *testing.T) {
+	gateway := &payments.FakeGateway{}
+	ref, err := gateway.Authorize(returnOrderID, entities.MustParseMoney("38.93"), "USD")
+	assert.NoError(t, err)
+	assert.NoError(t, gateway.Capture(ref, entities.MustParseMoney("38.93")))
+
+	payment := &entities.Payment{ID: "payment-id", OrderID: returnOrderID, Provider: "fake", ProviderRef: ref, Status: entities.PaymentCaptured,
+		AuthorizedAmount: entities.MustParseMoney("38.93"), CapturedAmount: entities.MustParseMoney("38.93")}
+	paymentRepositoryMock := new(PaymentRepositoryMock)
+	paymentRepositoryMock.On("GetByOrderID", returnOrderID).Return([]*entities.Payment{payment}, nil)
+	paymentRepositoryMock.On("ApplyEvent", payment, entities.PaymentCaptured, "return_return-id").Return(true, nil)
+
+	returnRepositoryMock := new(ReturnRepositoryMock)
+	ret := &entities.Return{ID: "return-id", OrderID: returnOrderID, Status: entities.ReturnRequested, RefundAmount: entities.MustParseMoney("11.31")}
+	returnRepositoryMock.On("GetByID", "return-id").Return(ret, nil)
+	returnRepositoryMock.On("UpdateStatus", "return-id", mock.Anything, mock.Anything, "admin-id").Return(nil)
+
+	returnUsecase := &usecases.ReturnUsecase{ReturnRepo: returnRepositoryMock, PaymentRepo: paymentRepositoryMock, Gateway: gateway}
+
+	// Items must be received before they are refunded
+	_, err = returnUsecase.AdvanceReturn(returnOrderID, "return-id", entities.ReturnRefunded, "admin-id")
+	assert.ErrorIs(t, err, apperrors.ErrReturnState)
+
+	_, err = returnUsecase.AdvanceReturn(returnOrderID, "return-id", entities.ReturnReceived, "admin-id")
+	assert.NoError(t, err)
+	res, err := returnUsecase.AdvanceReturn(returnOrderID, "return-id", entities.ReturnRefunded, "admin-id")
+	assert.NoError(t, err)
+	assert.Equal(t, entities.ReturnRefunded, res.Status)
+	assert.Equal(t, entities.PaymentRefunded, payment.Status)
+	assert.Equal(t, entities.MustParseMoney("11.31"), payment.RefundedAmount)
+	paymentRepositoryMock.AssertExpectations(t)
+}
+
+func TestReturnUsecase_AdvanceReturn_RefundExceedsPayment(t *testing.T) {
+	// Most of the captured amount was already refunded
+	payment := &entities.Payment{ID: "payment-id", OrderID: returnOrderID, Provider: "fake", ProviderRef: "ref", Status: entities.PaymentRefunded,
+		AuthorizedAmount: entities.MustParseMoney("38.93"), CapturedAmount: entities.MustParseMoney("38.93"), RefundedAmount: entities.MustParseMoney("30.00")}
+	paymentRepositoryMock := new(PaymentRepositoryMock)
+	paymentRepositoryMock.On("GetByOrderID", returnOrderID).Return([]*entities.Payment{payment}, nil)
+
+	returnRepositoryMock := new(ReturnRepositoryMock)
+	ret := &entities.Return{ID: "return-id", OrderID: returnOrderID, Status: entities.ReturnReceived, RefundAmount: entities.MustParseMoney("11.31")}
+	returnRepositoryMock.On("GetByID", "return-id").Return(ret, nil)
+
+	returnUsecase := &usecases.ReturnUsecase{ReturnRepo: returnRepositoryMock, PaymentRepo: paymentRepositoryMock, Gateway: &payments.FakeGateway{}}
+
+	_, err := returnUsecase.AdvanceReturn(returnOrderID, "return-id", entities.ReturnRefunded, "admin-id")
+
+	assert.ErrorIs(t, err, apperrors.ErrPaymentState)
+	paymentRepositoryMock.AssertNotCalled(t, "ApplyEvent", mock.Anything, mock.Anything, mock.Anything)
+	returnRepositoryMock.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderUsecase_GetByID_Returns(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderRepositoryMock.On("GetByID", returnOrderID).Return(&entities.Order{ID: returnOrderID, Currency: "USD"}, nil)
+	returnRepositoryMock := new(ReturnRepositoryMock)
+	returnRepositoryMock.On("GetByOrderID", returnOrderID).Return([]entities.Return{
+		{Status: entities.ReturnRefunded, RefundAmount: entities.MustParseMoney("11.31")},
+		{Status: entities.ReturnRequested, RefundAmount: entities.MustParseMoney("5.00")},
+	}, nil)
+	orderUsecase := &usecases.OrderUsecase{OrderRepo: orderRepositoryMock, ReturnRepo: returnRepositoryMock}
+
+	order, err := orderUsecase.GetByID(returnOrderID)
+
+	assert.NoError(t, err)
+	assert.Len(t, order.Returns, 2)
+	assert.Equal(t, entities.MustParseMoney("11.31"), order.RefundedTotal)
+}
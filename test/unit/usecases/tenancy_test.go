@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/shayja/orders-service/internal/entities"
+	apperrors "github.com/shayja/orders-service/internal/errors"
+	"github.com/shayja/orders-service/internal/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// tenantOrderRepository records the tenant the order repository was scoped to.
+type tenantOrderRepository struct {
+	*OrderRepositoryMock
+	tenantID string
+}
+
+func (m *tenantOrderRepository) ForTenant(tenantID string) usecases.OrderRepository {
+	return &tenantOrderRepository{OrderRepositoryMock: m.OrderRepositoryMock, tenantID: tenantID}
+}
+
+func TestOrderUsecase_ForTenant(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{
+		OrderRepo:         &tenantOrderRepository{OrderRepositoryMock: orderRepositoryMock},
+		AllowedCurrencies: []string{"USD", "EUR"},
+		Tenants: map[string]entities.TenantConfig{
+			"acme": {PageSize: 50, AllowedCurrencies: []string{"ILS"}},
+		},
+	}
+
+	scoped := orderUsecase.ForTenant("acme")
+	assert.Equal(t, "acme", scoped.OrderRepo.(*tenantOrderRepository).tenantID)
+	assert.Equal(t, []string{"ILS"}, scoped.AllowedCurrencies)
+	// The usecase of the service is left as is
+	assert.Equal(t, []string{"USD", "EUR"}, orderUsecase.AllowedCurrencies)
+
+	// Pages hold the page size of the tenant
+	filter := entities.OrderFilter{UserID: "test-user-id"}
+	orderRepositoryMock.On("GetAllOrders", 1, entities.OrderFilter{UserID: "test-user-id", PageSize: 50}).Return([]*entities.Order{}, nil)
+	_, err := scoped.GetOrders(1, filter)
+	assert.NoError(t, err)
+
+	// Orders are placed in the currencies of the tenant
+	_, err = scoped.Create(&entities.OrderRequest{Currency: "USD"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+
+	// Tenants without settings keep the settings of the service
+	other := orderUsecase.ForTenant("globex")
+	assert.Equal(t, "globex", other.OrderRepo.(*tenantOrderRepository).tenantID)
+	assert.Equal(t, []string{"USD", "EUR"}, other.AllowedCurrencies)
+	assert.Zero(t, other.PageSize)
+}
+
+func TestOrderUsecase_UpdateStatus_Transitions(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{
+		OrderRepo: orderRepositoryMock,
+		StatusTransitions: entities.StatusTransitions{
+			entities.OrderStatusPending:    {entities.OrderStatusProcessing, entities.OrderStatusCancelled},
+			entities.OrderStatusProcessing: {entities.OrderStatusCompleted},
+		},
+	}
+
+	orderRepositoryMock.On("GetByID", "completed-id").
+		Return(&entities.Order{ID: "completed-id", Status: entities.OrderStatusCompleted, Version: 4}, nil)
+	_, err := orderUsecase.UpdateStatus("completed-id", entities.OrderStatusPending, 0, "")
+	assert.ErrorIs(t, err, apperrors.ErrStatusTransition)
+
+	// An allowed change fails when the order moved since it was checked
+	orderRepositoryMock.On("GetByID", "pending-id").
+		Return(&entities.Order{ID: "pending-id", Status: entities.OrderStatusPending, Version: 2}, nil)
+	orderRepositoryMock.On("UpdateStatus", "pending-id", entities.OrderStatusProcessing, 2, "").
+		Return(&entities.Order{ID: "pending-id", Status: entities.OrderStatusProcessing, Version: 3}, nil)
+	order, err := orderUsecase.UpdateStatus("pending-id", entities.OrderStatusProcessing, 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, order.Version)
+
+	_, err = orderUsecase.UpdateStatus("pending-id", entities.OrderStatusProcessing, 1, "")
+	assert.ErrorIs(t, err, apperrors.ErrVersionMismatch)
+
+	orderRepositoryMock.On("GetByID", "missing-id").Return(&entities.Order{}, nil)
+	_, err = orderUsecase.UpdateStatus("missing-id", entities.OrderStatusCancelled, 0, "")
+	assert.ErrorIs(t, err, apperrors.ErrOrderNotFound)
+
+	orderRepositoryMock.AssertNumberOfCalls(t, "UpdateStatus", 1)
+}
+
+func TestOrderUsecase_UpdateStatusBulk_Transitions(t *testing.T) {
+	orderRepositoryMock := new(OrderRepositoryMock)
+	orderUsecase := &usecases.OrderUsecase{
+		OrderRepo:         orderRepositoryMock,
+		StatusTransitions: entities.StatusTransitions{entities.OrderStatusPending: {entities.OrderStatusCancelled}},
+	}
+
+	orderRepositoryMock.On("GetByID", "pending-id").
+		Return(&entities.Order{ID: "pending-id", Status: entities.OrderStatusPending, Version: 1}, nil)
+	orderRepositoryMock.On("GetByID", "cancelled-id").
+		Return(&entities.Order{ID: "cancelled-id", Status: entities.OrderStatusCancelled, Version: 1}, nil)
+
+	res, err := orderUsecase.UpdateStatusBulk(&entities.BulkStatusRequest{
+		Atomic: true,
+		Updates: []entities.StatusUpdate{
+			{ID: "pending-id", Status: entities.OrderStatusCancelled},
+			{ID: "cancelled-id", Status: entities.OrderStatusPending},
+		},
+	}, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	assert.Nil(t, res)
+	orderRepositoryMock.AssertNotCalled(t, "UpdateStatusBulk", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestValidateTenants(t *testing.T) {
+	assert.NoError(t, usecases.ValidateTenants(map[string]entities.TenantConfig{
+		"acme": {PageSize: 50, AllowedCurrencies: []string{"USD"}, StatusTransitions: entities.StatusTransitions{1: {2, 4}}},
+	}))
+	assert.Error(t, usecases.ValidateTenants(map[string]entities.TenantConfig{"*": {}}))
+	assert.Error(t, usecases.ValidateTenants(map[string]entities.TenantConfig{"acme": {PageSize: entities.MaxPageSize + 1}}))
+	assert.Error(t, usecases.ValidateTenants(map[string]entities.TenantConfig{"acme": {AllowedCurrencies: []string{"XXX"}}}))
+	assert.Error(t, usecases.ValidateTenants(map[string]entities.TenantConfig{"acme": {StatusTransitions: entities.StatusTransitions{1: {9}}}}))
+}